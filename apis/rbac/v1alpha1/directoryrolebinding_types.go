@@ -14,6 +14,8 @@ type DirectoryRoleBindingSpec struct {
 // DirectoryRoleBindingStatus defines the observed state of DirectoryRoleBinding
 type DirectoryRoleBindingStatus struct{}
 
+// +genclient
+// +genclient:noStatus
 // +kubebuilder:object:root=true
 // +kubebuilder:storageversion
 
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -13,26 +15,57 @@ import (
 	"github.com/mitchellh/mapstructure"
 	"github.com/prometheus/client_golang/prometheus"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/gocardless/theatre/v2/pkg/registry"
+	"github.com/gocardless/theatre/v2/pkg/vault"
 )
 
 const EnvconsulInjectorFQDN = "envconsul-injector.vault.crd.gocardless.com"
 
+const (
+	// injectionModeExec runs containers' commands with theatre-envconsul, which sets
+	// secrets in the environment of their process
+	injectionModeExec = "exec"
+	// injectionModeInitContainer resolves secrets in an init container, which writes
+	// them to a volume shared with the container, leaving its command untouched
+	injectionModeInitContainer = "init-container"
+)
+
 type EnvconsulInjector struct {
-	client  client.Client
-	logger  logr.Logger
-	decoder *admission.Decoder
-	opts    EnvconsulInjectorOptions
+	client    client.Client
+	apiReader client.Reader
+	logger    logr.Logger
+	decoder   *admission.Decoder
+	opts      EnvconsulInjectorOptions
+	images    imageConfigGetter
 }
 
-func NewEnvconsulInjector(c client.Client, logger logr.Logger, opts EnvconsulInjectorOptions) *EnvconsulInjector {
+// imageConfigGetter looks up the configuration of images, for containers that rely on
+// the entrypoint of their image
+type imageConfigGetter interface {
+	ImageConfig(ctx context.Context, image string, dockerConfigs ...[]byte) (registry.ImageConfig, error)
+}
+
+// NewEnvconsulInjector creates an injector, which reads the image pull secrets of pods
+// with apiReader when ReadImagePullSecrets is set, as watching every secret in the
+// cluster would be both expensive and require more access than we need.
+func NewEnvconsulInjector(c client.Client, apiReader client.Reader, logger logr.Logger, opts EnvconsulInjectorOptions) *EnvconsulInjector {
 	return &EnvconsulInjector{
-		client: c,
-		logger: logger,
-		opts:   opts,
+		client:    c,
+		apiReader: apiReader,
+		logger:    logger,
+		opts:      opts,
+		images: &registry.Client{
+			HTTPClient:      &http.Client{Timeout: 5 * time.Second},
+			CredentialsFile: opts.RegistryCredentialsFile,
+			CacheTTL:        opts.ImageCacheTTL,
+		},
 	}
 }
 
@@ -44,7 +77,13 @@ func (e *EnvconsulInjector) InjectDecoder(d *admission.Decoder) error {
 type EnvconsulInjectorOptions struct {
 	Image                       string           // image of theatre to use when constructing pod
 	InstallPath                 string           // location of vault installation directory
+	SecretsPath                 string           // location of resolved secrets, for pods using init containers
+	RegistryCredentialsFile     string           // docker config file with credentials for looking up image entrypoints
+	ReadImagePullSecrets        bool             // look up image entrypoints with the credentials of pods' image pull secrets
+	ImageCacheTTL               time.Duration    // how long image entrypoints are cached for
+	ImageLookupTimeout          time.Duration    // bounds the time spent looking up the entrypoints of a pod's images
 	NamespaceLabel              string           // namespace label that enables webhook to operate on
+	RejectDisabledNamespaces    bool             // reject, rather than skip, annotated pods in namespaces without the label
 	VaultConfigMapKey           client.ObjectKey // reference to the vault config configMap
 	ServiceAccountTokenFile     string           // mount path of our projected service account token
 	ServiceAccountTokenExpiry   time.Duration    // Kubelet expiry for the service account token
@@ -81,11 +120,18 @@ var (
 		},
 		podLabels,
 	)
+	namespaceRejectedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "theatre_vault_envconsul_injector_namespace_rejected_total",
+			Help: "Count of annotated pods skipped or rejected by the webhook, as their namespace isn't enabled",
+		},
+		podLabels,
+	)
 )
 
 func init() {
 	// Register custom metrics with the global controller runtime prometheus registry
-	metrics.Registry.MustRegister(handleTotal, mutateTotal, skipTotal, errorsTotal)
+	metrics.Registry.MustRegister(handleTotal, mutateTotal, skipTotal, errorsTotal, namespaceRejectedTotal)
 }
 
 func (i *EnvconsulInjector) Handle(ctx context.Context, req admission.Request) (resp admission.Response) {
@@ -100,6 +146,7 @@ func (i *EnvconsulInjector) Handle(ctx context.Context, req admission.Request) (
 			mutateTotal.With(labels).Add(0)
 			skipTotal.With(labels).Add(0)
 			errorsTotal.With(labels).Add(0)
+			namespaceRejectedTotal.With(labels).Add(0)
 		}
 
 		// Catch any Allowed=false responses, as this means we've failed to accept this pod
@@ -136,6 +183,50 @@ func (i *EnvconsulInjector) Handle(ctx context.Context, req admission.Request) (
 		"pod_name", pod.Name,
 	)
 
+	// Namespaces are read from the manager's cache, so this doesn't cost a request to
+	// the API server for each pod
+	ns := &corev1.Namespace{}
+	if err := i.client.Get(ctx, client.ObjectKey{Name: pod.Namespace}, ns); err != nil {
+		logger.Info("namespace lookup error", "event", "namespace.lookup", "error", err)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	// Pods are only injected in namespaces that have opted in with our label. Usually the
+	// webhook's namespace selector stops us from seeing any other pods, but without it we
+	// can reject pods that would otherwise start without their secrets.
+	if !i.namespaceEnabled(ns) {
+		namespaceRejectedTotal.With(labels).Inc()
+		if i.opts.RejectDisabledNamespaces {
+			logger.Info("rejecting pod in namespace without label", "event", "pod.rejected", "label", i.opts.NamespaceLabel)
+			return admission.Denied(fmt.Sprintf(
+				"namespace %s must be labelled %s=enabled for pods to use the %s/configs annotation",
+				pod.Namespace, i.opts.NamespaceLabel, EnvconsulInjectorFQDN,
+			))
+		}
+
+		logger.Info("skipping pod in namespace without label", "event", "pod.skipped", "label", i.opts.NamespaceLabel)
+		skipTotal.With(labels).Inc()
+		return admission.Allowed("namespace not enabled")
+	}
+
+	superviseAction, err := parseSuperviseAction(*pod)
+	if err != nil {
+		logger.Info("invalid annotation", "event", "pod.invalid", "error", err)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	mode, err := parseInjectionMode(*pod)
+	if err != nil {
+		logger.Info("invalid annotation", "event", "pod.invalid", "error", err)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if mode == injectionModeInitContainer && superviseAction != "" {
+		err := fmt.Errorf("pods can't be supervised when their secrets are resolved by init containers")
+		logger.Info("invalid annotation", "event", "pod.invalid", "error", err)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
 	mutateTotal.With(labels).Inc() // we're committed to mutating this pod now
 
 	vaultConfigMap := &corev1.ConfigMap{}
@@ -149,7 +240,43 @@ func (i *EnvconsulInjector) Handle(ctx context.Context, req admission.Request) (
 		return admission.Errored(http.StatusInternalServerError, err)
 	}
 
-	mutatedPod := podInjector{EnvconsulInjectorOptions: i.opts, vaultConfig: vaultConfig}.Inject(*pod)
+	if vaultConfig.AllowedOverrides != "" {
+		// Pod annotations take precedence over those of their namespace
+		overrides := parseOverrides(ns.Annotations)
+		for key, value := range parseOverrides(pod.Annotations) {
+			overrides[key] = value
+		}
+
+		vaultConfig, err = vaultConfig.withOverrides(overrides)
+		if err != nil {
+			logger.Info("invalid vault config override", "event", "pod.invalid", "error", err)
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	} else if overrides := parseOverrides(pod.Annotations); len(overrides) > 0 {
+		err := fmt.Errorf("vault config doesn't allow overrides, but pod overrides %s", strings.Join(sortedKeys(overrides), ", "))
+		logger.Info("invalid vault config override", "event", "pod.invalid", "error", err)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if _, err := parseAuthConfig(*pod, vaultConfig); err != nil {
+		logger.Info("invalid auth config", "event", "pod.invalid", "error", err)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// Containers run by theatre-envconsul need their whole command given to it, which
+	// isn't in the pod spec for those that rely on the entrypoint of their image
+	var commands map[string][]string
+	if mode == injectionModeExec {
+		// Registries may be unavailable or need credentials that only nodes have, so
+		// containers whose commands can't be resolved run their command and args as
+		// given, rather than rejecting the pod
+		commands, err = i.resolveCommands(ctx, *pod)
+		if err != nil {
+			logger.Info("failed to look up image entrypoints, using container commands as given", "event", "image.lookup", "error", err)
+		}
+	}
+
+	mutatedPod := podInjector{EnvconsulInjectorOptions: i.opts, vaultConfig: vaultConfig, commands: commands}.Inject(*pod)
 	if mutatedPod == nil {
 		logger.Info("no annotation found during inject - this should never occur", "event", "pod.skipped", "msg")
 		return admission.Allowed("no annotation found")
@@ -162,21 +289,212 @@ func (i *EnvconsulInjector) Handle(ctx context.Context, req admission.Request) (
 	return admission.PatchResponseFromRaw(req.Object.Raw, mutatedPodBytes)
 }
 
+// resolveCommands returns the commands of injected containers that don't set one, by
+// looking up the entrypoint and command of their image. Kubernetes runs the entrypoint
+// of an image with the container's args if it has any, or the image's command if not.
+// Lookups happen while the pod is being created, so together they're bounded by
+// ImageLookupTimeout to leave the webhook time to respond. The commands that could be
+// resolved are returned along with an error for those that couldn't.
+func (i *EnvconsulInjector) resolveCommands(ctx context.Context, pod corev1.Pod) (map[string][]string, error) {
+	if i.opts.ImageLookupTimeout > 0 {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, i.opts.ImageLookupTimeout)
+		defer cancel()
+	}
+
+	var (
+		dockerConfigs [][]byte
+		errs          []error
+	)
+	containerConfigs := parseContainerConfigs(pod)
+	commands := map[string][]string{}
+	for _, container := range pod.Spec.Containers {
+		if _, ok := containerConfigs[container.Name]; !ok || len(container.Command) > 0 {
+			continue
+		}
+
+		// Only read the pull secrets once we know that we need them. Without them,
+		// images are looked up with the registry credentials file, or anonymously.
+		if dockerConfigs == nil {
+			dockerConfigs = [][]byte{}
+			if i.opts.ReadImagePullSecrets {
+				configs, err := i.getDockerConfigs(ctx, pod)
+				if err != nil {
+					errs = append(errs, err)
+				} else {
+					dockerConfigs = configs
+				}
+			}
+		}
+
+		config, err := i.images.ImageConfig(ctx, container.Image, dockerConfigs...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to look up entrypoint of image %s: %w", container.Image, err))
+			continue
+		}
+
+		command := append([]string{}, config.Entrypoint...)
+		if len(container.Args) > 0 {
+			command = append(command, container.Args...)
+		} else {
+			command = append(command, config.Cmd...)
+		}
+
+		if len(command) == 0 {
+			errs = append(errs, fmt.Errorf("image %s has no entrypoint or command, so container %s must set one", container.Image, container.Name))
+			continue
+		}
+
+		commands[container.Name] = command
+	}
+
+	return commands, utilerrors.NewAggregate(errs)
+}
+
+// getDockerConfigs returns the Docker configs of the pod's image pull secrets, which
+// include those of its service account by the time we see it. Secrets that don't exist
+// or aren't of a Docker config type are skipped, as they would be by the kubelet.
+func (i *EnvconsulInjector) getDockerConfigs(ctx context.Context, pod corev1.Pod) ([][]byte, error) {
+	dockerConfigs := [][]byte{}
+	for _, ref := range pod.Spec.ImagePullSecrets {
+		secret := &corev1.Secret{}
+		if err := i.apiReader.Get(ctx, client.ObjectKey{Namespace: pod.Namespace, Name: ref.Name}, secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get image pull secret %s: %w", ref.Name, err)
+		}
+
+		switch secret.Type {
+		case corev1.SecretTypeDockerConfigJson:
+			dockerConfigs = append(dockerConfigs, secret.Data[corev1.DockerConfigJsonKey])
+		case corev1.SecretTypeDockercfg:
+			// The legacy format is the auths of the current one, without the wrapper
+			dockerConfigs = append(dockerConfigs, []byte(fmt.Sprintf(`{"auths":%s}`, secret.Data[corev1.DockerConfigKey])))
+		}
+	}
+
+	return dockerConfigs, nil
+}
+
+// namespaceEnabled returns true if the namespace has opted in to injection, by having
+// our label set to enabled. Every namespace is enabled if no label is configured.
+func (i *EnvconsulInjector) namespaceEnabled(ns *corev1.Namespace) bool {
+	if i.opts.NamespaceLabel == "" {
+		return true
+	}
+
+	return ns.ObjectMeta.Labels[i.opts.NamespaceLabel] == "enabled"
+}
+
 // vaultConfig specifies the structure we expect to find in a cluster-global namespace,
 // which we intend to be provisioned as part of whatever process generates the auth
 // backend in Vault.
 //
 // If we can't parse the configmap into this structure, we should fail our webhook.
 type vaultConfig struct {
-	Address               string `mapstructure:"address"`
+	Address string `mapstructure:"address"`
+	// Namespace is the Vault Enterprise namespace that secrets are read from, and
+	// auth methods logged into
+	Namespace string `mapstructure:"namespace"`
+	// AuthMethod is the default auth method for pods, which can be overridden by
+	// annotations. It defaults to kubernetes, for which AuthMountPath and AuthRole
+	// are used.
+	AuthMethod            string `mapstructure:"auth_method"`
 	AuthMountPath         string `mapstructure:"auth_mount_path"`
 	AuthRole              string `mapstructure:"auth_role"`
+	AuthJWTMountPath      string `mapstructure:"auth_jwt_mount_path"`
+	AuthJWTRole           string `mapstructure:"auth_jwt_role"`
+	AuthAppRoleMountPath  string `mapstructure:"auth_approle_mount_path"`
 	SecretMountPathPrefix string `mapstructure:"secret_mount_path_prefix"`
+	// AllowedOverrides is a comma separated list of the keys of this config that
+	// namespaces and pods can override with annotations
+	AllowedOverrides string `mapstructure:"allowed_overrides"`
 }
 
 func newVaultConfig(cfgmap *corev1.ConfigMap) (vaultConfig, error) {
 	var cfg vaultConfig
-	return cfg, mapstructure.Decode(cfgmap.Data, &cfg)
+	if err := mapstructure.Decode(cfgmap.Data, &cfg); err != nil {
+		return cfg, err
+	}
+
+	keys := vaultConfigKeys()
+	for key := range cfg.allowedOverrides() {
+		if !keys[key] {
+			return cfg, fmt.Errorf("allowed_overrides contains %q, which isn't a key that can be overridden", key)
+		}
+	}
+
+	return cfg, nil
+}
+
+// vaultConfigKeys returns the keys of the vault config that can be overridden, which is
+// all of them other than the list of what is allowed to be overridden
+func vaultConfigKeys() map[string]bool {
+	keys := map[string]bool{}
+	configType := reflect.TypeOf(vaultConfig{})
+	for idx := 0; idx < configType.NumField(); idx++ {
+		keys[configType.Field(idx).Tag.Get("mapstructure")] = true
+	}
+
+	delete(keys, "allowed_overrides")
+	return keys
+}
+
+func (c vaultConfig) allowedOverrides() map[string]bool {
+	allowed := map[string]bool{}
+	for _, key := range strings.Split(c.AllowedOverrides, ",") {
+		if key := strings.TrimSpace(key); key != "" {
+			allowed[key] = true
+		}
+	}
+
+	return allowed
+}
+
+// withOverrides returns a copy of the config with the given keys overridden, which
+// fails if any of them aren't in the allowed overrides.
+func (c vaultConfig) withOverrides(overrides map[string]string) (vaultConfig, error) {
+	allowed := c.allowedOverrides()
+	for _, key := range sortedKeys(overrides) {
+		if !allowed[key] {
+			return c, fmt.Errorf("overriding %s of the vault config isn't allowed", key)
+		}
+	}
+
+	if err := mapstructure.Decode(overrides, &c); err != nil {
+		return c, err
+	}
+
+	return c, nil
+}
+
+// parseOverrides extracts the keys of the vault config that pod or namespace annotations
+// override, such as the Vault namespace:
+//
+//   envconsul-injector.vault.crd.gocardless.com/override.namespace: payments
+//
+// Overrides are only applied if the vault config allows them.
+func parseOverrides(annotations map[string]string) map[string]string {
+	prefix := fmt.Sprintf("%s/override.", EnvconsulInjectorFQDN)
+	overrides := map[string]string{}
+	for name, value := range annotations {
+		if key := strings.TrimPrefix(name, prefix); key != name {
+			overrides[key] = strings.TrimSpace(value)
+		}
+	}
+
+	return overrides
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
 }
 
 // podInjector isolates the logic around injecting theatre-envconsul away from anything to
@@ -185,6 +503,9 @@ func newVaultConfig(cfgmap *corev1.ConfigMap) (vaultConfig, error) {
 type podInjector struct {
 	EnvconsulInjectorOptions
 	vaultConfig
+	// commands of containers that rely on the entrypoint of their image, which have been
+	// looked up, by container name
+	commands map[string][]string
 }
 
 // Inject configures the given pod to use theatre-envconsul. If it returns nil, it's
@@ -245,13 +566,40 @@ func (i podInjector) Inject(pod corev1.Pod) *corev1.Pod {
 
 	secretMountPathPrefix := path.Join(i.vaultConfig.SecretMountPathPrefix, pod.Namespace, pod.Spec.ServiceAccountName)
 
+	// Annotations are validated before injection, so any errors can be ignored here
+	superviseAction, _ := parseSuperviseAction(pod)
+	auth, _ := parseAuthConfig(pod, i.vaultConfig)
+	mode, _ := parseInjectionMode(pod)
+
+	if mode == injectionModeInitContainer {
+		mutatedPod.Spec.Volumes = append(
+			mutatedPod.Spec.Volumes,
+			// Resolved secrets, which are kept in memory so they're never written to disk
+			corev1.Volume{
+				Name: "theatre-envconsul-secrets",
+				VolumeSource: corev1.VolumeSource{
+					EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory},
+				},
+			},
+		)
+	}
+
 	for idx, container := range mutatedPod.Spec.Containers {
 		containerConfigPath, ok := containerConfigs[container.Name]
 		if !ok {
 			continue
 		}
 
-		mutatedPod.Spec.Containers[idx] = i.configureContainer(container, containerConfigPath, secretMountPathPrefix)
+		if mode == injectionModeInitContainer {
+			mutatedPod.Spec.InitContainers = append(
+				mutatedPod.Spec.InitContainers,
+				i.buildResolveContainer(container, containerConfigPath, secretMountPathPrefix, auth),
+			)
+			mutatedPod.Spec.Containers[idx] = i.mountSecrets(container)
+			continue
+		}
+
+		mutatedPod.Spec.Containers[idx] = i.configureContainer(container, containerConfigPath, secretMountPathPrefix, superviseAction, auth)
 	}
 
 	return mutatedPod
@@ -288,6 +636,144 @@ func parseContainerConfigs(pod corev1.Pod) map[string]string {
 	return containerConfigs
 }
 
+// parseSuperviseAction extracts the optional annotation that opts the pod into
+// theatre-envconsul supervising its containers, which keeps their secrets up to
+// date for as long as they run.
+//
+//   envconsul-injector.vault.crd.gocardless.com/supervise: SIGHUP
+//
+// The value is the signal sent to containers when their secrets change, or
+// 'restart' to restart them instead. A value of 'true' uses the default signal,
+// while 'false' or no annotation disables supervision, which returns an empty
+// action.
+func parseSuperviseAction(pod corev1.Pod) (string, error) {
+	action := strings.TrimSpace(pod.Annotations[fmt.Sprintf("%s/supervise", EnvconsulInjectorFQDN)])
+	switch action {
+	case "", "false":
+		return "", nil
+	case "true":
+		return "SIGHUP", nil
+	}
+
+	if _, err := vault.ParseSuperviseAction(action); err != nil {
+		return action, fmt.Errorf("invalid %s/supervise annotation: %w", EnvconsulInjectorFQDN, err)
+	}
+
+	return action, nil
+}
+
+// parseInjectionMode extracts the optional annotation that chooses how secrets are
+// provided to containers:
+//
+//   envconsul-injector.vault.crd.gocardless.com/injection-mode: init-container
+//
+// By default, containers have their command run by theatre-envconsul, which sets
+// secrets in its environment. With init-container, secrets are instead resolved by an
+// init container for each container, and written to a volume shared with it, which
+// suits containers whose command can't be changed.
+func parseInjectionMode(pod corev1.Pod) (string, error) {
+	mode := strings.TrimSpace(pod.Annotations[fmt.Sprintf("%s/injection-mode", EnvconsulInjectorFQDN)])
+	switch mode {
+	case "", injectionModeExec:
+		return injectionModeExec, nil
+	case injectionModeInitContainer:
+		return mode, nil
+	}
+
+	return mode, fmt.Errorf(
+		"invalid %s/injection-mode annotation %q, must be %s or %s",
+		EnvconsulInjectorFQDN, mode, injectionModeExec, injectionModeInitContainer,
+	)
+}
+
+// authConfig is how theatre-envconsul authenticates with Vault. The auth method
+// defaults to that of the vault ConfigMap, but can be chosen by pods with an
+// annotation:
+//
+//   envconsul-injector.vault.crd.gocardless.com/auth-method: approle
+//
+// Mount paths and roles of the kubernetes and jwt methods come from the ConfigMap,
+// while the approle and token-file methods need credentials that only the pod can
+// provide, given by the auth-approle-role-id, auth-approle-secret-id-file and
+// auth-token-file annotations.
+type authConfig struct {
+	Method              string
+	MountPath           string
+	Role                string
+	AppRoleRoleID       string
+	AppRoleSecretIDFile string
+	TokenFile           string
+}
+
+func parseAuthConfig(pod corev1.Pod, cfg vaultConfig) (authConfig, error) {
+	annotation := func(name string) string {
+		return strings.TrimSpace(pod.Annotations[fmt.Sprintf("%s/%s", EnvconsulInjectorFQDN, name)])
+	}
+
+	auth := authConfig{Method: cfg.AuthMethod}
+	if method := annotation("auth-method"); method != "" {
+		auth.Method = method
+	}
+
+	if auth.Method == "" {
+		auth.Method = vault.AuthMethodKubernetes
+	}
+
+	if err := vault.ValidateAuthMethod(auth.Method); err != nil {
+		return auth, err
+	}
+
+	switch auth.Method {
+	case vault.AuthMethodKubernetes:
+		auth.MountPath, auth.Role = cfg.AuthMountPath, cfg.AuthRole
+	case vault.AuthMethodJWT:
+		auth.MountPath, auth.Role = cfg.AuthJWTMountPath, cfg.AuthJWTRole
+	case vault.AuthMethodAppRole:
+		auth.MountPath = cfg.AuthAppRoleMountPath
+		auth.AppRoleRoleID = annotation("auth-approle-role-id")
+		auth.AppRoleSecretIDFile = annotation("auth-approle-secret-id-file")
+		if auth.AppRoleRoleID == "" {
+			return auth, fmt.Errorf("%s/auth-approle-role-id annotation is required for approle auth", EnvconsulInjectorFQDN)
+		}
+	case vault.AuthMethodTokenFile:
+		auth.TokenFile = annotation("auth-token-file")
+		if auth.TokenFile == "" {
+			return auth, fmt.Errorf("%s/auth-token-file annotation is required for token-file auth", EnvconsulInjectorFQDN)
+		}
+	}
+
+	return auth, nil
+}
+
+// args returns the theatre-envconsul flags that select this auth method. Kubernetes
+// auth is the default, so it's the only method that doesn't need to be named.
+func (a authConfig) args() []string {
+	if a.Method == vault.AuthMethodKubernetes {
+		return []string{"--auth-backend-mount-path", a.MountPath, "--auth-backend-role", a.Role}
+	}
+
+	args := []string{"--auth-method", a.Method}
+	if a.MountPath != "" {
+		args = append(args, "--auth-backend-mount-path", a.MountPath)
+	}
+
+	switch a.Method {
+	case vault.AuthMethodJWT:
+		if a.Role != "" {
+			args = append(args, "--auth-backend-role", a.Role)
+		}
+	case vault.AuthMethodAppRole:
+		args = append(args, "--auth-approle-role-id", a.AppRoleRoleID)
+		if a.AppRoleSecretIDFile != "" {
+			args = append(args, "--auth-approle-secret-id-file", a.AppRoleSecretIDFile)
+		}
+	case vault.AuthMethodTokenFile:
+		args = append(args, "--auth-token-file", a.TokenFile)
+	}
+
+	return args
+}
+
 func (i podInjector) buildInitContainer() corev1.Container {
 	return corev1.Container{
 		Name:            "theatre-envconsul-injector",
@@ -301,39 +787,130 @@ func (i podInjector) buildInitContainer() corev1.Container {
 				ReadOnly:  false,
 			},
 		},
-		Resources: corev1.ResourceRequirements{
-			Limits: corev1.ResourceList{
-				corev1.ResourceMemory: resource.MustParse("64Mi"),
-				corev1.ResourceCPU:    resource.MustParse("50m"),
-			},
-			Requests: corev1.ResourceList{
-				corev1.ResourceMemory: resource.MustParse("64Mi"),
-				corev1.ResourceCPU:    resource.MustParse("50m"),
-			},
+		Resources: theatreEnvconsulResources(),
+	}
+}
+
+// buildResolveContainer returns an init container that resolves the secrets of the
+// given container, writing them to its directory of the secrets volume. It runs the
+// container's image, with its environment and volumes, so that it can read the same
+// config file and write vault-file secrets to the same shared volumes.
+func (i podInjector) buildResolveContainer(reference corev1.Container, containerConfigPath, secretMountPathPrefix string, auth authConfig) corev1.Container {
+	args := []string{"resolve"}
+	args = append(args, i.vaultArgs(containerConfigPath, secretMountPathPrefix, auth)...)
+	args = append(args, "--output-path", i.SecretsPath)
+
+	volumeMounts := append([]corev1.VolumeMount{}, reference.VolumeMounts...)
+	volumeMounts = append(
+		volumeMounts,
+		corev1.VolumeMount{
+			Name:      "theatre-envconsul-install",
+			MountPath: i.InstallPath,
+			ReadOnly:  true,
+		},
+		corev1.VolumeMount{
+			Name:      "theatre-envconsul-serviceaccount",
+			MountPath: path.Dir(i.ServiceAccountTokenFile),
+			ReadOnly:  true,
 		},
+		corev1.VolumeMount{
+			Name:      "theatre-envconsul-secrets",
+			MountPath: i.SecretsPath,
+			SubPath:   reference.Name,
+		},
+	)
+
+	// Container names are limited to 63 characters, like other DNS labels
+	name := fmt.Sprintf("theatre-envconsul-resolve-%s", reference.Name)
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+
+	return corev1.Container{
+		Name:            name,
+		Image:           reference.Image,
+		ImagePullPolicy: reference.ImagePullPolicy,
+		Command:         []string{path.Join(i.InstallPath, "theatre-envconsul")},
+		Args:            args,
+		WorkingDir:      reference.WorkingDir,
+		Env:             reference.Env,
+		EnvFrom:         reference.EnvFrom,
+		VolumeMounts:    volumeMounts,
+		// Run as the same user as the container, so it can read the files we write
+		SecurityContext: reference.SecurityContext,
+		Resources:       theatreEnvconsulResources(),
 	}
 }
 
-// configureContainer returns a copy with the command modified to run theatre-envconsul,
-// along with a volume mount that will contain the envconsul binaries.
-func (i podInjector) configureContainer(reference corev1.Container, containerConfigPath, secretMountPathPrefix string) corev1.Container {
+// mountSecrets returns a copy of the container with its directory of the secrets volume
+// mounted, where an init container will have written its secrets
+func (i podInjector) mountSecrets(reference corev1.Container) corev1.Container {
 	c := &reference
+	c.VolumeMounts = append(
+		c.VolumeMounts,
+		corev1.VolumeMount{
+			Name:      "theatre-envconsul-secrets",
+			MountPath: i.SecretsPath,
+			SubPath:   reference.Name,
+			ReadOnly:  true,
+		},
+	)
 
-	args := []string{"exec"}
-	args = append(args, "--install-path", i.InstallPath)
-	args = append(args, "--vault-address", i.Address)
+	return *c
+}
+
+func theatreEnvconsulResources() corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("64Mi"),
+			corev1.ResourceCPU:    resource.MustParse("50m"),
+		},
+		Requests: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("64Mi"),
+			corev1.ResourceCPU:    resource.MustParse("50m"),
+		},
+	}
+}
+
+// vaultArgs returns the theatre-envconsul flags that tell it how to authenticate with
+// Vault, and which secrets to read
+func (i podInjector) vaultArgs(containerConfigPath, secretMountPathPrefix string, auth authConfig) []string {
+	args := []string{"--vault-address", i.Address}
+	if i.vaultConfig.Namespace != "" {
+		args = append(args, "--vault-namespace", i.vaultConfig.Namespace)
+	}
 	args = append(args, "--vault-path-prefix", secretMountPathPrefix)
-	args = append(args, "--auth-backend-mount-path", i.AuthMountPath)
-	args = append(args, "--auth-backend-role", i.AuthRole)
+	args = append(args, auth.args()...)
 	args = append(args, "--service-account-token-file", i.ServiceAccountTokenFile)
 
 	if containerConfigPath != "" {
 		args = append(args, "--config-file", containerConfigPath)
 	}
 
+	return args
+}
+
+// configureContainer returns a copy with the command modified to run theatre-envconsul,
+// along with a volume mount that will contain the theatre-envconsul binary.
+func (i podInjector) configureContainer(reference corev1.Container, containerConfigPath, secretMountPathPrefix, superviseAction string, auth authConfig) corev1.Container {
+	c := &reference
+
+	args := []string{"exec"}
+	args = append(args, i.vaultArgs(containerConfigPath, secretMountPathPrefix, auth)...)
+
+	if superviseAction != "" {
+		args = append(args, "--supervise", "--supervise-on-change", superviseAction)
+	}
+
+	// Containers without a command run the entrypoint of their image, which we've looked
+	// up if we could
 	execCommand := []string{"--"}
-	execCommand = append(execCommand, reference.Command...)
-	execCommand = append(execCommand, reference.Args...)
+	if command, ok := i.commands[reference.Name]; ok && len(reference.Command) == 0 {
+		execCommand = append(execCommand, command...)
+	} else {
+		execCommand = append(execCommand, reference.Command...)
+		execCommand = append(execCommand, reference.Args...)
+	}
 	args = append(args, execCommand...)
 
 	c.Command = []string{path.Join(i.InstallPath, "theatre-envconsul")}
@@ -1,19 +1,63 @@
 package v1alpha1
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"strings"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/gocardless/theatre/v2/pkg/registry"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gstruct"
 )
 
+// privateDockerConfig is the only Docker config that can read images in the private
+// repository of fakeImages
+const privateDockerConfig = `{"auths":{"registry.example.com":{"auth":"YWRtaW46aHVudGVyMg=="}}}`
+
+// fakeImages serves the config of images, by reference
+type fakeImages map[string]registry.ImageConfig
+
+func (f fakeImages) ImageConfig(ctx context.Context, image string, dockerConfigs ...[]byte) (registry.ImageConfig, error) {
+	if err := ctx.Err(); err != nil {
+		return registry.ImageConfig{}, err
+	}
+
+	if strings.HasPrefix(image, "registry.example.com/private/") {
+		authorised := false
+		for _, dockerConfig := range dockerConfigs {
+			authorised = authorised || string(dockerConfig) == privateDockerConfig
+		}
+
+		if !authorised {
+			return registry.ImageConfig{}, fmt.Errorf("unauthorized to read image %s", image)
+		}
+	}
+
+	config, ok := f[image]
+	if !ok {
+		return config, fmt.Errorf("no image %s", image)
+	}
+
+	return config, nil
+}
+
 func mustPodFixture(path string) *corev1.Pod {
 	podFixtureYAML, _ := ioutil.ReadFile(path)
 	decoder := scheme.Codecs.UniversalDeserializer()
@@ -21,6 +65,302 @@ func mustPodFixture(path string) *corev1.Pod {
 	return obj.(*corev1.Pod)
 }
 
+var _ = Describe("EnvconsulInjector", func() {
+	var (
+		injector  *EnvconsulInjector
+		namespace *corev1.Namespace
+		opts      EnvconsulInjectorOptions
+		fixture   *corev1.Pod
+		resp      admission.Response
+	)
+
+	BeforeEach(func() {
+		namespace = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "staging",
+				Labels: map[string]string{"theatre-envconsul-injector": "enabled"},
+			},
+		}
+		opts = EnvconsulInjectorOptions{
+			Image:          "theatre:latest",
+			InstallPath:    "/var/run/theatre-envconsul",
+			NamespaceLabel: "theatre-envconsul-injector",
+			VaultConfigMapKey: client.ObjectKey{
+				Namespace: "vault-system",
+				Name:      "vault-config",
+			},
+			ServiceAccountTokenFile:   "/var/run/secrets/kubernetes.io/vault/token",
+			ServiceAccountTokenExpiry: 15 * time.Minute,
+		}
+		fixture = mustPodFixture("./testdata/app_with_config_pod.yaml")
+	})
+
+	JustBeforeEach(func() {
+		vaultConfigMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "vault-system", Name: "vault-config"},
+			Data: map[string]string{
+				"address":                  "https://vault.example.com",
+				"auth_mount_path":          "kubernetes",
+				"auth_role":                "default",
+				"secret_mount_path_prefix": "secret/data/kubernetes",
+			},
+		}
+
+		decoder, err := admission.NewDecoder(scheme.Scheme)
+		Expect(err).NotTo(HaveOccurred())
+
+		c := fake.NewFakeClientWithScheme(scheme.Scheme, namespace, vaultConfigMap)
+		injector = NewEnvconsulInjector(
+			c,
+			c,
+			zap.LoggerTo(GinkgoWriter, true),
+			opts,
+		)
+		Expect(injector.InjectDecoder(decoder)).To(Succeed())
+		injector.images = fakeImages{
+			"app:v1": registry.ImageConfig{Entrypoint: []string{"/app"}, Cmd: []string{"serve"}},
+		}
+
+		podJSON, err := json.Marshal(fixture)
+		Expect(err).NotTo(HaveOccurred())
+
+		resp = injector.Handle(context.Background(), admission.Request{
+			AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				UID:       "uid",
+				Namespace: "staging",
+				Object:    runtime.RawExtension{Raw: podJSON},
+			},
+		})
+	})
+
+	rejected := func() float64 {
+		return testutil.ToFloat64(namespaceRejectedTotal.With(prometheus.Labels{"pod_namespace": "staging"}))
+	}
+
+	Context("In an enabled namespace", func() {
+		It("Mutates the pod", func() {
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(resp.Patches).NotTo(BeEmpty())
+		})
+	})
+
+	Context("In a namespace without the label", func() {
+		var rejectedBefore float64
+
+		BeforeEach(func() {
+			namespace.ObjectMeta.Labels = nil
+			rejectedBefore = rejected()
+		})
+
+		It("Skips the pod", func() {
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(resp.Patches).To(BeEmpty())
+			Expect(rejected()).To(Equal(rejectedBefore + 1))
+		})
+
+		Context("When rejecting pods in disabled namespaces", func() {
+			BeforeEach(func() {
+				opts.RejectDisabledNamespaces = true
+			})
+
+			It("Rejects the pod", func() {
+				Expect(resp.Allowed).To(BeFalse())
+				Expect(string(resp.Result.Reason)).To(ContainSubstring("namespace staging must be labelled theatre-envconsul-injector=enabled"))
+				Expect(rejected()).To(Equal(rejectedBefore + 1))
+			})
+		})
+	})
+
+	Context("With a container whose image can't be found", func() {
+		BeforeEach(func() {
+			fixture.Spec.Containers[0].Image = "app:v2"
+			fixture.Spec.Containers[0].Command = nil
+		})
+
+		It("Mutates the pod, running the container's args as given", func() {
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(resp.Patches).NotTo(BeEmpty())
+		})
+	})
+
+	Context("With init-container injection and supervision", func() {
+		BeforeEach(func() {
+			fixture.ObjectMeta.Annotations[fmt.Sprintf("%s/injection-mode", EnvconsulInjectorFQDN)] = "init-container"
+			fixture.ObjectMeta.Annotations[fmt.Sprintf("%s/supervise", EnvconsulInjectorFQDN)] = "true"
+		})
+
+		It("Rejects the pod", func() {
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring("can't be supervised"))
+		})
+	})
+
+	Context("With no namespace label configured", func() {
+		BeforeEach(func() {
+			namespace.ObjectMeta.Labels = nil
+			opts.NamespaceLabel = ""
+		})
+
+		It("Mutates the pod", func() {
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(resp.Patches).NotTo(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("resolveCommands", func() {
+	var (
+		fixture  *corev1.Pod
+		secrets  []runtime.Object
+		opts     EnvconsulInjectorOptions
+		commands map[string][]string
+		err      error
+	)
+
+	BeforeEach(func() {
+		fixture = mustPodFixture("./testdata/app_with_config_pod.yaml")
+		fixture.Spec.Containers[0].Image = "app:v1"
+		fixture.Spec.Containers[0].Command = nil
+		fixture.Spec.Containers[0].Args = nil
+		secrets = []runtime.Object{
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "staging", Name: "private-registry"},
+				Type:       corev1.SecretTypeDockerConfigJson,
+				Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(privateDockerConfig)},
+			},
+		}
+		opts = EnvconsulInjectorOptions{}
+	})
+
+	JustBeforeEach(func() {
+		injector := &EnvconsulInjector{
+			apiReader: fake.NewFakeClientWithScheme(scheme.Scheme, secrets...),
+			opts:      opts,
+			images: fakeImages{
+				"app:v1":   registry.ImageConfig{Entrypoint: []string{"/app"}, Cmd: []string{"serve"}},
+				"empty:v1": registry.ImageConfig{},
+				"registry.example.com/private/app:v1": registry.ImageConfig{Entrypoint: []string{"/private"}},
+			},
+		}
+		commands, err = injector.resolveCommands(context.Background(), *fixture)
+	})
+
+	It("Returns the entrypoint and command of the image", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(commands).To(Equal(map[string][]string{"app": {"/app", "serve"}}))
+	})
+
+	Context("With args", func() {
+		BeforeEach(func() {
+			fixture.Spec.Containers[0].Args = []string{"migrate"}
+		})
+
+		It("Returns the entrypoint of the image with the args", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(commands).To(Equal(map[string][]string{"app": {"/app", "migrate"}}))
+		})
+	})
+
+	Context("With a command", func() {
+		BeforeEach(func() {
+			fixture.Spec.Containers[0].Command = []string{"echo"}
+		})
+
+		It("Doesn't look up the image", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(commands).To(BeEmpty())
+		})
+	})
+
+	Context("With an image that has no entrypoint or command", func() {
+		BeforeEach(func() {
+			fixture.Spec.Containers[0].Image = "empty:v1"
+		})
+
+		It("Returns an error, without a command", func() {
+			Expect(err).To(MatchError("image empty:v1 has no entrypoint or command, so container app must set one"))
+			Expect(commands).To(BeEmpty())
+		})
+	})
+
+	Context("With several containers", func() {
+		BeforeEach(func() {
+			fixture.ObjectMeta.Annotations[fmt.Sprintf("%s/configs", EnvconsulInjectorFQDN)] = "app,other"
+			fixture.Spec.Containers = append(fixture.Spec.Containers, corev1.Container{Name: "other", Image: "missing:v1"})
+		})
+
+		It("Returns the commands it could resolve, and an error for the rest", func() {
+			Expect(err).To(MatchError(ContainSubstring("failed to look up entrypoint of image missing:v1")))
+			Expect(commands).To(Equal(map[string][]string{"app": {"/app", "serve"}}))
+		})
+	})
+
+	Context("With an image in a private registry", func() {
+		BeforeEach(func() {
+			fixture.Spec.Containers[0].Image = "registry.example.com/private/app:v1"
+		})
+
+		It("Returns an error without a pull secret", func() {
+			Expect(err).To(MatchError(ContainSubstring("unauthorized to read image")))
+		})
+
+		Context("And the pod's pull secret", func() {
+			BeforeEach(func() {
+				opts.ReadImagePullSecrets = true
+				fixture.Spec.ImagePullSecrets = []corev1.LocalObjectReference{
+					{Name: "missing"},
+					{Name: "private-registry"},
+				}
+			})
+
+			It("Reads the image with the credentials of the secret", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(commands).To(Equal(map[string][]string{"app": {"/private"}}))
+			})
+
+			Context("When pull secrets aren't read", func() {
+				BeforeEach(func() {
+					opts.ReadImagePullSecrets = false
+				})
+
+				It("Reads the image without credentials", func() {
+					Expect(err).To(MatchError(ContainSubstring("unauthorized to read image")))
+				})
+			})
+		})
+
+		Context("And a legacy pull secret", func() {
+			BeforeEach(func() {
+				auths := strings.TrimSuffix(strings.TrimPrefix(privateDockerConfig, `{"auths":`), "}")
+				secrets = []runtime.Object{
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Namespace: "staging", Name: "private-registry"},
+						Type:       corev1.SecretTypeDockercfg,
+						Data:       map[string][]byte{corev1.DockerConfigKey: []byte(auths)},
+					},
+				}
+				opts.ReadImagePullSecrets = true
+				fixture.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "private-registry"}}
+			})
+
+			It("Reads the image with the credentials of the secret", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(commands).To(Equal(map[string][]string{"app": {"/private"}}))
+			})
+		})
+	})
+
+	Context("When the lookup timeout has passed", func() {
+		BeforeEach(func() {
+			opts.ImageLookupTimeout = time.Nanosecond
+		})
+
+		It("Gives up on the lookup", func() {
+			Expect(err).To(MatchError(ContainSubstring(context.DeadlineExceeded.Error())))
+		})
+	})
+})
+
 var _ = Describe("PodInjector", func() {
 	var (
 		injector *podInjector
@@ -43,6 +383,7 @@ var _ = Describe("PodInjector", func() {
 			EnvconsulInjectorOptions: EnvconsulInjectorOptions{
 				Image:       "theatre:latest",
 				InstallPath: "/var/run/theatre-envconsul",
+				SecretsPath: "/var/run/theatre-secrets",
 				VaultConfigMapKey: client.ObjectKey{
 					Namespace: "vault-system",
 					Name:      "vault-config",
@@ -118,8 +459,6 @@ var _ = Describe("PodInjector", func() {
 							}),
 							"Args": Equal([]string{
 								"exec",
-								"--install-path",
-								"/var/run/theatre-envconsul",
 								"--vault-address",
 								"https://vault.example.com",
 								"--vault-path-prefix",
@@ -231,8 +570,6 @@ var _ = Describe("PodInjector", func() {
 							}),
 							"Args": Equal([]string{
 								"exec",
-								"--install-path",
-								"/var/run/theatre-envconsul",
 								"--vault-address",
 								"https://vault.example.com",
 								"--vault-path-prefix",
@@ -256,6 +593,376 @@ var _ = Describe("PodInjector", func() {
 			)
 		})
 	})
+
+	Context("Pod with supervise annotation", func() {
+		BeforeEach(func() {
+			fixture = mustPodFixture("./testdata/app_with_config_pod.yaml")
+			fixture.ObjectMeta.Annotations[fmt.Sprintf("%s/supervise", EnvconsulInjectorFQDN)] = "SIGUSR1"
+		})
+
+		It("Configures theatre-envconsul to supervise the command", func() {
+			Expect(pod.Spec.Containers[0].Args).To(
+				ContainElements("--supervise", "--supervise-on-change", "SIGUSR1"),
+			)
+			Expect(pod.Spec.Containers[0].Args[len(pod.Spec.Containers[0].Args)-4:]).To(
+				Equal([]string{"--", "echo", "inject", "only"}),
+			)
+		})
+	})
+
+	Context("Pod with a container that relies on the entrypoint of its image", func() {
+		BeforeEach(func() {
+			fixture = mustPodFixture("./testdata/app_with_config_pod.yaml")
+			fixture.Spec.Containers[0].Command = nil
+			injector.commands = map[string][]string{"app": {"/app", "serve"}}
+		})
+
+		It("Runs the resolved command", func() {
+			args := pod.Spec.Containers[0].Args
+			Expect(args[len(args)-3:]).To(Equal([]string{"--", "/app", "serve"}))
+		})
+	})
+
+	Context("Pod with init-container injection mode", func() {
+		BeforeEach(func() {
+			fixture = mustPodFixture("./testdata/app_with_config_pod.yaml")
+			fixture.ObjectMeta.Annotations[fmt.Sprintf("%s/injection-mode", EnvconsulInjectorFQDN)] = "init-container"
+			fixture.Spec.Containers[0].Image = "app:v1"
+		})
+
+		It("Leaves the container's command untouched", func() {
+			Expect(pod.Spec.Containers[0].Command).To(Equal([]string{"echo", "inject", "only"}))
+			Expect(pod.Spec.Containers[0].Args).To(BeEmpty())
+		})
+
+		It("Adds an in-memory secrets volume", func() {
+			Expect(pod.Spec.Volumes).To(ContainElement(
+				MatchFields(IgnoreExtras, Fields{
+					"Name": Equal("theatre-envconsul-secrets"),
+					"VolumeSource": MatchFields(IgnoreExtras, Fields{
+						"EmptyDir": PointTo(MatchFields(IgnoreExtras, Fields{
+							"Medium": Equal(corev1.StorageMediumMemory),
+						})),
+					}),
+				}),
+			))
+		})
+
+		It("Mounts the container's secrets", func() {
+			Expect(pod.Spec.Containers[0].VolumeMounts).To(ContainElement(
+				corev1.VolumeMount{
+					Name:      "theatre-envconsul-secrets",
+					MountPath: "/var/run/theatre-secrets",
+					SubPath:   "app",
+					ReadOnly:  true,
+				},
+			))
+		})
+
+		It("Resolves secrets in an init container after installing theatre-envconsul", func() {
+			Expect(pod.Spec.InitContainers).To(HaveLen(2))
+			Expect(pod.Spec.InitContainers[0].Name).To(Equal("theatre-envconsul-injector"))
+			Expect(pod.Spec.InitContainers[1]).To(
+				MatchFields(IgnoreExtras, Fields{
+					"Name":    Equal("theatre-envconsul-resolve-app"),
+					"Image":   Equal("app:v1"),
+					"Command": Equal([]string{"/var/run/theatre-envconsul/theatre-envconsul"}),
+					"Args": Equal([]string{
+						"resolve",
+						"--vault-address",
+						"https://vault.example.com",
+						"--vault-path-prefix",
+						"secret/data/kubernetes/staging/secret-reader",
+						"--auth-backend-mount-path",
+						"kubernetes.gc-prd-effc.cluster",
+						"--auth-backend-role",
+						"default",
+						"--service-account-token-file",
+						"/var/run/secrets/kubernetes.io/vault/token",
+						"--config-file",
+						"config/app.yaml",
+						"--output-path",
+						"/var/run/theatre-secrets",
+					}),
+					"VolumeMounts": ContainElement(corev1.VolumeMount{
+						Name:      "theatre-envconsul-secrets",
+						MountPath: "/var/run/theatre-secrets",
+						SubPath:   "app",
+					}),
+				}),
+			)
+		})
+	})
+
+	Context("With a Vault namespace", func() {
+		BeforeEach(func() {
+			fixture = mustPodFixture("./testdata/app_with_config_pod.yaml")
+			injector.vaultConfig.Namespace = "payments"
+		})
+
+		It("Configures theatre-envconsul to use the namespace", func() {
+			Expect(pod.Spec.Containers[0].Args[:5]).To(Equal([]string{
+				"exec",
+				"--vault-address",
+				"https://vault.example.com",
+				"--vault-namespace",
+				"payments",
+			}))
+		})
+	})
+
+	Context("Pod with approle auth annotations", func() {
+		BeforeEach(func() {
+			fixture = mustPodFixture("./testdata/app_with_config_pod.yaml")
+			fixture.ObjectMeta.Annotations[fmt.Sprintf("%s/auth-method", EnvconsulInjectorFQDN)] = "approle"
+			fixture.ObjectMeta.Annotations[fmt.Sprintf("%s/auth-approle-role-id", EnvconsulInjectorFQDN)] = "role-id"
+			fixture.ObjectMeta.Annotations[fmt.Sprintf("%s/auth-approle-secret-id-file", EnvconsulInjectorFQDN)] = "/etc/approle/secret-id"
+		})
+
+		It("Configures theatre-envconsul to use approle auth", func() {
+			Expect(pod.Spec.Containers[0].Args[:11]).To(Equal([]string{
+				"exec",
+				"--vault-address",
+				"https://vault.example.com",
+				"--vault-path-prefix",
+				"secret/data/kubernetes/staging/secret-reader",
+				"--auth-method",
+				"approle",
+				"--auth-approle-role-id",
+				"role-id",
+				"--auth-approle-secret-id-file",
+				"/etc/approle/secret-id",
+			}))
+		})
+	})
+
+	Context("With jwt auth in the vault config", func() {
+		BeforeEach(func() {
+			fixture = mustPodFixture("./testdata/app_with_config_pod.yaml")
+			injector.vaultConfig.AuthMethod = "jwt"
+			injector.vaultConfig.AuthJWTMountPath = "jwt.gc-prd-effc.cluster"
+			injector.vaultConfig.AuthJWTRole = "app"
+		})
+
+		It("Configures theatre-envconsul to use jwt auth", func() {
+			Expect(pod.Spec.Containers[0].Args[5:13]).To(Equal([]string{
+				"--auth-method",
+				"jwt",
+				"--auth-backend-mount-path",
+				"jwt.gc-prd-effc.cluster",
+				"--auth-backend-role",
+				"app",
+				"--service-account-token-file",
+				"/var/run/secrets/kubernetes.io/vault/token",
+			}))
+		})
+	})
+})
+
+var _ = Describe("vaultConfig", func() {
+	var (
+		configMap *corev1.ConfigMap
+		cfg       vaultConfig
+		err       error
+	)
+
+	BeforeEach(func() {
+		configMap = &corev1.ConfigMap{
+			Data: map[string]string{
+				"address":                  "https://vault.example.com",
+				"auth_mount_path":          "kubernetes",
+				"auth_role":                "default",
+				"secret_mount_path_prefix": "secret/data/kubernetes",
+				"allowed_overrides":        "namespace, auth_role",
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		cfg, err = newVaultConfig(configMap)
+	})
+
+	Context("With overrides that are allowed", func() {
+		It("Returns the config with the overrides applied", func() {
+			Expect(err).NotTo(HaveOccurred())
+
+			overridden, err := cfg.withOverrides(map[string]string{"namespace": "payments", "auth_role": "payments-api"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(overridden.Namespace).To(Equal("payments"))
+			Expect(overridden.AuthRole).To(Equal("payments-api"))
+			Expect(overridden.Address).To(Equal("https://vault.example.com"))
+			Expect(cfg.AuthRole).To(Equal("default"), "expected the original config to be unchanged")
+		})
+	})
+
+	Context("With an override that isn't allowed", func() {
+		It("Returns an error", func() {
+			_, err := cfg.withOverrides(map[string]string{"address": "https://attacker.example.com"})
+			Expect(err).To(MatchError("overriding address of the vault config isn't allowed"))
+		})
+	})
+
+	Context("With an unknown key in the allowed overrides", func() {
+		BeforeEach(func() {
+			configMap.Data["allowed_overrides"] = "namespace,allowed_overrides"
+		})
+
+		It("Returns an error", func() {
+			Expect(err).To(MatchError(ContainSubstring(`allowed_overrides contains "allowed_overrides"`)))
+		})
+	})
+})
+
+var _ = Describe("parseOverrides", func() {
+	It("Returns keys of override annotations", func() {
+		Expect(parseOverrides(map[string]string{
+			fmt.Sprintf("%s/override.namespace", EnvconsulInjectorFQDN): " payments ",
+			fmt.Sprintf("%s/configs", EnvconsulInjectorFQDN):            "app",
+			"override.auth_role": "ignored",
+		})).To(Equal(map[string]string{"namespace": "payments"}))
+	})
+})
+
+var _ = Describe("parseAuthConfig", func() {
+	var (
+		fixture *corev1.Pod
+		cfg     vaultConfig
+		auth    authConfig
+		err     error
+	)
+
+	BeforeEach(func() {
+		fixture = &corev1.Pod{}
+		fixture.ObjectMeta.Annotations = map[string]string{}
+		cfg = vaultConfig{
+			AuthMountPath: "kubernetes.gc-prd-effc.cluster",
+			AuthRole:      "default",
+		}
+	})
+
+	JustBeforeEach(func() {
+		auth, err = parseAuthConfig(*fixture, cfg)
+	})
+
+	withAnnotation := func(name, value string) {
+		BeforeEach(func() {
+			fixture.ObjectMeta.Annotations[fmt.Sprintf("%s/%s", EnvconsulInjectorFQDN, name)] = value
+		})
+	}
+
+	Context("With no annotations or auth method in the vault config", func() {
+		It("Returns kubernetes auth from the vault config", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(auth).To(Equal(authConfig{
+				Method:    "kubernetes",
+				MountPath: "kubernetes.gc-prd-effc.cluster",
+				Role:      "default",
+			}))
+		})
+	})
+
+	Context("With token-file auth", func() {
+		withAnnotation("auth-method", "token-file")
+		withAnnotation("auth-token-file", "/var/run/vault/token")
+
+		It("Returns the token file", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(auth).To(Equal(authConfig{Method: "token-file", TokenFile: "/var/run/vault/token"}))
+		})
+	})
+
+	Context("With token-file auth and no token file", func() {
+		withAnnotation("auth-method", "token-file")
+
+		It("Returns an error", func() {
+			Expect(err).To(MatchError(ContainSubstring("auth-token-file annotation is required")))
+		})
+	})
+
+	Context("With approle auth and no role ID", func() {
+		withAnnotation("auth-method", "approle")
+
+		It("Returns an error", func() {
+			Expect(err).To(MatchError(ContainSubstring("auth-approle-role-id annotation is required")))
+		})
+	})
+
+	Context("With an unknown auth method", func() {
+		withAnnotation("auth-method", "userpass")
+
+		It("Returns an error", func() {
+			Expect(err).To(MatchError(ContainSubstring(`invalid auth method "userpass"`)))
+		})
+	})
+})
+
+var _ = Describe("parseInjectionMode", func() {
+	It("Defaults to exec", func() {
+		Expect(parseInjectionMode(corev1.Pod{})).To(Equal("exec"))
+	})
+
+	It("Returns an error for unknown modes", func() {
+		pod := corev1.Pod{}
+		pod.ObjectMeta.Annotations = map[string]string{fmt.Sprintf("%s/injection-mode", EnvconsulInjectorFQDN): "sidecar"}
+
+		_, err := parseInjectionMode(pod)
+		Expect(err).To(MatchError(ContainSubstring(`injection-mode annotation "sidecar", must be exec or init-container`)))
+	})
+})
+
+var _ = Describe("parseSuperviseAction", func() {
+	var (
+		fixture *corev1.Pod
+		action  string
+		err     error
+	)
+
+	BeforeEach(func() {
+		fixture = &corev1.Pod{}
+	})
+
+	JustBeforeEach(func() {
+		action, err = parseSuperviseAction(*fixture)
+	})
+
+	withAnnotation := func(value string) {
+		BeforeEach(func() {
+			fixture.ObjectMeta.Annotations = map[string]string{fmt.Sprintf("%s/supervise", EnvconsulInjectorFQDN): value}
+		})
+	}
+
+	Context("With no annotation", func() {
+		It("Returns no action", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(action).To(Equal(""))
+		})
+	})
+
+	Context("With true", func() {
+		withAnnotation("true")
+
+		It("Returns the default signal", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(action).To(Equal("SIGHUP"))
+		})
+	})
+
+	Context("With restart", func() {
+		withAnnotation("restart")
+
+		It("Returns restart", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(action).To(Equal("restart"))
+		})
+	})
+
+	Context("With an invalid signal", func() {
+		withAnnotation("SIGKILL")
+
+		It("Returns an error", func() {
+			Expect(err).To(MatchError(ContainSubstring("invalid envconsul-injector.vault.crd.gocardless.com/supervise annotation")))
+		})
+	})
 })
 
 var _ = Describe("parseContainerConfigs", func() {
@@ -15,11 +15,15 @@ import (
 type ConsoleAuthenticatorWebhook struct {
 	logger  logr.Logger
 	decoder *admission.Decoder
+	// managerUsername is the user that the workloads manager authenticates as,
+	// which creates consoles on behalf of the users of console schedules
+	managerUsername string
 }
 
-func NewConsoleAuthenticatorWebhook(logger logr.Logger) *ConsoleAuthenticatorWebhook {
+func NewConsoleAuthenticatorWebhook(logger logr.Logger, managerUsername string) *ConsoleAuthenticatorWebhook {
 	return &ConsoleAuthenticatorWebhook{
-		logger: logger,
+		logger:          logger,
+		managerUsername: managerUsername,
 	}
 }
 
@@ -35,14 +39,35 @@ func (c *ConsoleAuthenticatorWebhook) Handle(ctx context.Context, req admission.
 		logger.Info("completed request", "event", "request.end", "duration", time.Now().Sub(start).Seconds())
 	}(time.Now())
 
-	csl := &Console{}
-	if err := c.decoder.Decode(req, csl); err != nil {
-		return admission.Errored(http.StatusBadRequest, err)
-	}
-
 	user := req.UserInfo.Username
-	copy := csl.DeepCopy()
-	copy.Spec.User = user
+
+	// Console schedules record the user that last created or modified them, as
+	// that is whose version of the schedule is authorised
+	var copy interface{}
+	if req.Kind.Kind == "ConsoleSchedule" {
+		schedule := &ConsoleSchedule{}
+		if err := c.decoder.Decode(req, schedule); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+
+		scheduleCopy := schedule.DeepCopy()
+		scheduleCopy.Spec.User = user
+		copy = scheduleCopy
+	} else {
+		csl := &Console{}
+		if err := c.decoder.Decode(req, csl); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+
+		// Consoles created by a schedule run on behalf of the schedule's user,
+		// which the manager sets when it creates them
+		cslCopy := csl.DeepCopy()
+		if c.managerUsername != "" && user == c.managerUsername && csl.GetConsoleSchedule() != nil && csl.Spec.User != "" {
+			user = csl.Spec.User
+		}
+		cslCopy.Spec.User = user
+		copy = cslCopy
+	}
 
 	copyBytes, err := json.Marshal(copy)
 	if err != nil {
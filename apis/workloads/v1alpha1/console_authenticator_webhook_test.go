@@ -0,0 +1,109 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var _ = Describe("ConsoleAuthenticatorWebhook", func() {
+	const managerUsername = "system:serviceaccount:theatre-system:theatre-workloads-manager"
+
+	var (
+		csl  *Console
+		user string
+		resp admission.Response
+	)
+
+	BeforeEach(func() {
+		csl = &Console{
+			ObjectMeta: metav1.ObjectMeta{Name: "console", Namespace: "staging"},
+			Spec: ConsoleSpec{
+				ConsoleTemplateRef: corev1.LocalObjectReference{Name: "template"},
+				User:               "schedule-user@example.com",
+			},
+		}
+		user = "user@example.com"
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(AddToScheme(scheme)).To(Succeed())
+		decoder, err := admission.NewDecoder(scheme)
+		Expect(err).NotTo(HaveOccurred())
+
+		webhook := NewConsoleAuthenticatorWebhook(zap.LoggerTo(GinkgoWriter, true), managerUsername)
+		Expect(webhook.InjectDecoder(decoder)).To(Succeed())
+
+		raw, err := json.Marshal(csl)
+		Expect(err).NotTo(HaveOccurred())
+
+		resp = webhook.Handle(context.TODO(), admission.Request{
+			AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Kind:      metav1.GroupVersionKind{Group: GroupVersion.Group, Version: GroupVersion.Version, Kind: "Console"},
+				Operation: admissionv1beta1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+				UserInfo:  authenticationv1.UserInfo{Username: user},
+			},
+		})
+		Expect(resp.Allowed).To(BeTrue())
+	})
+
+	userPatch := func() interface{} {
+		for _, patch := range resp.Patches {
+			if patch.Path == "/spec/user" {
+				return patch.Value
+			}
+		}
+
+		return nil
+	}
+
+	It("Sets the user to the user creating the console", func() {
+		Expect(userPatch()).To(Equal("user@example.com"))
+	})
+
+	Context("With a console schedule as owner", func() {
+		BeforeEach(func() {
+			csl.OwnerReferences = []metav1.OwnerReference{{
+				APIVersion: GroupVersion.String(),
+				Kind:       "ConsoleSchedule",
+				Name:       "nightly",
+				UID:        "b7c2d5a0-0000-0000-0000-000000000000",
+			}}
+		})
+
+		It("Sets the user to the user creating the console", func() {
+			Expect(userPatch()).To(Equal("user@example.com"))
+		})
+
+		Context("Created by the manager", func() {
+			BeforeEach(func() {
+				user = managerUsername
+			})
+
+			It("Keeps the user of the schedule", func() {
+				Expect(userPatch()).To(BeNil())
+			})
+		})
+	})
+
+	Context("Created by the manager without a console schedule as owner", func() {
+		BeforeEach(func() {
+			user = managerUsername
+		})
+
+		It("Sets the user to the manager", func() {
+			Expect(userPatch()).To(Equal(managerUsername))
+		})
+	})
+})
@@ -11,6 +11,13 @@ type ConsoleAuthorisationSpec struct {
 	// The reference to the console by name that this console authorisation belongs to.
 	ConsoleRef corev1.LocalObjectReference `json:"consoleRef"`
 
+	// The reference to the console schedule by name that this console
+	// authorisation belongs to, if it is the schedule's standing authorisation
+	// rather than that of a single console. The consoleRef is empty when this is
+	// set.
+	// +optional
+	ConsoleScheduleRef *corev1.LocalObjectReference `json:"consoleScheduleRef,omitempty"`
+
 	// List of authorisations that have been given to the referenced console.
 	Authorisations []rbacv1.Subject `json:"authorisations"`
 }
@@ -18,6 +25,8 @@ type ConsoleAuthorisationSpec struct {
 // ConsoleAuthorisationStatus defines the observed state of ConsoleAuthorisation
 type ConsoleAuthorisationStatus struct{}
 
+// +genclient
+// +genclient:noStatus
 // +kubebuilder:object:root=true
 // +kubebuilder:storageversion
 
@@ -11,6 +11,7 @@ import (
 	rbacutils "github.com/gocardless/theatre/v2/pkg/rbac"
 	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
@@ -20,12 +21,16 @@ type ConsoleAuthorisationWebhook struct {
 	client  client.Client
 	logger  logr.Logger
 	decoder *admission.Decoder
+	// managerUsername is the user that the workloads manager authenticates as,
+	// which is trusted to create authorisations on behalf of console schedules
+	managerUsername string
 }
 
-func NewConsoleAuthorisationWebhook(c client.Client, logger logr.Logger) *ConsoleAuthorisationWebhook {
+func NewConsoleAuthorisationWebhook(c client.Client, logger logr.Logger, managerUsername string) *ConsoleAuthorisationWebhook {
 	return &ConsoleAuthorisationWebhook{
-		client: c,
-		logger: logger,
+		client:          c,
+		logger:          logger,
+		managerUsername: managerUsername,
 	}
 }
 
@@ -44,28 +49,60 @@ func (c *ConsoleAuthorisationWebhook) Handle(ctx context.Context, req admission.
 	// request console authorisation object
 	updatedAuth := &ConsoleAuthorisation{}
 	if err := c.decoder.DecodeRaw(req.Object, updatedAuth); err != nil {
-		admission.Errored(http.StatusBadRequest, err)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// user making the request
+	user := req.AdmissionRequest.UserInfo.Username
+
+	// Authorisations are given by updating an existing authorisation object, so
+	// new objects can't carry any authorisations unless they were created by the
+	// manager on behalf of a console schedule
+	if req.Operation == admissionv1beta1.Create {
+		create := &ConsoleAuthorisationCreate{
+			auth:            updatedAuth,
+			user:            user,
+			managerUsername: c.managerUsername,
+		}
+
+		if err := create.Validate(); err != nil {
+			logger.Info("authorisation failed", "event", "authorisation.failure", "error", err)
+			return admission.ValidationResponse(false, fmt.Sprintf("the console authorisation spec is invalid: %v", err))
+		}
+
+		return admission.ValidationResponse(true, "")
 	}
 
 	// existing console authorisation object
 	existingAuth := &ConsoleAuthorisation{}
 	if err := c.decoder.DecodeRaw(req.OldObject, existingAuth); err != nil {
-		admission.Errored(http.StatusBadRequest, err)
+		return admission.Errored(http.StatusBadRequest, err)
 	}
 
-	// user making the request
-	user := req.AdmissionRequest.UserInfo.Username
+	// the owner of a standing authorisation is the user of the console schedule,
+	// otherwise it's the user of the console
+	var owner string
+	if scheduleRef := existingAuth.Spec.ConsoleScheduleRef; scheduleRef != nil {
+		schedule, err := c.getConsoleSchedule(ctx, scheduleRef.Name, existingAuth.Namespace)
+		if err != nil {
+			return admission.ValidationResponse(false, fmt.Sprintf("failed to retrieve console schedule for the authorisation: %v", err))
+		}
 
-	csl, err := c.getConsole(ctx, existingAuth.Spec.ConsoleRef.Name, existingAuth.Namespace)
-	if err != nil {
-		return admission.ValidationResponse(false, fmt.Sprintf("failed to retrieve console for the authorisation: %v", err))
+		owner = schedule.Spec.User
+	} else {
+		csl, err := c.getConsole(ctx, existingAuth.Spec.ConsoleRef.Name, existingAuth.Namespace)
+		if err != nil {
+			return admission.ValidationResponse(false, fmt.Sprintf("failed to retrieve console for the authorisation: %v", err))
+		}
+
+		owner = csl.Spec.User
 	}
 
 	update := &ConsoleAuthorisationUpdate{
 		existingAuth: existingAuth,
 		updatedAuth:  updatedAuth,
 		user:         user,
-		owner:        csl.Spec.User,
+		owner:        owner,
 	}
 
 	if err := update.Validate(); err != nil {
@@ -88,6 +125,45 @@ func (c *ConsoleAuthorisationWebhook) getConsole(ctx context.Context, name, name
 	return csl, c.client.Get(ctx, namespacedName, csl)
 }
 
+func (c *ConsoleAuthorisationWebhook) getConsoleSchedule(ctx context.Context, name, namespace string) (*ConsoleSchedule, error) {
+	namespacedName := client.ObjectKey{
+		Name:      name,
+		Namespace: namespace,
+	}
+
+	schedule := &ConsoleSchedule{}
+
+	return schedule, c.client.Get(ctx, namespacedName, schedule)
+}
+
+type ConsoleAuthorisationCreate struct {
+	auth            *ConsoleAuthorisation
+	user            string
+	managerUsername string
+}
+
+func (c *ConsoleAuthorisationCreate) Validate() error {
+	if c.managerUsername != "" && c.user == c.managerUsername {
+		return nil
+	}
+
+	var err error
+
+	if len(c.auth.Spec.Authorisations) > 0 {
+		err = multierror.Append(err, errors.New("the spec.authorisations field must be empty on creation"))
+	}
+
+	if c.auth.Spec.ConsoleScheduleRef != nil {
+		err = multierror.Append(err, errors.New("the spec.consoleScheduleRef field can only be set by the workloads manager"))
+	}
+
+	if _, ok := c.auth.Labels[ScheduledAuthorisationLabel]; ok {
+		err = multierror.Append(err, fmt.Errorf("the %s label can only be set by the workloads manager", ScheduledAuthorisationLabel))
+	}
+
+	return err
+}
+
 type ConsoleAuthorisationUpdate struct {
 	existingAuth *ConsoleAuthorisation
 	updatedAuth  *ConsoleAuthorisation
@@ -103,6 +179,10 @@ func (u *ConsoleAuthorisationUpdate) Validate() error {
 		err = multierror.Append(err, errors.New("the spec.consoleRef field is immutable"))
 	}
 
+	if !reflect.DeepEqual(u.updatedAuth.Spec.ConsoleScheduleRef, u.existingAuth.Spec.ConsoleScheduleRef) {
+		err = multierror.Append(err, errors.New("the spec.consoleScheduleRef field is immutable"))
+	}
+
 	// check no existing authorisation subjects have been modified and that a single subject has been added
 	add := rbacutils.Diff(u.updatedAuth.Spec.Authorisations, u.existingAuth.Spec.Authorisations)
 	remove := rbacutils.Diff(u.existingAuth.Spec.Authorisations, u.updatedAuth.Spec.Authorisations)
@@ -6,6 +6,8 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -123,4 +125,76 @@ var _ = Describe("Authorisation webhook", func() {
 			})
 		})
 	})
+
+	Describe("Validate creation", func() {
+		var (
+			auth *ConsoleAuthorisation
+			user string
+			err  error
+		)
+
+		BeforeEach(func() {
+			auth = &ConsoleAuthorisation{
+				Spec: ConsoleAuthorisationSpec{
+					ConsoleRef:     corev1.LocalObjectReference{Name: "console"},
+					Authorisations: []rbacv1.Subject{},
+				},
+			}
+			user = "user"
+		})
+
+		JustBeforeEach(func() {
+			create := &ConsoleAuthorisationCreate{
+				auth:            auth,
+				user:            user,
+				managerUsername: "system:serviceaccount:theatre-system:theatre-workloads-manager",
+			}
+
+			err = create.Validate()
+		})
+
+		It("Returns no errors", func() {
+			Expect(err).To(BeNil())
+		})
+
+		Context("With authorisations", func() {
+			BeforeEach(func() {
+				auth.Spec.Authorisations = []rbacv1.Subject{{Kind: "User", Name: "authoriser"}}
+			})
+
+			It("Returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("the spec.authorisations field must be empty on creation")))
+			})
+
+			Context("Created by the manager", func() {
+				BeforeEach(func() {
+					user = "system:serviceaccount:theatre-system:theatre-workloads-manager"
+				})
+
+				It("Returns no errors", func() {
+					Expect(err).To(BeNil())
+				})
+			})
+		})
+
+		Context("With the label of a console schedule's authorisation", func() {
+			BeforeEach(func() {
+				auth.Labels = map[string]string{ScheduledAuthorisationLabel: "b7c2d5a0-0000-0000-0000-000000000000"}
+			})
+
+			It("Returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("label can only be set by the workloads manager")))
+			})
+		})
+
+		Context("With a console schedule reference", func() {
+			BeforeEach(func() {
+				auth.Spec.ConsoleScheduleRef = &corev1.LocalObjectReference{Name: "nightly"}
+			})
+
+			It("Returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("the spec.consoleScheduleRef field can only be set by the workloads manager")))
+			})
+		})
+	})
 })
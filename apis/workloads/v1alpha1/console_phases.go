@@ -6,6 +6,12 @@ type ConsolePhase string
 const (
 	// ConsolePendingAuthorisation means the console been created but it is not yet authorised to run
 	ConsolePendingAuthorisation ConsolePhase = "Pending Authorisation"
+	// ConsoleScheduled means the console is authorised to run, but its
+	// scheduled start time has not yet arrived
+	ConsoleScheduled ConsolePhase = "Scheduled"
+	// ConsoleQueued means the console is authorised to run, but is waiting for
+	// capacity under its template's concurrency limits
+	ConsoleQueued ConsolePhase = "Queued"
 	// ConsolePending means the console has been created but its pod is not yet ready
 	ConsolePending ConsolePhase = "Pending"
 	// ConsoleRunning means the pod has started and is running
@@ -0,0 +1,127 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConsoleScheduleConcurrencyPolicy describes how a ConsoleSchedule treats its
+// unfinished Consoles when the next one is due.
+// +kubebuilder:validation:Enum=Allow;Forbid;Replace
+type ConsoleScheduleConcurrencyPolicy string
+
+const (
+	// AllowConcurrent creates the next Console regardless of any that are
+	// unfinished.
+	AllowConcurrent ConsoleScheduleConcurrencyPolicy = "Allow"
+	// ForbidConcurrent skips the next Console while any are unfinished.
+	ForbidConcurrent ConsoleScheduleConcurrencyPolicy = "Forbid"
+	// ReplaceConcurrent deletes any unfinished Consoles before creating the
+	// next one.
+	ReplaceConcurrent ConsoleScheduleConcurrencyPolicy = "Replace"
+)
+
+// ConsoleScheduleSpec defines the desired state of ConsoleSchedule
+type ConsoleScheduleSpec struct {
+	// The schedule on which to create Consoles, as a standard five field cron
+	// expression, e.g. "30 2 * * *". Times are in UTC.
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	ConsoleTemplateRef corev1.LocalObjectReference `json:"consoleTemplateRef"`
+
+	// The command and arguments to run in each Console. If not set, the
+	// template's command is run.
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	// The reason recorded against each Console that is created.
+	// +kubebuilder:validation:MinLength=1
+	Reason string `json:"reason"`
+
+	// Number of seconds that each Console should run for, limited by the
+	// template's maximum. If not set, the template's default is used.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+
+	// How to treat unfinished Consoles when the next one is due: Allow,
+	// Forbid (the default) or Replace.
+	// +optional
+	ConcurrencyPolicy ConsoleScheduleConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+
+	// Deadline, in seconds, for creating a Console if it misses its scheduled
+	// time for any reason. Missed Consoles are counted as skipped. If not set,
+	// there is no deadline.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	StartingDeadlineSeconds *int64 `json:"startingDeadlineSeconds,omitempty"`
+
+	// Don't create any Consoles while set. This doesn't affect Consoles that
+	// have already been created.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// The number of successfully finished Consoles to keep. Defaults to 3.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	SuccessfulHistoryLimit *int32 `json:"successfulHistoryLimit,omitempty"`
+
+	// The number of failed Consoles to keep. Defaults to 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	FailedHistoryLimit *int32 `json:"failedHistoryLimit,omitempty"`
+
+	// The user that last created or modified the schedule, which is set by an
+	// admission webhook. Standing authorisations are given to this user's
+	// version of the schedule.
+	User string `json:"user,omitempty"`
+}
+
+// ConsoleScheduleStatus defines the observed state of ConsoleSchedule
+type ConsoleScheduleStatus struct {
+	// Consoles created by this schedule that haven't yet finished.
+	// +optional
+	Active []corev1.ObjectReference `json:"active,omitempty"`
+
+	// The last time that a Console was due to be created.
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// Whether the schedule's standing authorisation satisfies the authorisation
+	// rule of the template for its command.
+	Authorised bool `json:"authorised"`
+}
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+
+// ConsoleSchedule creates Consoles on a schedule, with a standing authorisation
+// in place of authorising each Console
+// +kubebuilder:printcolumn:name="Schedule",type="string",JSONPath=".spec.schedule"
+// +kubebuilder:printcolumn:name="Suspend",type="boolean",JSONPath=".spec.suspend"
+// +kubebuilder:printcolumn:name="Authorised",type="boolean",JSONPath=".status.authorised"
+// +kubebuilder:printcolumn:name="Last Schedule",type="date",JSONPath=".status.lastScheduleTime"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type ConsoleSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConsoleScheduleSpec   `json:"spec,omitempty"`
+	Status ConsoleScheduleStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ConsoleScheduleList contains a list of ConsoleSchedule
+type ConsoleScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConsoleSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConsoleSchedule{}, &ConsoleScheduleList{})
+}
@@ -0,0 +1,51 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:object:generate=false
+type ConsoleScheduleValidationWebhook struct {
+	logger  logr.Logger
+	decoder *admission.Decoder
+}
+
+func NewConsoleScheduleValidationWebhook(logger logr.Logger) *ConsoleScheduleValidationWebhook {
+	return &ConsoleScheduleValidationWebhook{
+		logger: logger,
+	}
+}
+
+func (c *ConsoleScheduleValidationWebhook) InjectDecoder(d *admission.Decoder) error {
+	c.decoder = d
+	return nil
+}
+
+func (c *ConsoleScheduleValidationWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	logger := c.logger.WithValues("uuid", string(req.UID))
+	logger.Info("starting request", "event", "request.start")
+
+	defer func(start time.Time) {
+		logger.Info("request completed", "event", "request.end", "duration", time.Now().Sub(start).Seconds())
+	}(time.Now())
+
+	schedule := &ConsoleSchedule{}
+	if err := c.decoder.Decode(req, schedule); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if err := schedule.Validate(); err != nil {
+		logger.Info("validation failure", "event", "validation.failure")
+		return admission.ValidationResponse(false, fmt.Sprintf("the console schedule spec is invalid: %v", err))
+	}
+
+	logger.Info("completed validation", "event", "validation.success")
+	return admission.ValidationResponse(true, "")
+}
@@ -0,0 +1,272 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// Annotation listing the policy checks that a template is exempt from, e.g.
+	// "hostPath,runAsRoot"
+	PolicyExemptAnnotation = "workloads.crd.gocardless.com/policy-exempt"
+	// Annotation explaining why a template is exempt, which is required whenever
+	// it is exempt from any checks
+	PolicyExemptReasonAnnotation = "workloads.crd.gocardless.com/policy-exempt-reason"
+)
+
+// The checks that make up the console template policy
+const (
+	PolicyCheckPrivileged        = "privileged"
+	PolicyCheckHostPath          = "hostPath"
+	PolicyCheckHostNamespaces    = "hostNamespaces"
+	PolicyCheckAddedCapabilities = "addedCapabilities"
+	PolicyCheckRunAsRoot         = "runAsRoot"
+)
+
+var policyChecks = []string{
+	PolicyCheckPrivileged,
+	PolicyCheckHostPath,
+	PolicyCheckHostNamespaces,
+	PolicyCheckAddedCapabilities,
+	PolicyCheckRunAsRoot,
+}
+
+// PolicyAction is what happens to a template that fails a policy check
+type PolicyAction string
+
+const (
+	PolicyAllow  PolicyAction = "allow"
+	PolicyWarn   PolicyAction = "warn"
+	PolicyReject PolicyAction = "reject"
+)
+
+// ParsePolicyAction validates a policy action, as given in flags or the policy
+// ConfigMap
+func ParsePolicyAction(action string) (PolicyAction, error) {
+	switch PolicyAction(action) {
+	case PolicyAllow, PolicyWarn, PolicyReject:
+		return PolicyAction(action), nil
+	default:
+		return "", fmt.Errorf("invalid policy action %q, must be one of allow, warn or reject", action)
+	}
+}
+
+// ConsoleTemplatePolicy restricts the pods that console templates can define, as
+// templates are otherwise able to create consoles with access to their nodes.
+// Each check can be configured with its own action.
+//
+// +kubebuilder:object:generate=false
+type ConsoleTemplatePolicy struct {
+	Privileged        PolicyAction `mapstructure:"privileged"`
+	HostPath          PolicyAction `mapstructure:"hostPath"`
+	HostNamespaces    PolicyAction `mapstructure:"hostNamespaces"`
+	AddedCapabilities PolicyAction `mapstructure:"addedCapabilities"`
+	RunAsRoot         PolicyAction `mapstructure:"runAsRoot"`
+
+	// Capabilities that containers may add without failing the
+	// addedCapabilities check, as a comma separated list in the ConfigMap
+	AllowedCapabilities []string `mapstructure:"allowedCapabilities"`
+}
+
+// NewConsoleTemplatePolicy returns a policy that applies the same action to
+// every check
+func NewConsoleTemplatePolicy(action PolicyAction) ConsoleTemplatePolicy {
+	return ConsoleTemplatePolicy{
+		Privileged:        action,
+		HostPath:          action,
+		HostNamespaces:    action,
+		AddedCapabilities: action,
+		RunAsRoot:         action,
+	}
+}
+
+// WithConfigMap overrides the policy with any settings in the data of a
+// ConfigMap, whose keys match the names of the checks
+func (p ConsoleTemplatePolicy) WithConfigMap(data map[string]string) (ConsoleTemplatePolicy, error) {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:  mapstructure.StringToSliceHookFunc(","),
+		ErrorUnused: true,
+		Result:      &p,
+	})
+	if err != nil {
+		return p, err
+	}
+
+	if err := decoder.Decode(data); err != nil {
+		return p, fmt.Errorf("invalid console template policy: %w", err)
+	}
+
+	for _, action := range []PolicyAction{p.Privileged, p.HostPath, p.HostNamespaces, p.AddedCapabilities, p.RunAsRoot} {
+		if _, err := ParsePolicyAction(string(action)); err != nil {
+			return p, fmt.Errorf("invalid console template policy: %w", err)
+		}
+	}
+
+	return p, nil
+}
+
+// Action returns the action for a check
+func (p ConsoleTemplatePolicy) Action(check string) PolicyAction {
+	switch check {
+	case PolicyCheckPrivileged:
+		return p.Privileged
+	case PolicyCheckHostPath:
+		return p.HostPath
+	case PolicyCheckHostNamespaces:
+		return p.HostNamespaces
+	case PolicyCheckAddedCapabilities:
+		return p.AddedCapabilities
+	case PolicyCheckRunAsRoot:
+		return p.RunAsRoot
+	default:
+		return PolicyAllow
+	}
+}
+
+// PolicyViolation describes how a template fails a policy check
+type PolicyViolation struct {
+	Check   string
+	Message string
+}
+
+func (v PolicyViolation) String() string {
+	return fmt.Sprintf("%s: %s", v.Check, v.Message)
+}
+
+// Check returns the ways in which a template's pod fails each of the policy's
+// checks, regardless of their actions or any exemptions
+func (p ConsoleTemplatePolicy) Check(template *ConsoleTemplate) []PolicyViolation {
+	var violations []PolicyViolation
+	spec := template.Spec.Template.Spec
+
+	for _, volume := range spec.Volumes {
+		if volume.HostPath != nil {
+			violations = append(violations, PolicyViolation{
+				PolicyCheckHostPath, fmt.Sprintf("volume %s mounts host path %s", volume.Name, volume.HostPath.Path),
+			})
+		}
+	}
+
+	for _, hostNamespace := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"network", spec.HostNetwork},
+		{"PID", spec.HostPID},
+		{"IPC", spec.HostIPC},
+	} {
+		if hostNamespace.enabled {
+			violations = append(violations, PolicyViolation{
+				PolicyCheckHostNamespaces, fmt.Sprintf("pod uses the host %s namespace", hostNamespace.name),
+			})
+		}
+	}
+
+	allowedCapabilities := map[string]bool{}
+	for _, capability := range p.AllowedCapabilities {
+		allowedCapabilities[normaliseCapability(capability)] = true
+	}
+
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		securityContext := container.SecurityContext
+		if securityContext == nil {
+			securityContext = &corev1.SecurityContext{}
+		}
+
+		if securityContext.Privileged != nil && *securityContext.Privileged {
+			violations = append(violations, PolicyViolation{
+				PolicyCheckPrivileged, fmt.Sprintf("container %s is privileged", container.Name),
+			})
+		}
+
+		if securityContext.Capabilities != nil {
+			for _, capability := range securityContext.Capabilities.Add {
+				if !allowedCapabilities[normaliseCapability(string(capability))] {
+					violations = append(violations, PolicyViolation{
+						PolicyCheckAddedCapabilities, fmt.Sprintf("container %s adds capability %s", container.Name, capability),
+					})
+				}
+			}
+		}
+
+		if runsAsRoot(spec.SecurityContext, securityContext) {
+			violations = append(violations, PolicyViolation{
+				PolicyCheckRunAsRoot, fmt.Sprintf("container %s may run as root", container.Name),
+			})
+		}
+	}
+
+	// Group violations by check, keeping the order in which they were found
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Check < violations[j].Check
+	})
+
+	return violations
+}
+
+// runsAsRoot returns true unless the container's effective security context
+// ensures that it runs as a non-root user, as otherwise it runs as whichever
+// user its image specifies.
+func runsAsRoot(pod *corev1.PodSecurityContext, container *corev1.SecurityContext) bool {
+	runAsUser, runAsNonRoot := container.RunAsUser, container.RunAsNonRoot
+	if pod != nil {
+		if runAsUser == nil {
+			runAsUser = pod.RunAsUser
+		}
+		if runAsNonRoot == nil {
+			runAsNonRoot = pod.RunAsNonRoot
+		}
+	}
+
+	if runAsUser != nil {
+		return *runAsUser == 0
+	}
+
+	return runAsNonRoot == nil || !*runAsNonRoot
+}
+
+// Capabilities may be given with or without their CAP_ prefix
+func normaliseCapability(capability string) string {
+	return strings.TrimPrefix(strings.ToUpper(capability), "CAP_")
+}
+
+// GetPolicyExemptions returns the checks that the template is exempt from,
+// along with the reason given for its exemption
+func (ct *ConsoleTemplate) GetPolicyExemptions() (map[string]bool, string, error) {
+	value, ok := ct.Annotations[PolicyExemptAnnotation]
+	if !ok {
+		return nil, "", nil
+	}
+
+	known := map[string]bool{}
+	for _, check := range policyChecks {
+		known[check] = true
+	}
+
+	exemptions := map[string]bool{}
+	for _, check := range strings.Split(value, ",") {
+		check = strings.TrimSpace(check)
+		if check == "" {
+			continue
+		}
+		if !known[check] {
+			return nil, "", fmt.Errorf(
+				"unknown policy check %q in %s annotation, must be one of %s",
+				check, PolicyExemptAnnotation, strings.Join(policyChecks, ", "),
+			)
+		}
+		exemptions[check] = true
+	}
+
+	reason := strings.TrimSpace(ct.Annotations[PolicyExemptReasonAnnotation])
+	if len(exemptions) > 0 && reason == "" {
+		return nil, "", fmt.Errorf("%s annotation must be set to explain policy exemptions", PolicyExemptReasonAnnotation)
+	}
+
+	return exemptions, reason, nil
+}
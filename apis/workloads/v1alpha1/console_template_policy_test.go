@@ -0,0 +1,159 @@
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("ConsoleTemplatePolicy", func() {
+	var (
+		policy   ConsoleTemplatePolicy
+		template ConsoleTemplate
+	)
+
+	BeforeEach(func() {
+		policy = NewConsoleTemplatePolicy(PolicyReject)
+
+		nonRoot := true
+		template = ConsoleTemplate{}
+		template.Spec.Template.Spec = corev1.PodSpec{
+			SecurityContext: &corev1.PodSecurityContext{RunAsNonRoot: &nonRoot},
+			Containers:      []corev1.Container{{Name: "app", Image: "app"}},
+		}
+	})
+
+	checks := func() []string {
+		var result []string
+		for _, violation := range policy.Check(&template) {
+			result = append(result, violation.Check)
+		}
+		return result
+	}
+
+	It("passes a template with a non-root pod", func() {
+		Expect(policy.Check(&template)).To(BeEmpty())
+	})
+
+	It("fails a privileged container", func() {
+		privileged := true
+		template.Spec.Template.Spec.Containers[0].SecurityContext = &corev1.SecurityContext{Privileged: &privileged}
+		Expect(checks()).To(ConsistOf(PolicyCheckPrivileged))
+	})
+
+	It("fails hostPath volumes", func() {
+		template.Spec.Template.Spec.Volumes = []corev1.Volume{{
+			Name:         "docker",
+			VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/run/docker.sock"}},
+		}}
+		Expect(checks()).To(ConsistOf(PolicyCheckHostPath))
+	})
+
+	It("fails each host namespace", func() {
+		template.Spec.Template.Spec.HostNetwork = true
+		template.Spec.Template.Spec.HostPID = true
+		Expect(checks()).To(ConsistOf(PolicyCheckHostNamespaces, PolicyCheckHostNamespaces))
+	})
+
+	It("reports host namespaces in a consistent order", func() {
+		template.Spec.Template.Spec.HostNetwork = true
+		template.Spec.Template.Spec.HostPID = true
+		template.Spec.Template.Spec.HostIPC = true
+
+		var messages []string
+		for _, violation := range policy.Check(&template) {
+			messages = append(messages, violation.Message)
+		}
+
+		Expect(messages).To(Equal([]string{
+			"pod uses the host network namespace",
+			"pod uses the host PID namespace",
+			"pod uses the host IPC namespace",
+		}))
+	})
+
+	It("fails added capabilities that aren't allowed", func() {
+		policy.AllowedCapabilities = []string{"CAP_NET_BIND_SERVICE"}
+		template.Spec.Template.Spec.Containers[0].SecurityContext = &corev1.SecurityContext{
+			Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"NET_BIND_SERVICE", "SYS_ADMIN"}},
+		}
+
+		violations := policy.Check(&template)
+		Expect(violations).To(HaveLen(1))
+		Expect(violations[0].Message).To(Equal("container app adds capability SYS_ADMIN"))
+	})
+
+	Describe("running as root", func() {
+		It("fails containers that don't require a non-root user", func() {
+			template.Spec.Template.Spec.SecurityContext = nil
+			Expect(checks()).To(ConsistOf(PolicyCheckRunAsRoot))
+		})
+
+		It("fails init containers too", func() {
+			user := int64(0)
+			template.Spec.Template.Spec.InitContainers = []corev1.Container{{
+				Name: "init", SecurityContext: &corev1.SecurityContext{RunAsUser: &user},
+			}}
+			Expect(checks()).To(ConsistOf(PolicyCheckRunAsRoot))
+		})
+
+		It("passes containers with a non-root user", func() {
+			user := int64(1000)
+			template.Spec.Template.Spec.SecurityContext = nil
+			template.Spec.Template.Spec.Containers[0].SecurityContext = &corev1.SecurityContext{RunAsUser: &user}
+			Expect(checks()).To(BeEmpty())
+		})
+	})
+
+	Describe("WithConfigMap", func() {
+		It("overrides the actions of individual checks", func() {
+			result, err := policy.WithConfigMap(map[string]string{
+				"hostPath":            "warn",
+				"allowedCapabilities": "NET_BIND_SERVICE,NET_RAW",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Action(PolicyCheckHostPath)).To(Equal(PolicyWarn))
+			Expect(result.Action(PolicyCheckPrivileged)).To(Equal(PolicyReject))
+			Expect(result.AllowedCapabilities).To(Equal([]string{"NET_BIND_SERVICE", "NET_RAW"}))
+		})
+
+		It("rejects unknown actions", func() {
+			_, err := policy.WithConfigMap(map[string]string{"hostPath": "ignore"})
+			Expect(err).To(MatchError(ContainSubstring(`invalid policy action "ignore"`)))
+		})
+
+		It("rejects unknown keys", func() {
+			_, err := policy.WithConfigMap(map[string]string{"hostNetwork": "warn"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("GetPolicyExemptions", func() {
+		It("returns the exempt checks and reason", func() {
+			template.Annotations = map[string]string{
+				PolicyExemptAnnotation:       "hostPath, runAsRoot",
+				PolicyExemptReasonAnnotation: "Debugging node issues",
+			}
+
+			exemptions, reason, err := template.GetPolicyExemptions()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exemptions).To(Equal(map[string]bool{PolicyCheckHostPath: true, PolicyCheckRunAsRoot: true}))
+			Expect(reason).To(Equal("Debugging node issues"))
+		})
+
+		It("requires a reason", func() {
+			template.Annotations = map[string]string{PolicyExemptAnnotation: "hostPath"}
+			_, _, err := template.GetPolicyExemptions()
+			Expect(err).To(MatchError(ContainSubstring(PolicyExemptReasonAnnotation)))
+		})
+
+		It("rejects unknown checks", func() {
+			template.Annotations = map[string]string{
+				PolicyExemptAnnotation:       "everything",
+				PolicyExemptReasonAnnotation: "Because",
+			}
+			_, _, err := template.GetPolicyExemptions()
+			Expect(err).To(MatchError(ContainSubstring(`unknown policy check "everything"`)))
+		})
+	})
+})
@@ -2,7 +2,9 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -39,6 +41,48 @@ type ConsoleAuthorisers struct {
 	Subjects []rbacv1.Subject `json:"subjects"`
 }
 
+// ConsoleResourceOverrides declares the compute resources that a Console may
+// request for its container, in place of those in the template.
+type ConsoleResourceOverrides struct {
+	// Bounds for the CPU of a Console. If not set, CPU can't be overridden.
+	// +optional
+	CPU *ConsoleResourceBounds `json:"cpu,omitempty"`
+
+	// Bounds for the memory of a Console. If not set, memory can't be
+	// overridden.
+	// +optional
+	Memory *ConsoleResourceBounds `json:"memory,omitempty"`
+
+	// Authorisation required for a Console that requests resources above the
+	// authorisation threshold of any of the bounds. This applies in place of
+	// the rule for the Console's command, unless that rule requires more
+	// authorisations.
+	// +optional
+	AuthorisationRule *ConsoleAuthorisers `json:"authorisationRule,omitempty"`
+}
+
+// ConsoleResourceBounds declares the range of values that a Console may request
+// for a compute resource.
+type ConsoleResourceBounds struct {
+	// +optional
+	Min *resource.Quantity `json:"min,omitempty"`
+	Max resource.Quantity  `json:"max"`
+
+	// Requests above this value require authorisation, as per the
+	// authorisationRule of the resource overrides.
+	// +optional
+	AuthorisationThreshold *resource.Quantity `json:"authorisationThreshold,omitempty"`
+}
+
+// ConsoleNetworkPolicy declares the network traffic that a Console is allowed.
+type ConsoleNetworkPolicy struct {
+	// Destinations that a Console may send traffic to, in the same form as the
+	// egress rules of a NetworkPolicy. All other egress is denied, so this
+	// should usually include DNS. If empty, all egress is denied.
+	// +optional
+	Egress []networkingv1.NetworkPolicyEgressRule `json:"egress,omitempty"`
+}
+
 // ConsoleTemplateSpec defines the desired state of ConsoleTemplate
 type ConsoleTemplateSpec struct {
 	Template corev1.PodTemplateSpec `json:"template"`
@@ -82,11 +126,66 @@ type ConsoleTemplateSpec struct {
 	// Default authorisation rule to use if no authorisation rules are defined or no authorisation rules match.
 	// +optional
 	DefaultAuthorisationRule *ConsoleAuthorisers `json:"defaultAuthorisationRule,omitempty"`
+
+	// Permit users attached to a Console created with this template to
+	// port-forward to its pod. If not set, port-forwarding is not allowed.
+	// +optional
+	AllowPortForward bool `json:"allowPortForward,omitempty"`
+
+	// Permit users to copy files to and from a Console created with this
+	// template, using `theatre-consoles cp`. If not set, the CLI refuses to copy
+	// files.
+	// This is advisory: it's only checked by the CLI, which is also what audits
+	// copied files. Console users are always granted pods/exec, so can still
+	// move data in and out of consoles by other means.
+	// +optional
+	AllowFileCopy bool `json:"allowFileCopy,omitempty"`
+
+	// Maximum number of unfinished Consoles that a single user can have for
+	// this template at any one time. If not set, or 0, there is no limit.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxConcurrentPerUser int `json:"maxConcurrentPerUser,omitempty"`
+
+	// Maximum number of unfinished Consoles that can exist for this template
+	// at any one time, across all users. If not set, or 0, there is no limit.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxConcurrent int `json:"maxConcurrent,omitempty"`
+
+	// Queue Consoles that would exceed the concurrency limits, rather than
+	// rejecting them. Queued Consoles are started in order of creation as
+	// capacity becomes available, and are still subject to their
+	// TTLSecondsBeforeRunning.
+	// +optional
+	QueueWhenAtCapacity bool `json:"queueWhenAtCapacity,omitempty"`
+
+	// Maximum time, in seconds, that a Console can be scheduled to start in
+	// advance, with spec.startAfter. If not set, this defaults to 7 days. If 0,
+	// Consoles can't be scheduled.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxStartAfterSeconds *int32 `json:"maxStartAfterSeconds,omitempty"`
+
+	// Permit Consoles to override the compute resources of their container,
+	// within these bounds. If not set, resources can't be overridden.
+	// +optional
+	ResourceOverrides *ConsoleResourceOverrides `json:"resourceOverrides,omitempty"`
+
+	// Restrict the network egress of Consoles. If set, each Console is given a
+	// NetworkPolicy selecting its pod, which denies egress other than to the
+	// declared destinations. If not set, Consoles have the same network access
+	// as any other pod with their labels, and any NetworkPolicy previously
+	// created for them is removed.
+	// +optional
+	NetworkPolicy *ConsoleNetworkPolicy `json:"networkPolicy,omitempty"`
 }
 
 // ConsoleTemplateStatus defines the observed state of ConsoleTemplate
 type ConsoleTemplateStatus struct{}
 
+// +genclient
+// +genclient:noStatus
 // +kubebuilder:object:root=true
 // +kubebuilder:storageversion
 
@@ -4,22 +4,63 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	corev1 "k8s.io/api/core/v1"
 
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
+var (
+	policyViolationsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "theatre_workloads_console_template_policy_violations_total",
+			Help: "Count of console template policy violations, by the action taken",
+		},
+		[]string{"namespace", "check", "action"},
+	)
+	policyExemptionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "theatre_workloads_console_template_policy_exemptions_total",
+			Help: "Count of console template policy violations that were allowed by an exemption",
+		},
+		[]string{"namespace", "check"},
+	)
+)
+
+// ConsoleTemplatePolicyOptions configures the policy enforced on console
+// templates
+//
+// +kubebuilder:object:generate=false
+type ConsoleTemplatePolicyOptions struct {
+	// Action for every check, unless overridden by the ConfigMap. Defaults to
+	// allow.
+	DefaultAction PolicyAction
+	// Optional reference to a ConfigMap that configures the policy, which is read
+	// on every request so that it can be changed without restarting. It should be
+	// read with an uncached client, so that the manager doesn't need to watch
+	// every ConfigMap in the cluster.
+	ConfigMapKey *client.ObjectKey
+}
+
 // +kubebuilder:object:generate=false
 type ConsoleTemplateValidationWebhook struct {
+	client  client.Reader
 	logger  logr.Logger
 	decoder *admission.Decoder
+	opts    ConsoleTemplatePolicyOptions
 }
 
-func NewConsoleTemplateValidationWebhook(logger logr.Logger) *ConsoleTemplateValidationWebhook {
+func NewConsoleTemplateValidationWebhook(c client.Reader, logger logr.Logger, opts ConsoleTemplatePolicyOptions) *ConsoleTemplateValidationWebhook {
 	return &ConsoleTemplateValidationWebhook{
+		client: c,
 		logger: logger,
+		opts:   opts,
 	}
 }
 
@@ -38,7 +79,7 @@ func (c *ConsoleTemplateValidationWebhook) Handle(ctx context.Context, req admis
 
 	template := &ConsoleTemplate{}
 	if err := c.decoder.Decode(req, template); err != nil {
-		admission.Errored(http.StatusBadRequest, err)
+		return admission.Errored(http.StatusBadRequest, err)
 	}
 
 	if err := template.Validate(); err != nil {
@@ -46,6 +87,85 @@ func (c *ConsoleTemplateValidationWebhook) Handle(ctx context.Context, req admis
 		return admission.ValidationResponse(false, fmt.Sprintf("the console template spec is invalid: %v", err))
 	}
 
+	policy, err := c.getPolicy(ctx)
+	if err != nil {
+		logger.Info("policy config error", "event", "policy.config", "error", err)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	exemptions, reason, err := template.GetPolicyExemptions()
+	if err != nil {
+		logger.Info("validation failure", "event", "validation.failure", "error", err)
+		return admission.ValidationResponse(false, fmt.Sprintf("the console template is invalid: %v", err))
+	}
+
+	logger = logger.WithValues(
+		"namespace", req.Namespace,
+		"console_template", req.Name,
+		"user", req.UserInfo.Username,
+	)
+
+	var rejections []string
+	for _, violation := range policy.Check(template) {
+		if exemptions[violation.Check] {
+			// Exemptions bypass the policy, so every use of one is logged as an audit
+			// event, attributed to whoever created or modified the template.
+			logger.Info(
+				"console template exempt from policy",
+				"event", "policy.exempted",
+				"check", violation.Check,
+				"violation", violation.Message,
+				"reason", reason,
+			)
+			policyExemptionsTotal.WithLabelValues(req.Namespace, violation.Check).Inc()
+			continue
+		}
+
+		action := policy.Action(violation.Check)
+		if action == PolicyAllow {
+			continue
+		}
+
+		policyViolationsTotal.WithLabelValues(req.Namespace, violation.Check, string(action)).Inc()
+		logger.Info(
+			"console template violates policy",
+			"event", "policy.violation",
+			"check", violation.Check,
+			"violation", violation.Message,
+			"action", action,
+		)
+
+		if action == PolicyReject {
+			rejections = append(rejections, violation.String())
+		}
+	}
+
+	if len(rejections) > 0 {
+		return admission.ValidationResponse(false, fmt.Sprintf(
+			"the console template violates policy: %s. Templates can be exempted from checks with the %s and %s annotations",
+			strings.Join(rejections, "; "), PolicyExemptAnnotation, PolicyExemptReasonAnnotation,
+		))
+	}
+
 	logger.Info("completed validation", "event", "validation.success")
 	return admission.ValidationResponse(true, "")
 }
+
+func (c *ConsoleTemplateValidationWebhook) getPolicy(ctx context.Context) (ConsoleTemplatePolicy, error) {
+	action := c.opts.DefaultAction
+	if action == "" {
+		action = PolicyAllow
+	}
+
+	policy := NewConsoleTemplatePolicy(action)
+	if c.opts.ConfigMapKey == nil {
+		return policy, nil
+	}
+
+	cfgmap := &corev1.ConfigMap{}
+	if err := c.client.Get(ctx, *c.opts.ConfigMapKey, cfgmap); err != nil {
+		return policy, err
+	}
+
+	return policy.WithConfigMap(cfgmap.Data)
+}
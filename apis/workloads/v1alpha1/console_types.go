@@ -2,6 +2,7 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -50,6 +51,28 @@ type ConsoleSpec struct {
 	// situations, enabling the TTY on a container in the console causes
 	// breakage - in Tekton steps, for example.
 	Noninteractive bool `json:"noninteractive,omitempty"`
+
+	// Compute resources for the console container, overriding those in the
+	// template. These must be within the bounds declared by the template's
+	// resourceOverrides.
+	// +optional
+	Resources *ConsoleResources `json:"resources,omitempty"`
+
+	// Time before which the Console will not be started. The Console can be
+	// authorised at any point before then, and TTLSecondsBeforeRunning is
+	// measured from this time rather than from the creation of the Console.
+	// +optional
+	StartAfter *metav1.Time `json:"startAfter,omitempty"`
+}
+
+// ConsoleResources declares the compute resources requested for a console
+// container. Each resource that is set is used as both the request and the limit
+// for the container.
+type ConsoleResources struct {
+	// +optional
+	CPU *resource.Quantity `json:"cpu,omitempty"`
+	// +optional
+	Memory *resource.Quantity `json:"memory,omitempty"`
 }
 
 // ConsoleStatus defines the observed state of Console
@@ -59,8 +82,13 @@ type ConsoleStatus struct {
 	// Time at which the job completed successfully
 	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
 	Phase          ConsolePhase `json:"phase"`
+	// Position of the console in its template's queue, starting from 1, while
+	// it is in the Queued phase
+	QueuePosition int `json:"queuePosition,omitempty"`
 }
 
+// +genclient
+// +genclient:noStatus
 // +kubebuilder:object:root=true
 // +kubebuilder:storageversion
 
@@ -0,0 +1,209 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	MaxConcurrentPerUserLimit = "maxConcurrentPerUser"
+	MaxConcurrentLimit        = "maxConcurrent"
+)
+
+var (
+	concurrencyRejectionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "theatre_workloads_console_concurrency_rejections_total",
+			Help: "Count of consoles rejected for exceeding a template concurrency limit",
+		},
+		[]string{"namespace", "console_template", "limit"},
+	)
+)
+
+// +kubebuilder:object:generate=false
+type ConsoleValidationWebhook struct {
+	client  client.Client
+	logger  logr.Logger
+	decoder *admission.Decoder
+}
+
+func NewConsoleValidationWebhook(c client.Client, logger logr.Logger) *ConsoleValidationWebhook {
+	return &ConsoleValidationWebhook{
+		client: c,
+		logger: logger,
+	}
+}
+
+func (c *ConsoleValidationWebhook) InjectDecoder(d *admission.Decoder) error {
+	c.decoder = d
+	return nil
+}
+
+func (c *ConsoleValidationWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	logger := c.logger.WithValues("uuid", string(req.UID))
+	logger.Info("starting request", "event", "request.start")
+	defer func(start time.Time) {
+		logger.Info("completed request", "event", "request.end", "duration", time.Now().Sub(start).Seconds())
+	}(time.Now())
+
+	csl := &Console{}
+	if err := c.decoder.Decode(req, csl); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// Resources are validated against the template when the console is created,
+	// and the controller trusts them from then on, so they can't be changed
+	if req.Operation == admissionv1beta1.Update {
+		existing := &Console{}
+		if err := c.decoder.DecodeRaw(req.OldObject, existing); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+
+		if !equality.Semantic.DeepEqual(existing.Spec.Resources, csl.Spec.Resources) {
+			logger.Info("validation failure", "event", "validation.failure", "error", "resources changed")
+			return admission.ValidationResponse(false, "the console spec is invalid: the spec.resources field is immutable")
+		}
+
+		// Consoles can be rescheduled, but no further in advance than the
+		// template allows, which is validated below
+		if equality.Semantic.DeepEqual(existing.Spec.StartAfter, csl.Spec.StartAfter) {
+			return admission.ValidationResponse(true, "")
+		}
+	}
+
+	// The authenticator webhook will have set the user, as mutating webhooks run
+	// before validating ones, but fall back to the requester just in case.
+	if csl.Spec.User == "" {
+		csl.Spec.User = req.UserInfo.Username
+	}
+
+	logger = logger.WithValues("console_template", csl.Spec.ConsoleTemplateRef.Name, "user", csl.Spec.User)
+
+	template := &ConsoleTemplate{}
+	err := c.client.Get(ctx, client.ObjectKey{Namespace: req.Namespace, Name: csl.Spec.ConsoleTemplateRef.Name}, template)
+	if apierrors.IsNotFound(err) {
+		// The controller reports missing templates on the console itself, so we
+		// have nothing to validate against here.
+		logger.Info("console template not found, skipping", "event", "validation.skipped")
+		return admission.ValidationResponse(true, "")
+	}
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if err := template.ValidateStartAfter(csl.Spec.StartAfter, time.Now()); err != nil {
+		logger.Info("validation failure", "event", "validation.failure", "error", err)
+		return admission.ValidationResponse(false, fmt.Sprintf("the console spec is invalid: %v", err))
+	}
+
+	// Only the start time can have changed for updates that reach this point,
+	// and updates aren't subject to the concurrency limits
+	if req.Operation == admissionv1beta1.Update {
+		logger.Info("completed validation", "event", "validation.success")
+		return admission.ValidationResponse(true, "")
+	}
+
+	if err := template.ValidateResources(csl.Spec.Resources); err != nil {
+		logger.Info("validation failure", "event", "validation.failure", "error", err)
+		return admission.ValidationResponse(false, fmt.Sprintf("the console spec is invalid: %v", err))
+	}
+
+	if template.Spec.MaxConcurrentPerUser == 0 && template.Spec.MaxConcurrent == 0 {
+		logger.Info("completed validation", "event", "validation.success")
+		return admission.ValidationResponse(true, "")
+	}
+
+	// Consoles beyond the limits of these templates are held in the Queued phase
+	// by the controller, instead of being rejected
+	if template.Spec.QueueWhenAtCapacity {
+		logger.Info("console template queues when at capacity, skipping", "event", "concurrency.skipped")
+		return admission.ValidationResponse(true, "")
+	}
+
+	consoles := &ConsoleList{}
+	if err := c.client.List(ctx, consoles, client.InNamespace(req.Namespace)); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	concurrency := &ConsoleConcurrency{
+		template: template,
+		console:  csl,
+		existing: consoles.Items,
+	}
+
+	if limit, err := concurrency.Validate(); err != nil {
+		concurrencyRejectionsTotal.With(prometheus.Labels{
+			"namespace":        req.Namespace,
+			"console_template": template.Name,
+			"limit":            limit,
+		}).Inc()
+
+		logger.Info("concurrency limit exceeded", "event", "concurrency.failure", "limit", limit, "error", err)
+		return admission.ValidationResponse(false, err.Error())
+	}
+
+	logger.Info("concurrency limits satisfied", "event", "concurrency.success")
+	return admission.ValidationResponse(true, "")
+}
+
+// ConsoleConcurrency determines whether a console can be created without exceeding
+// the concurrency limits of its template, given the consoles that already exist in
+// the namespace.
+//
+// +kubebuilder:object:generate=false
+type ConsoleConcurrency struct {
+	template *ConsoleTemplate
+	console  *Console
+	existing []Console
+}
+
+// Validate returns an error describing the limit that would be exceeded by creating
+// the console, along with the name of that limit.
+func (c *ConsoleConcurrency) Validate() (string, error) {
+	var active, activeForUser int
+	for _, csl := range c.existing {
+		if csl.Spec.ConsoleTemplateRef.Name != c.template.Name {
+			continue
+		}
+
+		// Consoles that have finished, or are on their way out, no longer count
+		// towards the limits
+		if csl.PostRunning() || csl.DeletionTimestamp != nil {
+			continue
+		}
+
+		active++
+		if csl.Spec.User == c.console.Spec.User {
+			activeForUser++
+		}
+	}
+
+	if max := c.template.Spec.MaxConcurrentPerUser; max > 0 && activeForUser >= max {
+		return MaxConcurrentPerUserLimit, fmt.Errorf(
+			"user %s already has %d unfinished console(s) for template %s, which allows at most %d per user: "+
+				"please stop an existing console before creating another",
+			c.console.Spec.User, activeForUser, c.template.Name, max,
+		)
+	}
+
+	if max := c.template.Spec.MaxConcurrent; max > 0 && active >= max {
+		return MaxConcurrentLimit, fmt.Errorf(
+			"there are already %d unfinished console(s) for template %s, which allows at most %d at once: "+
+				"please try again once another console has finished",
+			active, c.template.Name, max,
+		)
+	}
+
+	return "", nil
+}
@@ -0,0 +1,332 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var _ = Describe("Concurrency webhook", func() {
+	Describe("Validate", func() {
+		var (
+			template *ConsoleTemplate
+			existing []Console
+			limit    string
+			err      error
+		)
+
+		newConsole := func(user, templateName string, phase ConsolePhase) Console {
+			return Console{
+				Spec: ConsoleSpec{
+					User:               user,
+					ConsoleTemplateRef: corev1.LocalObjectReference{Name: templateName},
+				},
+				Status: ConsoleStatus{Phase: phase},
+			}
+		}
+
+		BeforeEach(func() {
+			template = &ConsoleTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: "template"},
+				Spec: ConsoleTemplateSpec{
+					MaxConcurrentPerUser: 2,
+					MaxConcurrent:        3,
+				},
+			}
+			existing = []Console{}
+		})
+
+		JustBeforeEach(func() {
+			csl := newConsole("user", "template", "")
+			concurrency := &ConsoleConcurrency{
+				template: template,
+				console:  &csl,
+				existing: existing,
+			}
+
+			limit, err = concurrency.Validate()
+		})
+
+		Context("With no existing consoles", func() {
+			It("Returns no errors", func() {
+				Expect(err).To(BeNil())
+			})
+		})
+
+		Context("When the user is at their limit", func() {
+			BeforeEach(func() {
+				existing = []Console{
+					newConsole("user", "template", ConsoleRunning),
+					newConsole("user", "template", ConsolePendingAuthorisation),
+				}
+			})
+
+			It("Returns an error", func() {
+				Expect(limit).To(Equal(MaxConcurrentPerUserLimit))
+				Expect(err).To(MatchError(ContainSubstring("user user already has 2 unfinished console(s) for template template")))
+			})
+		})
+
+		Context("When the user's other consoles have finished", func() {
+			BeforeEach(func() {
+				existing = []Console{
+					newConsole("user", "template", ConsoleRunning),
+					newConsole("user", "template", ConsoleStopped),
+					newConsole("user", "template", ConsoleDestroyed),
+				}
+			})
+
+			It("Returns no errors", func() {
+				Expect(err).To(BeNil())
+			})
+		})
+
+		Context("When the user's other consoles use a different template", func() {
+			BeforeEach(func() {
+				existing = []Console{
+					newConsole("user", "template", ConsoleRunning),
+					newConsole("user", "other-template", ConsoleRunning),
+				}
+			})
+
+			It("Returns no errors", func() {
+				Expect(err).To(BeNil())
+			})
+		})
+
+		Context("When the template is at its limit", func() {
+			BeforeEach(func() {
+				existing = []Console{
+					newConsole("user", "template", ConsoleRunning),
+					newConsole("other-user", "template", ConsoleRunning),
+					newConsole("another-user", "template", ConsolePending),
+				}
+			})
+
+			It("Returns an error", func() {
+				Expect(limit).To(Equal(MaxConcurrentLimit))
+				Expect(err).To(MatchError(ContainSubstring("there are already 3 unfinished console(s) for template template")))
+			})
+		})
+
+		Context("When the template has no limits", func() {
+			BeforeEach(func() {
+				template.Spec.MaxConcurrentPerUser = 0
+				template.Spec.MaxConcurrent = 0
+				existing = []Console{
+					newConsole("user", "template", ConsoleRunning),
+					newConsole("user", "template", ConsoleRunning),
+					newConsole("user", "template", ConsoleRunning),
+				}
+			})
+
+			It("Returns no errors", func() {
+				Expect(err).To(BeNil())
+			})
+		})
+	})
+})
+
+var _ = Describe("Console validation webhook", func() {
+	Describe("Updating a console", func() {
+		var (
+			template *ConsoleTemplate
+			existing *Console
+			updated  *Console
+			resp     admission.Response
+		)
+
+		withResources := func(cpu, memory string) *ConsoleResources {
+			cpuQuantity, memoryQuantity := resource.MustParse(cpu), resource.MustParse(memory)
+			return &ConsoleResources{CPU: &cpuQuantity, Memory: &memoryQuantity}
+		}
+
+		startingIn := func(d time.Duration) *metav1.Time {
+			t := metav1.NewTime(time.Now().Add(d))
+			return &t
+		}
+
+		BeforeEach(func() {
+			maxStartAfterSeconds := int32(3600)
+			template = &ConsoleTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: "template", Namespace: "staging"},
+				Spec:       ConsoleTemplateSpec{MaxStartAfterSeconds: &maxStartAfterSeconds},
+			}
+			existing = &Console{
+				ObjectMeta: metav1.ObjectMeta{Name: "console", Namespace: "staging"},
+				Spec: ConsoleSpec{
+					ConsoleTemplateRef: corev1.LocalObjectReference{Name: "template"},
+					User:               "user@example.com",
+					Resources:          withResources("1", "1Gi"),
+				},
+			}
+			updated = existing.DeepCopy()
+		})
+
+		JustBeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(AddToScheme(scheme)).To(Succeed())
+			decoder, err := admission.NewDecoder(scheme)
+			Expect(err).NotTo(HaveOccurred())
+
+			webhook := NewConsoleValidationWebhook(
+				fake.NewFakeClientWithScheme(scheme, template), zap.LoggerTo(GinkgoWriter, true),
+			)
+			Expect(webhook.InjectDecoder(decoder)).To(Succeed())
+
+			oldRaw, err := json.Marshal(existing)
+			Expect(err).NotTo(HaveOccurred())
+			raw, err := json.Marshal(updated)
+			Expect(err).NotTo(HaveOccurred())
+
+			resp = webhook.Handle(context.TODO(), admission.Request{
+				AdmissionRequest: admissionv1beta1.AdmissionRequest{
+					Operation: admissionv1beta1.Update,
+					Namespace: "staging",
+					Object:    runtime.RawExtension{Raw: raw},
+					OldObject: runtime.RawExtension{Raw: oldRaw},
+				},
+			})
+		})
+
+		Context("Changing other fields", func() {
+			BeforeEach(func() {
+				updated.Labels = map[string]string{"team": "payments"}
+			})
+
+			It("Allows the update", func() {
+				Expect(resp.Allowed).To(BeTrue())
+			})
+		})
+
+		Context("Changing resources", func() {
+			BeforeEach(func() {
+				updated.Spec.Resources = withResources("8", "64Gi")
+			})
+
+			It("Rejects the update", func() {
+				Expect(resp.Allowed).To(BeFalse())
+				Expect(string(resp.Result.Reason)).To(ContainSubstring("the spec.resources field is immutable"))
+			})
+		})
+
+		Context("Adding resources", func() {
+			BeforeEach(func() {
+				existing.Spec.Resources = nil
+			})
+
+			It("Rejects the update", func() {
+				Expect(resp.Allowed).To(BeFalse())
+			})
+		})
+
+		Context("Rescheduling within the template's maximum", func() {
+			BeforeEach(func() {
+				updated.Spec.StartAfter = startingIn(30 * time.Minute)
+			})
+
+			It("Allows the update", func() {
+				Expect(resp.Allowed).To(BeTrue())
+			})
+		})
+
+		Context("Rescheduling beyond the template's maximum", func() {
+			BeforeEach(func() {
+				existing.Spec.StartAfter = startingIn(30 * time.Minute)
+				updated.Spec.StartAfter = startingIn(2 * time.Hour)
+			})
+
+			It("Rejects the update", func() {
+				Expect(resp.Allowed).To(BeFalse())
+				Expect(string(resp.Result.Reason)).To(ContainSubstring("more than 1h0m0s in advance"))
+			})
+		})
+
+		Context("When the template's maximum was lowered after the console was scheduled", func() {
+			BeforeEach(func() {
+				existing.Spec.StartAfter = startingIn(2 * time.Hour)
+				updated.Spec.StartAfter = existing.Spec.StartAfter
+				updated.Labels = map[string]string{"team": "payments"}
+			})
+
+			It("Allows updates that don't reschedule it", func() {
+				Expect(resp.Allowed).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("Creating a console", func() {
+		var (
+			csl  *Console
+			resp admission.Response
+		)
+
+		BeforeEach(func() {
+			csl = &Console{
+				ObjectMeta: metav1.ObjectMeta{Name: "console", Namespace: "staging"},
+				Spec: ConsoleSpec{
+					ConsoleTemplateRef: corev1.LocalObjectReference{Name: "template"},
+					User:               "user@example.com",
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(AddToScheme(scheme)).To(Succeed())
+			decoder, err := admission.NewDecoder(scheme)
+			Expect(err).NotTo(HaveOccurred())
+
+			template := &ConsoleTemplate{ObjectMeta: metav1.ObjectMeta{Name: "template", Namespace: "staging"}}
+			webhook := NewConsoleValidationWebhook(
+				fake.NewFakeClientWithScheme(scheme, template), zap.LoggerTo(GinkgoWriter, true),
+			)
+			Expect(webhook.InjectDecoder(decoder)).To(Succeed())
+
+			raw, err := json.Marshal(csl)
+			Expect(err).NotTo(HaveOccurred())
+
+			resp = webhook.Handle(context.TODO(), admission.Request{
+				AdmissionRequest: admissionv1beta1.AdmissionRequest{
+					Operation: admissionv1beta1.Create,
+					Namespace: "staging",
+					Object:    runtime.RawExtension{Raw: raw},
+				},
+			})
+		})
+
+		Context("Scheduled to start within the default maximum", func() {
+			BeforeEach(func() {
+				startAfter := metav1.NewTime(time.Now().Add(24 * time.Hour))
+				csl.Spec.StartAfter = &startAfter
+			})
+
+			It("Allows the console", func() {
+				Expect(resp.Allowed).To(BeTrue())
+			})
+		})
+
+		Context("Scheduled to start beyond the default maximum", func() {
+			BeforeEach(func() {
+				startAfter := metav1.NewTime(time.Now().Add(30 * 24 * time.Hour))
+				csl.Spec.StartAfter = &startAfter
+			})
+
+			It("Rejects the console", func() {
+				Expect(resp.Allowed).To(BeFalse())
+				Expect(string(resp.Result.Reason)).To(ContainSubstring("the maximum allowed by console template template"))
+			})
+		})
+	})
+})
@@ -1,10 +1,16 @@
 package v1alpha1
 
 import (
+	"fmt"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
 )
 
 // Creating returns true if the console has no status (the console has just been created)
@@ -17,10 +23,20 @@ func (c *Console) PendingAuthorisation() bool {
 	return c.Status.Phase == ConsolePendingAuthorisation
 }
 
+// Scheduled returns true if the console is Scheduled
+func (c *Console) Scheduled() bool {
+	return c.Status.Phase == ConsoleScheduled
+}
+
+// Queued returns true if the console is Queued
+func (c *Console) Queued() bool {
+	return c.Status.Phase == ConsoleQueued
+}
+
 // PendingJob returns true if the console is in a phase that occurs before job
 // creation
 func (c *Console) PendingJob() bool {
-	return c.Creating() || c.PendingAuthorisation()
+	return c.Creating() || c.PendingAuthorisation() || c.Scheduled() || c.Queued()
 }
 
 // Pending returns true if the console is Pending
@@ -45,7 +61,7 @@ func (c *Console) Destroyed() bool {
 
 // PreRunning returns true if the console is in a phase before Running
 func (c *Console) PreRunning() bool {
-	return c.Creating() || c.PendingAuthorisation() || c.Pending()
+	return c.Creating() || c.PendingAuthorisation() || c.Scheduled() || c.Queued() || c.Pending()
 }
 
 // PostRunning returns true if the console is in a phase after Running
@@ -67,13 +83,14 @@ func (c *Console) EligibleForGC() bool {
 // nil if it cannot be.
 //
 // This will be the case if:
-// - TTLSecondsBeforeRunning has elapsed and the console hasn't progressed to running
+// - TTLSecondsBeforeRunning has elapsed since the scheduled start time and the
+//   console hasn't progressed to running
 // - TTLSecondsAfterFinished has elapsed and the console is stopped or destroyed
 func (c *Console) GetGCTime() *time.Time {
 	switch {
 	case c.PreRunning():
 		// When the console hasn't progressed to the running phase
-		t := c.CreationTimestamp.Add(c.TTLSecondsBeforeRunning())
+		t := c.ScheduledStartTime().Add(c.TTLSecondsBeforeRunning())
 		return &t
 	case c.PostRunning():
 		// When the console is completed
@@ -89,6 +106,16 @@ func (c *Console) GetGCTime() *time.Time {
 	return nil
 }
 
+// ScheduledStartTime returns the time from which the console can start, which is
+// the later of its creation and any StartAfter time
+func (c *Console) ScheduledStartTime() time.Time {
+	if c.Spec.StartAfter != nil && c.Spec.StartAfter.Time.After(c.CreationTimestamp.Time) {
+		return c.Spec.StartAfter.Time
+	}
+
+	return c.CreationTimestamp.Time
+}
+
 // TTLSecondsAfterFinished returns the console's after finished TTL as a time.Duration
 func (c *Console) TTLSecondsAfterFinished() time.Duration {
 	return time.Duration(*c.Spec.TTLSecondsAfterFinished) * time.Second
@@ -254,5 +281,345 @@ func (ct *ConsoleTemplate) Validate() error {
 		))
 	}
 
+	if overrides := ct.Spec.ResourceOverrides; overrides != nil {
+		var hasThreshold bool
+		for _, override := range overrides.all() {
+			if override.bounds == nil {
+				continue
+			}
+
+			bounds := override.bounds
+			if bounds.Min != nil && bounds.Min.Cmp(bounds.Max) > 0 {
+				err = multierror.Append(err, errors.Errorf(
+					".spec.resourceOverrides.%s: min must not be greater than max", override.name,
+				))
+			}
+
+			if bounds.AuthorisationThreshold != nil {
+				hasThreshold = true
+				if bounds.AuthorisationThreshold.Cmp(bounds.Max) >= 0 {
+					err = multierror.Append(err, errors.Errorf(
+						".spec.resourceOverrides.%s: authorisationThreshold must be less than max", override.name,
+					))
+				}
+			}
+		}
+
+		if hasThreshold && overrides.AuthorisationRule == nil {
+			err = multierror.Append(err, errors.New(
+				".spec.resourceOverrides.authorisationRule must be set if an authorisation threshold is defined",
+			))
+		}
+	}
+
+	return err
+}
+
+// ValidateResources checks that the resources requested by a console are within
+// the bounds declared by the template.
+func (ct *ConsoleTemplate) ValidateResources(resources *ConsoleResources) error {
+	if resources == nil || (resources.CPU == nil && resources.Memory == nil) {
+		return nil
+	}
+
+	overrides := ct.Spec.ResourceOverrides
+	if overrides == nil {
+		return errors.Errorf("console template %s does not permit overriding resources", ct.Name)
+	}
+
+	var err error
+	for i, override := range overrides.all() {
+		quantity := resources.all()[i]
+		if quantity == nil {
+			continue
+		}
+
+		bounds := override.bounds
+		switch {
+		case bounds == nil:
+			err = multierror.Append(err, errors.Errorf(
+				".spec.resources.%s: console template %s does not permit overriding %s",
+				override.name, ct.Name, override.name,
+			))
+		case bounds.Min != nil && quantity.Cmp(*bounds.Min) < 0:
+			err = multierror.Append(err, errors.Errorf(
+				".spec.resources.%s: %s is below the minimum of %s",
+				override.name, quantity.String(), bounds.Min.String(),
+			))
+		case quantity.Cmp(bounds.Max) > 0:
+			err = multierror.Append(err, errors.Errorf(
+				".spec.resources.%s: %s is above the maximum of %s",
+				override.name, quantity.String(), bounds.Max.String(),
+			))
+		}
+	}
+
+	return err
+}
+
+// DefaultMaxStartAfter is how far in advance consoles can be scheduled to start,
+// for templates that don't set MaxStartAfterSeconds
+const DefaultMaxStartAfter = 7 * 24 * time.Hour
+
+// GetMaxStartAfter returns how far in advance of now consoles for the template
+// can be scheduled to start
+func (ct *ConsoleTemplate) GetMaxStartAfter() time.Duration {
+	if ct.Spec.MaxStartAfterSeconds == nil {
+		return DefaultMaxStartAfter
+	}
+
+	return time.Duration(*ct.Spec.MaxStartAfterSeconds) * time.Second
+}
+
+// ValidateStartAfter checks that a console isn't scheduled to start further in
+// advance of now than the template allows.
+func (ct *ConsoleTemplate) ValidateStartAfter(startAfter *metav1.Time, now time.Time) error {
+	if startAfter == nil {
+		return nil
+	}
+
+	max := ct.GetMaxStartAfter()
+	if startAfter.Time.Sub(now) > max {
+		return errors.Errorf(
+			".spec.startAfter: %s is more than %s in advance, the maximum allowed by console template %s",
+			startAfter.Time.Format(time.RFC3339), max, ct.Name,
+		)
+	}
+
+	return nil
+}
+
+// GetAuthorisationRule returns the authorisation rule for a console with the
+// given command and resources, or nil if the console doesn't require
+// authorisation.
+//
+// Consoles with resources above any authorisation threshold of the template's
+// resource overrides use the rule for resource overrides, unless the rule for
+// their command requires more authorisations.
+func (ct *ConsoleTemplate) GetAuthorisationRule(command []string, resources *ConsoleResources) (*ConsoleAuthorisationRule, error) {
+	var rule *ConsoleAuthorisationRule
+	if ct.HasAuthorisationRules() {
+		commandRule, err := ct.GetAuthorisationRuleForCommand(command)
+		if err != nil {
+			return nil, err
+		}
+
+		rule = &commandRule
+	}
+
+	overrides := ct.Spec.ResourceOverrides
+	if overrides == nil || overrides.AuthorisationRule == nil || !overrides.exceedAuthorisationThreshold(resources) {
+		return rule, nil
+	}
+
+	if rule != nil && rule.AuthorisationsRequired > overrides.AuthorisationRule.AuthorisationsRequired {
+		return rule, nil
+	}
+
+	return &ConsoleAuthorisationRule{
+		Name:               "resource-overrides",
+		ConsoleAuthorisers: *overrides.AuthorisationRule,
+	}, nil
+}
+
+// +kubebuilder:object:generate=false
+type namedResourceBounds struct {
+	name   corev1.ResourceName
+	bounds *ConsoleResourceBounds
+}
+
+// all returns the bounds for each resource that can be overridden, in the same
+// order as ConsoleResources.all
+func (o *ConsoleResourceOverrides) all() []namedResourceBounds {
+	return []namedResourceBounds{
+		{name: corev1.ResourceCPU, bounds: o.CPU},
+		{name: corev1.ResourceMemory, bounds: o.Memory},
+	}
+}
+
+func (o *ConsoleResourceOverrides) exceedAuthorisationThreshold(resources *ConsoleResources) bool {
+	if resources == nil {
+		return false
+	}
+
+	for i, override := range o.all() {
+		quantity := resources.all()[i]
+		if quantity == nil || override.bounds == nil || override.bounds.AuthorisationThreshold == nil {
+			continue
+		}
+
+		if quantity.Cmp(*override.bounds.AuthorisationThreshold) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// all returns the quantity of each resource that can be overridden, in the same
+// order as ConsoleResourceOverrides.all
+func (r *ConsoleResources) all() []*resource.Quantity {
+	return []*resource.Quantity{r.CPU, r.Memory}
+}
+
+const (
+	DefaultConsoleScheduleSuccessfulHistoryLimit = 3
+	DefaultConsoleScheduleFailedHistoryLimit     = 1
+)
+
+// Validate checks the schedule's cron expression and concurrency policy, which
+// can't be fully expressed in the CRD's validation schema
+func (s *ConsoleSchedule) Validate() error {
+	var err error
+
+	if _, cronErr := s.CronSchedule(); cronErr != nil {
+		err = multierror.Append(err, errors.Wrap(cronErr, ".spec.schedule is invalid"))
+	}
+
+	switch s.Spec.ConcurrencyPolicy {
+	case "", AllowConcurrent, ForbidConcurrent, ReplaceConcurrent:
+	default:
+		err = multierror.Append(err, errors.Errorf(
+			".spec.concurrencyPolicy must be one of %s, %s or %s",
+			AllowConcurrent, ForbidConcurrent, ReplaceConcurrent,
+		))
+	}
+
 	return err
 }
+
+// CronSchedule parses the schedule's cron expression
+func (s *ConsoleSchedule) CronSchedule() (cron.Schedule, error) {
+	return cron.ParseStandard(s.Spec.Schedule)
+}
+
+// GetConcurrencyPolicy returns the schedule's concurrency policy, defaulting to
+// ForbidConcurrent
+func (s *ConsoleSchedule) GetConcurrencyPolicy() ConsoleScheduleConcurrencyPolicy {
+	if s.Spec.ConcurrencyPolicy == "" {
+		return ForbidConcurrent
+	}
+
+	return s.Spec.ConcurrencyPolicy
+}
+
+// GetSuccessfulHistoryLimit returns the number of successfully finished consoles
+// to keep
+func (s *ConsoleSchedule) GetSuccessfulHistoryLimit() int {
+	if s.Spec.SuccessfulHistoryLimit == nil {
+		return DefaultConsoleScheduleSuccessfulHistoryLimit
+	}
+
+	return int(*s.Spec.SuccessfulHistoryLimit)
+}
+
+// GetFailedHistoryLimit returns the number of failed consoles to keep
+func (s *ConsoleSchedule) GetFailedHistoryLimit() int {
+	if s.Spec.FailedHistoryLimit == nil {
+		return DefaultConsoleScheduleFailedHistoryLimit
+	}
+
+	return int(*s.Spec.FailedHistoryLimit)
+}
+
+// MaxMissedScheduleTimes is how many scheduled times are counted when looking for
+// the most recent, as with the starts of CronJobs
+const MaxMissedScheduleTimes = 100
+
+// GetMostRecentScheduleTime returns the latest time at or before now that a
+// console was due to be created, and hasn't been yet, or nil if there isn't one.
+//
+// Times are counted from the schedule's last schedule time or, if it has never
+// been scheduled, its creation. If more than MaxMissedScheduleTimes have passed
+// since, such as when the schedule has been suspended for a long time, they're
+// given up on, and only those since its starting deadline are counted, or none
+// without one. tooManyMissed is true when this happens, in which case the time
+// returned is nil if there are still too many to count.
+func (s *ConsoleSchedule) GetMostRecentScheduleTime(schedule cron.Schedule, now time.Time) (mostRecent *time.Time, tooManyMissed bool) {
+	from := s.CreationTimestamp.Time
+	if s.Status.LastScheduleTime != nil {
+		from = s.Status.LastScheduleTime.Time
+	}
+
+	if mostRecent, ok := mostRecentScheduleTime(schedule, from, now); ok {
+		return mostRecent, false
+	}
+
+	from = now
+	if s.Spec.StartingDeadlineSeconds != nil {
+		from = now.Add(-time.Duration(*s.Spec.StartingDeadlineSeconds) * time.Second)
+	}
+
+	mostRecent, _ = mostRecentScheduleTime(schedule, from, now)
+	return mostRecent, true
+}
+
+// mostRecentScheduleTime returns the latest scheduled time after from and at or
+// before now, unless there are more than MaxMissedScheduleTimes
+func mostRecentScheduleTime(schedule cron.Schedule, from, now time.Time) (*time.Time, bool) {
+	var mostRecent *time.Time
+	missed := 0
+	for t := schedule.Next(from); !t.IsZero() && !t.After(now); t = schedule.Next(t) {
+		if missed++; missed > MaxMissedScheduleTimes {
+			return nil, false
+		}
+
+		scheduled := t
+		mostRecent = &scheduled
+	}
+
+	return mostRecent, true
+}
+
+// MissedStartingDeadline returns true if a console that was due at the given
+// time can no longer be created, as its starting deadline has passed
+func (s *ConsoleSchedule) MissedStartingDeadline(scheduled, now time.Time) bool {
+	if s.Spec.StartingDeadlineSeconds == nil {
+		return false
+	}
+
+	deadline := time.Duration(*s.Spec.StartingDeadlineSeconds) * time.Second
+	return now.Sub(scheduled) > deadline
+}
+
+// IsActive returns true if the console is one of the schedule's unfinished
+// consoles
+func (s *ConsoleSchedule) IsActive(csl *Console) bool {
+	for _, ref := range s.Status.Active {
+		if ref.UID == csl.UID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetStandingAuthorisationName returns the name of the ConsoleAuthorisation
+// that holds the schedule's standing authorisation
+func (s *ConsoleSchedule) GetStandingAuthorisationName() string {
+	return fmt.Sprintf("%s-schedule", s.Name)
+}
+
+// ScheduledAuthorisationLabel is set on the authorisations that the console
+// schedule controller creates for the consoles of a schedule, to the UID of the
+// schedule whose standing authorisation they were copied from. Only the manager
+// can create authorisations with this label.
+const ScheduledAuthorisationLabel = "workloads.crd.gocardless.com/console-schedule-uid"
+
+// GetConsoleSchedule returns a reference to the ConsoleSchedule that created
+// the console, or nil if it wasn't created by a schedule
+func (c *Console) GetConsoleSchedule() *metav1.OwnerReference {
+	for _, ref := range c.OwnerReferences {
+		if ref.APIVersion == GroupVersion.String() && ref.Kind == "ConsoleSchedule" {
+			ref := ref
+			return &ref
+		}
+	}
+
+	return nil
+}
+
+// Succeeded returns true if the console's job completed successfully
+func (c *Console) Succeeded() bool {
+	return c.PostRunning() && c.Status.CompletionTime != nil
+}
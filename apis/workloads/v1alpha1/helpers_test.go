@@ -1,12 +1,54 @@
 package v1alpha1
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+func mustQuantity(value string) *resource.Quantity {
+	quantity := resource.MustParse(value)
+	return &quantity
+}
+
 var _ = Describe("Helpers", func() {
 
+	Describe("Console GetGCTime", func() {
+		var (
+			csl     Console
+			created time.Time
+		)
+
+		BeforeEach(func() {
+			ttl := int32(600)
+			created = time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+			csl = Console{}
+			csl.CreationTimestamp = metav1.NewTime(created)
+			csl.Spec.TTLSecondsBeforeRunning = &ttl
+			csl.Status.Phase = ConsolePendingAuthorisation
+		})
+
+		It("measures the before-running TTL from creation", func() {
+			Expect(*csl.GetGCTime()).To(Equal(created.Add(10 * time.Minute)))
+		})
+
+		Context("with a scheduled start", func() {
+			BeforeEach(func() {
+				startAfter := metav1.NewTime(created.Add(14 * time.Hour))
+				csl.Spec.StartAfter = &startAfter
+				csl.Status.Phase = ConsoleScheduled
+			})
+
+			It("measures the before-running TTL from the scheduled start", func() {
+				Expect(*csl.GetGCTime()).To(Equal(created.Add(14*time.Hour + 10*time.Minute)))
+			})
+		})
+	})
+
 	Describe("ConsoleTemplate GetAuthorisationRuleForCommand", func() {
 		var (
 			// Inputs
@@ -265,5 +307,395 @@ var _ = Describe("Helpers", func() {
 				Expect(err).To(MatchError(ContainSubstring(".spec.defaultAuthorisationRule must be set if authorisation rules are defined")))
 			})
 		})
+
+		Context("with resource bounds where min is greater than max", func() {
+			BeforeEach(func() {
+				template.Spec.ResourceOverrides = &ConsoleResourceOverrides{
+					Memory: &ConsoleResourceBounds{Min: mustQuantity("4Gi"), Max: *mustQuantity("2Gi")},
+				}
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring(".spec.resourceOverrides.memory: min must not be greater than max")))
+			})
+		})
+
+		Context("with a resource authorisation threshold but no authorisation rule", func() {
+			BeforeEach(func() {
+				template.Spec.ResourceOverrides = &ConsoleResourceOverrides{
+					CPU: &ConsoleResourceBounds{Max: *mustQuantity("4"), AuthorisationThreshold: mustQuantity("2")},
+				}
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring(".spec.resourceOverrides.authorisationRule must be set if an authorisation threshold is defined")))
+			})
+		})
+	})
+
+	Describe("ConsoleTemplate ValidateResources", func() {
+		var (
+			template  ConsoleTemplate
+			resources *ConsoleResources
+			err       error
+		)
+
+		BeforeEach(func() {
+			template = ConsoleTemplate{}
+			template.Name = "template"
+			template.Spec.ResourceOverrides = &ConsoleResourceOverrides{
+				Memory: &ConsoleResourceBounds{Min: mustQuantity("1Gi"), Max: *mustQuantity("8Gi")},
+			}
+			resources = &ConsoleResources{}
+		})
+
+		JustBeforeEach(func() {
+			err = template.ValidateResources(resources)
+		})
+
+		Context("with no overrides", func() {
+			BeforeEach(func() {
+				resources = nil
+			})
+
+			It("returns no error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("with an override within bounds", func() {
+			BeforeEach(func() {
+				resources.Memory = mustQuantity("4Gi")
+			})
+
+			It("returns no error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("with an override above the maximum", func() {
+			BeforeEach(func() {
+				resources.Memory = mustQuantity("16Gi")
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring(".spec.resources.memory: 16Gi is above the maximum of 8Gi")))
+			})
+		})
+
+		Context("with an override below the minimum", func() {
+			BeforeEach(func() {
+				resources.Memory = mustQuantity("512Mi")
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring(".spec.resources.memory: 512Mi is below the minimum of 1Gi")))
+			})
+		})
+
+		Context("with an override for a resource without bounds", func() {
+			BeforeEach(func() {
+				resources.CPU = mustQuantity("2")
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("console template template does not permit overriding cpu")))
+			})
+		})
+
+		Context("when the template doesn't permit overrides", func() {
+			BeforeEach(func() {
+				template.Spec.ResourceOverrides = nil
+				resources.Memory = mustQuantity("4Gi")
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("console template template does not permit overriding resources")))
+			})
+		})
+	})
+
+	Describe("ConsoleTemplate ValidateStartAfter", func() {
+		var (
+			template   ConsoleTemplate
+			now        time.Time
+			startAfter *metav1.Time
+			err        error
+		)
+
+		startingIn := func(d time.Duration) *metav1.Time {
+			t := metav1.NewTime(now.Add(d))
+			return &t
+		}
+
+		BeforeEach(func() {
+			template = ConsoleTemplate{}
+			template.Name = "template"
+			now = time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+			startAfter = nil
+		})
+
+		JustBeforeEach(func() {
+			err = template.ValidateStartAfter(startAfter, now)
+		})
+
+		Context("with no start time", func() {
+			It("returns no error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("with a start time within the default maximum", func() {
+			BeforeEach(func() {
+				startAfter = startingIn(DefaultMaxStartAfter)
+			})
+
+			It("returns no error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("with a start time beyond the default maximum", func() {
+			BeforeEach(func() {
+				startAfter = startingIn(DefaultMaxStartAfter + time.Minute)
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring(
+					".spec.startAfter: 2020-01-08T12:01:00Z is more than 168h0m0s in advance, the maximum allowed by console template template",
+				)))
+			})
+		})
+
+		Context("with a start time beyond the template's maximum", func() {
+			BeforeEach(func() {
+				maxStartAfterSeconds := int32(3600)
+				template.Spec.MaxStartAfterSeconds = &maxStartAfterSeconds
+				startAfter = startingIn(2 * time.Hour)
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("more than 1h0m0s in advance")))
+			})
+		})
+
+		Context("when the template doesn't permit scheduling", func() {
+			BeforeEach(func() {
+				maxStartAfterSeconds := int32(0)
+				template.Spec.MaxStartAfterSeconds = &maxStartAfterSeconds
+			})
+
+			It("rejects start times in the future", func() {
+				startAfter = startingIn(time.Minute)
+				Expect(template.ValidateStartAfter(startAfter, now)).NotTo(Succeed())
+			})
+
+			It("accepts start times that have passed", func() {
+				startAfter = startingIn(-time.Minute)
+				Expect(template.ValidateStartAfter(startAfter, now)).To(Succeed())
+			})
+		})
+	})
+
+	Describe("ConsoleTemplate GetAuthorisationRule", func() {
+		var (
+			template  ConsoleTemplate
+			resources *ConsoleResources
+			result    *ConsoleAuthorisationRule
+			err       error
+		)
+
+		BeforeEach(func() {
+			template = ConsoleTemplate{}
+			template.Spec.ResourceOverrides = &ConsoleResourceOverrides{
+				Memory: &ConsoleResourceBounds{Max: *mustQuantity("8Gi"), AuthorisationThreshold: mustQuantity("4Gi")},
+				AuthorisationRule: &ConsoleAuthorisers{
+					AuthorisationsRequired: 1,
+				},
+			}
+			resources = &ConsoleResources{Memory: mustQuantity("2Gi")}
+		})
+
+		JustBeforeEach(func() {
+			result, err = template.GetAuthorisationRule([]string{"bash"}, resources)
+		})
+
+		Context("with resources below the threshold", func() {
+			It("requires no authorisation", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(BeNil())
+			})
+		})
+
+		Context("with resources above the threshold", func() {
+			BeforeEach(func() {
+				resources.Memory = mustQuantity("6Gi")
+			})
+
+			It("returns the resource overrides rule", func() {
+				Expect(result.Name).To(Equal("resource-overrides"))
+				Expect(result.AuthorisationsRequired).To(Equal(1))
+			})
+
+			Context("and a command rule that requires more authorisations", func() {
+				BeforeEach(func() {
+					template.Spec.DefaultAuthorisationRule = &ConsoleAuthorisers{AuthorisationsRequired: 2}
+				})
+
+				It("returns the command rule", func() {
+					Expect(result.Name).To(Equal("default"))
+					Expect(result.AuthorisationsRequired).To(Equal(2))
+				})
+			})
+		})
+	})
+
+	Describe("ConsoleSchedule Validate", func() {
+		var (
+			schedule ConsoleSchedule
+		)
+
+		BeforeEach(func() {
+			schedule = ConsoleSchedule{
+				Spec: ConsoleScheduleSpec{Schedule: "30 2 * * *"},
+			}
+		})
+
+		It("accepts a valid schedule", func() {
+			Expect(schedule.Validate()).To(Succeed())
+		})
+
+		It("rejects an invalid cron expression", func() {
+			schedule.Spec.Schedule = "30 2 * *"
+			Expect(schedule.Validate()).To(MatchError(ContainSubstring(".spec.schedule is invalid")))
+		})
+
+		It("rejects an unknown concurrency policy", func() {
+			schedule.Spec.ConcurrencyPolicy = "Sometimes"
+			Expect(schedule.Validate()).To(MatchError(ContainSubstring(".spec.concurrencyPolicy must be one of")))
+		})
+	})
+
+	Describe("ConsoleSchedule GetMostRecentScheduleTime", func() {
+		var (
+			schedule ConsoleSchedule
+			created  time.Time
+		)
+
+		BeforeEach(func() {
+			created = time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+			schedule = ConsoleSchedule{
+				Spec: ConsoleScheduleSpec{Schedule: "0 * * * *"},
+			}
+			schedule.CreationTimestamp = metav1.NewTime(created)
+		})
+
+		get := func(now time.Time) *time.Time {
+			cronSchedule, err := schedule.CronSchedule()
+			Expect(err).NotTo(HaveOccurred())
+
+			mostRecent, tooManyMissed := schedule.GetMostRecentScheduleTime(cronSchedule, now)
+			Expect(tooManyMissed).To(BeFalse())
+			return mostRecent
+		}
+
+		It("returns nil before the first scheduled time", func() {
+			Expect(get(created.Add(30 * time.Minute))).To(BeNil())
+		})
+
+		It("returns the most recent of several missed times", func() {
+			Expect(*get(created.Add(150 * time.Minute))).To(Equal(created.Add(2 * time.Hour)))
+		})
+
+		Context("when the schedule has run before", func() {
+			BeforeEach(func() {
+				lastScheduleTime := metav1.NewTime(created.Add(2 * time.Hour))
+				schedule.Status.LastScheduleTime = &lastScheduleTime
+			})
+
+			It("returns nil until the next scheduled time", func() {
+				Expect(get(created.Add(150 * time.Minute))).To(BeNil())
+			})
+
+			It("returns the next scheduled time once it has passed", func() {
+				Expect(*get(created.Add(3 * time.Hour))).To(Equal(created.Add(3 * time.Hour)))
+			})
+		})
+
+		Context("when too many times have been missed", func() {
+			var now time.Time
+
+			BeforeEach(func() {
+				schedule.Spec.Schedule = "* * * * *"
+				now = created.Add(time.Duration(MaxMissedScheduleTimes+1)*time.Minute + 30*time.Second)
+			})
+
+			getMissed := func() *time.Time {
+				cronSchedule, err := schedule.CronSchedule()
+				Expect(err).NotTo(HaveOccurred())
+
+				mostRecent, tooManyMissed := schedule.GetMostRecentScheduleTime(cronSchedule, now)
+				Expect(tooManyMissed).To(BeTrue())
+				return mostRecent
+			}
+
+			It("gives up on them without a starting deadline", func() {
+				Expect(getMissed()).To(BeNil())
+			})
+
+			Context("with a starting deadline", func() {
+				BeforeEach(func() {
+					deadline := int64(300)
+					schedule.Spec.StartingDeadlineSeconds = &deadline
+				})
+
+				It("returns the most recent time since the deadline", func() {
+					Expect(*getMissed()).To(Equal(created.Add(time.Duration(MaxMissedScheduleTimes+1) * time.Minute)))
+				})
+			})
+
+			Context("with too many times since the starting deadline", func() {
+				BeforeEach(func() {
+					deadline := int64(24 * 60 * 60)
+					schedule.Spec.StartingDeadlineSeconds = &deadline
+				})
+
+				It("gives up on them", func() {
+					Expect(getMissed()).To(BeNil())
+				})
+			})
+		})
+	})
+
+	Describe("ConsoleSchedule MissedStartingDeadline", func() {
+		var (
+			schedule  ConsoleSchedule
+			scheduled time.Time
+		)
+
+		BeforeEach(func() {
+			scheduled = time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+			schedule = ConsoleSchedule{}
+		})
+
+		It("never misses without a deadline", func() {
+			Expect(schedule.MissedStartingDeadline(scheduled, scheduled.Add(24*time.Hour))).To(BeFalse())
+		})
+
+		Context("with a deadline", func() {
+			BeforeEach(func() {
+				deadline := int64(300)
+				schedule.Spec.StartingDeadlineSeconds = &deadline
+			})
+
+			It("doesn't miss within the deadline", func() {
+				Expect(schedule.MissedStartingDeadline(scheduled, scheduled.Add(5*time.Minute))).To(BeFalse())
+			})
+
+			It("misses after the deadline", func() {
+				Expect(schedule.MissedStartingDeadline(scheduled, scheduled.Add(6*time.Minute))).To(BeTrue())
+			})
+		})
 	})
 })
@@ -5,7 +5,9 @@
 package v1alpha1
 
 import (
-	"k8s.io/api/rbac/v1"
+	"k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -120,9 +122,14 @@ func (in *ConsoleAuthorisationRule) DeepCopy() *ConsoleAuthorisationRule {
 func (in *ConsoleAuthorisationSpec) DeepCopyInto(out *ConsoleAuthorisationSpec) {
 	*out = *in
 	out.ConsoleRef = in.ConsoleRef
+	if in.ConsoleScheduleRef != nil {
+		in, out := &in.ConsoleScheduleRef, &out.ConsoleScheduleRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
 	if in.Authorisations != nil {
 		in, out := &in.Authorisations, &out.Authorisations
-		*out = make([]v1.Subject, len(*in))
+		*out = make([]rbacv1.Subject, len(*in))
 		copy(*out, *in)
 	}
 }
@@ -182,7 +189,7 @@ func (in *ConsoleAuthorisers) DeepCopyInto(out *ConsoleAuthorisers) {
 	*out = *in
 	if in.Subjects != nil {
 		in, out := &in.Subjects, &out.Subjects
-		*out = make([]v1.Subject, len(*in))
+		*out = make([]rbacv1.Subject, len(*in))
 		copy(*out, *in)
 	}
 }
@@ -229,6 +236,228 @@ func (in *ConsoleList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleNetworkPolicy) DeepCopyInto(out *ConsoleNetworkPolicy) {
+	*out = *in
+	if in.Egress != nil {
+		in, out := &in.Egress, &out.Egress
+		*out = make([]networkingv1.NetworkPolicyEgressRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleNetworkPolicy.
+func (in *ConsoleNetworkPolicy) DeepCopy() *ConsoleNetworkPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleNetworkPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleResourceBounds) DeepCopyInto(out *ConsoleResourceBounds) {
+	*out = *in
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	out.Max = in.Max.DeepCopy()
+	if in.AuthorisationThreshold != nil {
+		in, out := &in.AuthorisationThreshold, &out.AuthorisationThreshold
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleResourceBounds.
+func (in *ConsoleResourceBounds) DeepCopy() *ConsoleResourceBounds {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleResourceBounds)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleResourceOverrides) DeepCopyInto(out *ConsoleResourceOverrides) {
+	*out = *in
+	if in.CPU != nil {
+		in, out := &in.CPU, &out.CPU
+		*out = new(ConsoleResourceBounds)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		*out = new(ConsoleResourceBounds)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AuthorisationRule != nil {
+		in, out := &in.AuthorisationRule, &out.AuthorisationRule
+		*out = new(ConsoleAuthorisers)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleResourceOverrides.
+func (in *ConsoleResourceOverrides) DeepCopy() *ConsoleResourceOverrides {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleResourceOverrides)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleResources) DeepCopyInto(out *ConsoleResources) {
+	*out = *in
+	if in.CPU != nil {
+		in, out := &in.CPU, &out.CPU
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleResources.
+func (in *ConsoleResources) DeepCopy() *ConsoleResources {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleResources)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleSchedule) DeepCopyInto(out *ConsoleSchedule) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleSchedule.
+func (in *ConsoleSchedule) DeepCopy() *ConsoleSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsoleSchedule) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleScheduleList) DeepCopyInto(out *ConsoleScheduleList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ConsoleSchedule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleScheduleList.
+func (in *ConsoleScheduleList) DeepCopy() *ConsoleScheduleList {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleScheduleList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsoleScheduleList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleScheduleSpec) DeepCopyInto(out *ConsoleScheduleSpec) {
+	*out = *in
+	out.ConsoleTemplateRef = in.ConsoleTemplateRef
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StartingDeadlineSeconds != nil {
+		in, out := &in.StartingDeadlineSeconds, &out.StartingDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.SuccessfulHistoryLimit != nil {
+		in, out := &in.SuccessfulHistoryLimit, &out.SuccessfulHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailedHistoryLimit != nil {
+		in, out := &in.FailedHistoryLimit, &out.FailedHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleScheduleSpec.
+func (in *ConsoleScheduleSpec) DeepCopy() *ConsoleScheduleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleScheduleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleScheduleStatus) DeepCopyInto(out *ConsoleScheduleStatus) {
+	*out = *in
+	if in.Active != nil {
+		in, out := &in.Active, &out.Active
+		*out = make([]v1.ObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleScheduleStatus.
+func (in *ConsoleScheduleStatus) DeepCopy() *ConsoleScheduleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleScheduleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConsoleSpec) DeepCopyInto(out *ConsoleSpec) {
 	*out = *in
@@ -248,6 +477,15 @@ func (in *ConsoleSpec) DeepCopyInto(out *ConsoleSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(ConsoleResources)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartAfter != nil {
+		in, out := &in.StartAfter, &out.StartAfter
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleSpec.
@@ -348,7 +586,7 @@ func (in *ConsoleTemplateSpec) DeepCopyInto(out *ConsoleTemplateSpec) {
 	in.Template.DeepCopyInto(&out.Template)
 	if in.AdditionalAttachSubjects != nil {
 		in, out := &in.AdditionalAttachSubjects, &out.AdditionalAttachSubjects
-		*out = make([]v1.Subject, len(*in))
+		*out = make([]rbacv1.Subject, len(*in))
 		copy(*out, *in)
 	}
 	if in.DefaultTTLSecondsBeforeRunning != nil {
@@ -373,6 +611,21 @@ func (in *ConsoleTemplateSpec) DeepCopyInto(out *ConsoleTemplateSpec) {
 		*out = new(ConsoleAuthorisers)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.MaxStartAfterSeconds != nil {
+		in, out := &in.MaxStartAfterSeconds, &out.MaxStartAfterSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ResourceOverrides != nil {
+		in, out := &in.ResourceOverrides, &out.ResourceOverrides
+		*out = new(ConsoleResourceOverrides)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(ConsoleNetworkPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleTemplateSpec.
@@ -399,3 +652,18 @@ func (in *ConsoleTemplateStatus) DeepCopy() *ConsoleTemplateStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyViolation) DeepCopyInto(out *PolicyViolation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyViolation.
+func (in *PolicyViolation) DeepCopy() *PolicyViolation {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyViolation)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/alecthomas/kingpin"
+	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp" // this is required to auth against GCP
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/gocardless/theatre/v2/cmd"
+	"github.com/gocardless/theatre/v2/pkg/signals"
+	"github.com/gocardless/theatre/v2/pkg/workloads/console/gateway"
+)
+
+var (
+	app = kingpin.New("theatre-consoles-gateway", "Serves a web interface for reviewing and authorising consoles").Version(cmd.VersionStanza())
+
+	commonOpts = cmd.NewCommonOptions(app)
+
+	listenAddress  = app.Flag("listen-address", "Address to bind the HTTP listener").Default("127.0.0.1:8080").String()
+	userHeader     = app.Flag("user-header", "Header set by the authenticating proxy to identify the user").Default(gateway.DefaultUserHeader).String()
+	trustedProxies = app.Flag("trusted-proxy", "CIDR of the authenticating proxy, which is trusted to set the user header. Defaults to loopback addresses").Strings()
+	allowedUsers   = app.Flag("allowed-user", "User that may use the gateway. If not set, any user except system users may").Strings()
+)
+
+func main() {
+	kingpin.MustParse(app.Parse(os.Args[1:]))
+	logger := commonOpts.Logger()
+
+	ctx, cancel := signals.SetupSignalHandler()
+	defer cancel()
+
+	opts := gateway.Options{UserHeader: *userHeader, AllowedUsers: *allowedUsers}
+	for _, cidr := range *trustedProxies {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			app.Fatalf("invalid trusted proxy: %v", err)
+		}
+
+		opts.TrustedProxies = append(opts.TrustedProxies, network)
+	}
+
+	server := &http.Server{
+		Addr: *listenAddress,
+		Handler: gateway.New(
+			ctrl.GetConfigOrDie(),
+			logger.WithName("gateway"),
+			opts,
+		),
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer shutdownCancel()
+
+		server.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info("starting server", "event", "server.start", "address", *listenAddress)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		app.Fatalf("failed to run server: %v", err)
+	}
+}
@@ -7,10 +7,12 @@ import (
 	stdlog "log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/alecthomas/kingpin"
 	kitlog "github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
+	"k8s.io/apimachinery/pkg/api/resource"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp" // this is required to auth against GCP
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -19,6 +21,7 @@ import (
 	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
 	"github.com/gocardless/theatre/v2/cmd"
 	"github.com/gocardless/theatre/v2/pkg/signals"
+	"github.com/gocardless/theatre/v2/pkg/workloads/console/archive"
 	"github.com/gocardless/theatre/v2/pkg/workloads/console/runner"
 )
 
@@ -47,6 +50,15 @@ var (
 				Bool()
 	createAttach = create.Flag("attach", "Attach to the console if it starts successfully").
 			Bool()
+	createCPU = create.Flag("cpu", "CPU for the console, overriding the template within its bounds, e.g. 2 or 500m").
+			String()
+	createMemory = create.Flag("memory", "Memory for the console, overriding the template within its bounds, e.g. 4Gi").
+			String()
+	createAt = create.Flag("at", "Schedule the console to start at this time, either RFC3339 or HH:MM for the next occurrence in local time. The console can be authorised before then").
+			String()
+	createEscape = create.Flag("escape", "Sequence typed at the start of a line to detach from the console, leaving it running. Set to an empty string to disable").
+			Default(runner.DefaultEscapeSequence).
+			String()
 	createCommand = create.Arg("command", "Command to run in console").
 			Strings()
 
@@ -54,6 +66,34 @@ var (
 	attachName = attach.Flag("name", "Console name").
 			Required().
 			String()
+	attachEscape = attach.Flag("escape", "Sequence typed at the start of a line to detach from the console, leaving it running. Set to an empty string to disable").
+			Default(runner.DefaultEscapeSequence).
+			String()
+
+	portForward     = cli.Command("port-forward", "Forward local ports to a running console")
+	portForwardName = portForward.Flag("name", "Console name").
+			Required().
+			String()
+	portForwardPorts = portForward.Arg("ports", "Ports to forward, as [LOCAL_PORT:]REMOTE_PORT").
+				Required().
+				Strings()
+
+	cp            = cli.Command("cp", "Copy files to or from a running console")
+	cpSource      = cp.Arg("source", "Local path, or <console>:<path> to copy from a console").Required().String()
+	cpDestination = cp.Arg("destination", "Local path, or <console>:<path> to copy to a console").Required().String()
+
+	logs     = cli.Command("logs", "Print the logs of a console, from its pod if it's still running and otherwise from the log archive")
+	logsName = logs.Flag("name", "Console name").
+			Required().
+			String()
+	logsUID = logs.Flag("uid", "UID of a deleted console, to read its archived logs once its name has been reused").
+		String()
+	logsFollow = logs.Flag("follow", "Follow the logs of a running console").
+			Short('f').
+			Bool()
+	logsArchiveLocation = logs.Flag("archive-location", "Location that console logs are archived to, as configured for the workloads manager").
+				Envar("THEATRE_CONSOLE_LOG_ARCHIVE_LOCATION").
+				String()
 
 	list         = cli.Command("list", "List currently running consoles")
 	listUsername = list.Flag("user", "Kubernetes username. Not usually supplied, can be inferred from your gcloud login").
@@ -108,6 +148,16 @@ func Run(ctx context.Context, logger kitlog.Logger) error {
 	// Match on the kingpin command and enter the main command
 	switch cmd {
 	case create.FullCommand():
+		resources, err := parseResources(*createCPU, *createMemory)
+		if err != nil {
+			return err
+		}
+
+		startAfter, err := parseStartAfter(*createAt, time.Now())
+		if err != nil {
+			return err
+		}
+
 		_, err = consoleRunner.Create(
 			ctx,
 			runner.CreateOptions{
@@ -118,7 +168,10 @@ func Run(ctx context.Context, logger kitlog.Logger) error {
 				Command:        *createCommand,
 				Attach:         *createAttach,
 				Noninteractive: *createNoninteractive,
+				Resources:      resources,
+				StartAfter:     startAfter,
 				KubeConfig:     config,
+				EscapeSequence: *createEscape,
 				IO: runner.IOStreams{
 					In:     os.Stdin,
 					Out:    os.Stdout,
@@ -140,9 +193,66 @@ func Run(ctx context.Context, logger kitlog.Logger) error {
 					Out:    os.Stdout,
 					ErrOut: os.Stderr,
 				},
-				Hook: LifecyclePrinter(logger),
+				Hook:           LifecyclePrinter(logger),
+				EscapeSequence: *attachEscape,
+			},
+		)
+	case portForward.FullCommand():
+		return consoleRunner.PortForward(
+			ctx,
+			runner.PortForwardOptions{
+				Namespace:  *cliNamespace,
+				KubeConfig: config,
+				Name:       *portForwardName,
+				Ports:      *portForwardPorts,
+				IO: runner.IOStreams{
+					In:     os.Stdin,
+					Out:    os.Stdout,
+					ErrOut: os.Stderr,
+				},
+			},
+		)
+	case cp.FullCommand():
+		opts, err := parseCopyOptions(*cpSource, *cpDestination)
+		if err != nil {
+			return err
+		}
+
+		opts.Namespace = *cliNamespace
+		opts.KubeConfig = config
+		opts.Hook = LifecyclePrinter(logger)
+
+		return consoleRunner.Copy(ctx, opts)
+	case logs.FullCommand():
+		var store archive.LogStore
+		if *logsArchiveLocation != "" {
+			if store, err = archive.NewLogStore(*logsArchiveLocation); err != nil {
+				return err
+			}
+		}
+
+		meta, err := consoleRunner.Logs(
+			ctx,
+			runner.LogsOptions{
+				Namespace: *cliNamespace,
+				Name:      *logsName,
+				UID:       *logsUID,
+				Follow:    *logsFollow,
+				Archive:   store,
+				Output:    os.Stdout,
 			},
 		)
+		if meta != nil {
+			logger.Log(
+				"msg", "Printed archived logs, as the console or its pod no longer exists",
+				"console", meta.Console,
+				"namespace", meta.Namespace,
+				"uid", meta.UID,
+				"user", meta.User,
+				"archived_at", meta.ArchivedAt.Format(time.RFC3339),
+			)
+		}
+		return err
 	case list.FullCommand():
 		_, err = consoleRunner.List(
 			ctx,
@@ -181,6 +291,40 @@ func LifecyclePrinter(logger kitlog.Logger) runner.LifecycleHook {
 			)
 			return nil
 		},
+		ReconnectingToConsoleFunc: func(csl *workloadsv1alpha1.Console, err error) error {
+			logger.Log(
+				"msg", "Connection to console lost, reconnecting...",
+				"console", csl.Name,
+				"namespace", csl.Namespace,
+				"pod", csl.Status.PodName,
+				"error", err,
+			)
+			return nil
+		},
+		DetachedFromConsoleFunc: func(csl *workloadsv1alpha1.Console) error {
+			logger.Log(
+				"msg", "Detached from console, which is still running",
+				"prompt", fmt.Sprintf("Reattach by running `theatre-consoles attach --name %s --namespace %s`", csl.Name, csl.Namespace),
+				"console", csl.Name,
+				"namespace", csl.Namespace,
+			)
+			return nil
+		},
+		FileCopiedFunc: func(csl *workloadsv1alpha1.Console, file runner.CopiedFile) error {
+			logger.Log(
+				"msg", "Copied file",
+				"event", "console.file_copied",
+				"direction", file.Direction,
+				"remote_path", file.RemotePath,
+				"local_path", file.LocalPath,
+				"size", file.Size,
+				"sha256", file.SHA256,
+				"console", csl.Name,
+				"namespace", csl.Namespace,
+				"user", csl.Spec.User,
+			)
+			return nil
+		},
 		ConsoleRequiresAuthorisationFunc: func(csl *workloadsv1alpha1.Console, rule *workloadsv1alpha1.ConsoleAuthorisationRule) error {
 			authoriserSlice := make([]string, 0, len(rule.ConsoleAuthorisers.Subjects))
 			for _, authoriser := range rule.ConsoleAuthorisers.Subjects {
@@ -198,6 +342,16 @@ func LifecyclePrinter(logger kitlog.Logger) runner.LifecycleHook {
 			)
 			return nil
 		},
+		ConsoleScheduledFunc: func(csl *workloadsv1alpha1.Console) error {
+			logger.Log(
+				"msg", "Console is scheduled",
+				"start_after", csl.Spec.StartAfter.Format(time.RFC3339),
+				"prompt", fmt.Sprintf("Once it has started, attach by running `theatre-consoles attach --name %s --namespace %s`", csl.Name, csl.Namespace),
+				"console", csl.Name,
+				"namespace", csl.Namespace,
+			)
+			return nil
+		},
 		ConsoleReadyFunc: func(csl *workloadsv1alpha1.Console) error {
 			logger.Log(
 				"msg", "Console is ready",
@@ -218,6 +372,100 @@ func LifecyclePrinter(logger kitlog.Logger) runner.LifecycleHook {
 	}
 }
 
+// parseStartAfter parses the value of the --at flag, returning nil if it wasn't
+// provided. Times without a date refer to their next occurrence after now, and
+// times with one must be in the future.
+func parseStartAfter(value string, now time.Time) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		if !t.After(now) {
+			return nil, fmt.Errorf("invalid --at, must be in the future: %s", value)
+		}
+
+		return &t, nil
+	}
+
+	clock, err := time.ParseInLocation("15:04", value, now.Location())
+	if err != nil {
+		return nil, fmt.Errorf("invalid --at, expected RFC3339 or HH:MM: %s", value)
+	}
+
+	t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+	if !t.After(now) {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return &t, nil
+}
+
+// parseResources builds resource overrides for a console from the values of the
+// --cpu and --memory flags, returning nil if neither were provided
+func parseResources(cpu, memory string) (*workloadsv1alpha1.ConsoleResources, error) {
+	if cpu == "" && memory == "" {
+		return nil, nil
+	}
+
+	resources := &workloadsv1alpha1.ConsoleResources{}
+	if cpu != "" {
+		quantity, err := resource.ParseQuantity(cpu)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --cpu: %w", err)
+		}
+		resources.CPU = &quantity
+	}
+
+	if memory != "" {
+		quantity, err := resource.ParseQuantity(memory)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --memory: %w", err)
+		}
+		resources.Memory = &quantity
+	}
+
+	return resources, nil
+}
+
+// parseCopyOptions determines the direction of a copy from its arguments, where exactly
+// one of the source and destination must refer to a console as <console>:<path>
+func parseCopyOptions(source, destination string) (runner.CopyOptions, error) {
+	srcConsole, srcPath := splitConsolePath(source)
+	dstConsole, dstPath := splitConsolePath(destination)
+
+	switch {
+	case srcConsole != "" && dstConsole == "":
+		return runner.CopyOptions{
+			Name:       srcConsole,
+			Direction:  runner.CopyFromConsole,
+			RemotePath: srcPath,
+			LocalPath:  dstPath,
+		}, nil
+	case srcConsole == "" && dstConsole != "":
+		return runner.CopyOptions{
+			Name:       dstConsole,
+			Direction:  runner.CopyToConsole,
+			RemotePath: dstPath,
+			LocalPath:  srcPath,
+		}, nil
+	default:
+		return runner.CopyOptions{}, errors.New("exactly one of source and destination must be a console path, as <console>:<path>")
+	}
+}
+
+// splitConsolePath returns the console name and path from <console>:<path>. Local
+// paths, including those containing a colon after a path separator, return an empty
+// console name.
+func splitConsolePath(arg string) (string, string) {
+	idx := strings.Index(arg, ":")
+	if idx < 1 || strings.Contains(arg[:idx], "/") {
+		return "", arg
+	}
+
+	return arg[:idx], arg[idx+1:]
+}
+
 // newKubeConfig first tries using internal kubernetes configuration, and then falls back
 // to ~/.kube/config
 func newKubeConfig(kctx string) (*rest.Config, error) {
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/gocardless/theatre/v2/pkg/workloads/console/runner"
+)
+
+var _ = Describe("parseCopyOptions", func() {
+	DescribeTable("Determines the direction of the copy",
+		func(source, destination string, expected runner.CopyOptions) {
+			opts, err := parseCopyOptions(source, destination)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(opts).To(Equal(expected))
+		},
+		Entry("uploads to a console", "./data.csv", "my-console:/tmp/data.csv", runner.CopyOptions{
+			Name: "my-console", Direction: runner.CopyToConsole, LocalPath: "./data.csv", RemotePath: "/tmp/data.csv",
+		}),
+		Entry("downloads from a console", "my-console:/tmp/report", "report", runner.CopyOptions{
+			Name: "my-console", Direction: runner.CopyFromConsole, LocalPath: "report", RemotePath: "/tmp/report",
+		}),
+		Entry("keeps relative remote paths", "my-console:../report", "report", runner.CopyOptions{
+			Name: "my-console", Direction: runner.CopyFromConsole, LocalPath: "report", RemotePath: "../report",
+		}),
+		Entry("treats local paths with a colon after a separator as local", "./a:b", "my-console:/tmp/a", runner.CopyOptions{
+			Name: "my-console", Direction: runner.CopyToConsole, LocalPath: "./a:b", RemotePath: "/tmp/a",
+		}),
+		Entry("treats absolute local paths with a colon as local", "/home/user/a:b", "my-console:/tmp/a", runner.CopyOptions{
+			Name: "my-console", Direction: runner.CopyToConsole, LocalPath: "/home/user/a:b", RemotePath: "/tmp/a",
+		}),
+	)
+
+	DescribeTable("Requires exactly one console path",
+		func(source, destination string) {
+			_, err := parseCopyOptions(source, destination)
+			Expect(err).To(MatchError("exactly one of source and destination must be a console path, as <console>:<path>"))
+		},
+		Entry("two local paths", "./a", "/tmp/b"),
+		Entry("two console paths", "one:/tmp/a", "two:/tmp/b"),
+		Entry("a path with a leading colon", ":/tmp/a", "./b"),
+	)
+})
+
+var _ = Describe("parseStartAfter", func() {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	DescribeTable("Parses the start time",
+		func(value string, expected time.Time) {
+			startAfter, err := parseStartAfter(value, now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*startAfter).To(BeTemporally("==", expected))
+		},
+		Entry("a future RFC3339 time", "2020-01-02T02:00:00Z", time.Date(2020, 1, 2, 2, 0, 0, 0, time.UTC)),
+		Entry("a time later today", "14:30", time.Date(2020, 1, 1, 14, 30, 0, 0, time.UTC)),
+		Entry("a time that has passed today", "02:00", time.Date(2020, 1, 2, 2, 0, 0, 0, time.UTC)),
+	)
+
+	It("Returns nil without a value", func() {
+		Expect(parseStartAfter("", now)).To(BeNil())
+	})
+
+	DescribeTable("Rejects invalid times",
+		func(value, message string) {
+			_, err := parseStartAfter(value, now)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("an RFC3339 time in the past", "2019-12-31T02:00:00Z", "must be in the future"),
+		Entry("an RFC3339 time of now", "2020-01-01T12:00:00Z", "must be in the future"),
+		Entry("an unrecognised format", "tomorrow", "expected RFC3339 or HH:MM"),
+	)
+})
@@ -2,8 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/base64"
-	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -11,7 +9,6 @@ import (
 	"os"
 	execpkg "os/exec"
 	"path"
-	"path/filepath"
 	"strings"
 	"syscall"
 
@@ -26,13 +23,13 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/gocardless/theatre/v2/cmd"
-	"github.com/gocardless/theatre/v2/pkg/signals"
+	"github.com/gocardless/theatre/v2/pkg/vault"
 )
 
 var logger logr.Logger
 
 var (
-	app = kingpin.New("theatre-envconsul", "Kubernetes container vault support using envconsul").Version(cmd.VersionStanza())
+	app = kingpin.New("theatre-envconsul", "Kubernetes container vault support").Version(cmd.VersionStanza())
 
 	commonOpts = cmd.NewCommonOptions(app)
 
@@ -41,28 +38,36 @@ var (
 
 	install                       = app.Command("install", "Install binaries into path")
 	installPath                   = install.Flag("path", "Path to install theatre binaries").Default(defaultInstallPath).String()
-	installEnvconsulBinary        = install.Flag("envconsul-binary", "Path to envconsul binary").Default("/usr/local/bin/envconsul").String()
 	installTheatreEnvconsulBinary = install.Flag("theatre-envconsul-binary", "Path to theatre-envconsul binary").Default(defaultTheatreEnvconsulPath).String()
 
-	exec                        = app.Command("exec", "Authenticate with vault and exec envconsul")
+	exec                        = app.Command("exec", "Authenticate with vault, resolve secrets and exec the command")
 	execVaultOptions            = newVaultOptions(exec)
 	execConfigFile              = exec.Flag("config-file", "App config file").String()
-	execInstallPath             = exec.Flag("install-path", "Path containing installed binaries").Default(defaultInstallPath).String()
-	execTheatreEnvconsulBinary  = exec.Flag("theatre-envconsul-binary", "Path to theatre-envconsul binary").Default(defaultTheatreEnvconsulPath).String()
 	execServiceAccountTokenFile = exec.Flag("service-account-token-file", "Path to Kubernetes service account token file").String()
+	execSupervise               = exec.Flag("supervise", "Remain the parent of the command, keeping its secrets up to date").Bool()
+	execSuperviseOnChange       = exec.Flag("supervise-on-change", "Signal to send the command when its secrets change, or 'restart' to restart it").Default("SIGHUP").String()
+	execSuperviseInterval       = exec.Flag("supervise-interval", "Interval between reading secrets when supervising").Default("1m").Duration()
+	execSuperviseRestartTimeout = exec.Flag("supervise-restart-timeout", "Time the command has to exit when restarting, before it is killed").Default("30s").Duration()
 	execCommand                 = exec.Arg("command", "Command to execute").Required().Strings()
 
-	base64Exec        = app.Command("base64-exec", "Decode base64 encoded args and exec them").Hidden()
-	base64ExecCommand = base64Exec.Arg("base64-command", "Command to execute").Required().Strings()
+	resolve                        = app.Command("resolve", "Authenticate with vault and write resolved secrets to files, for another container to read")
+	resolveVaultOptions            = newVaultOptions(resolve)
+	resolveConfigFile              = resolve.Flag("config-file", "App config file").String()
+	resolveServiceAccountTokenFile = resolve.Flag("service-account-token-file", "Path to Kubernetes service account token file").String()
+	resolveOutputPath              = resolve.Flag("output-path", "Directory to write the env file and secret files to").Required().String()
 
-	envCmd = app.Command("env", "Output environment as JSON").Hidden()
+	// Pods created before secrets were resolved natively still pass this flag, so
+	// we accept it to avoid breaking them, but it has no effect.
+	_ = exec.Flag("install-path", "Path containing installed binaries (unused)").Hidden().String()
 )
 
 func main() {
 	command := kingpin.MustParse(app.Parse(os.Args[1:]))
 	logger = commonOpts.Logger()
 
-	ctx, cancel := signals.SetupSignalHandler()
+	// Signals aren't handled here, as exec either replaces this process with the
+	// command or supervises it, forwarding signals to the command
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	if err := mainError(ctx, command); err != nil {
@@ -77,195 +82,97 @@ func mainError(ctx context.Context, command string) (err error) {
 	// prime any target containers with the tools they will need to authenticate with Vault
 	// and pull secrets.
 	case install.FullCommand():
-		files := map[string]string{
-			*installEnvconsulBinary:        "envconsul",
-			*installTheatreEnvconsulBinary: "theatre-envconsul",
-		}
-
 		logger.Info("copying files into install path", "file_path", *installPath)
-		for src, dstName := range files {
-			if err := copyExecutable(src, path.Join(*installPath, dstName)); err != nil {
-				return errors.Wrap(err, "error copying file")
-			}
+		if err := copyExecutable(*installTheatreEnvconsulBinary, path.Join(*installPath, "theatre-envconsul")); err != nil {
+			return errors.Wrap(err, "error copying file")
 		}
 
-	// Run the authentication dance against Vault, exchanging our Kubernetes service account
-	// token for a Vault token that can read secrets. Then prepare a Hashicorp envconsul
-	// configuration file and exec into envconsul with the Vault token, leaving envconsul to
-	// do all the secret fetching and lease management.
+	// Run the authentication dance against Vault, using the selected auth method to obtain
+	// a Vault token that can read secrets. Then resolve any environment variables that
+	// reference Vault secrets, and exec the command with the resolved environment.
 	case exec.FullCommand():
-		var vaultToken string
-		if execVaultOptions.Token == "" {
-			serviceAccountToken, err := getKubernetesToken(*execServiceAccountTokenFile)
-			if err != nil {
-				return errors.Wrap(err, "failed to authenticate within kubernetes")
-			}
-
-			execVaultOptions.Decorate(logger).Info("logging into vault", "event", "vault.login")
-			vaultToken, err = execVaultOptions.Login(serviceAccountToken)
-			if err != nil {
-				return errors.Wrap(err, "failed to login to vault")
-			}
-		}
-
-		var env = environment{}
-
-		// Load all the environment variables we currently know from our process
-		for _, element := range os.Environ() {
-			nameValue := strings.SplitN(element, "=", 2)
-			env[nameValue[0]] = nameValue[1]
-		}
-
-		if *execConfigFile != "" {
-			logger.Info(
-				fmt.Sprintf("loading config from %s", *execConfigFile),
-				"event", "config.load",
-				"file_path", *execConfigFile,
-			)
-			config, err := loadConfigFromFile(*execConfigFile)
+		var superviseAction vault.SuperviseAction
+		if *execSupervise {
+			superviseAction, err = vault.ParseSuperviseAction(*execSuperviseOnChange)
 			if err != nil {
 				return err
 			}
-
-			// Load all the values from our config, which will now override what is set in the
-			// environment variables of the current process
-			for key, value := range config.Environment {
-				env[key] = value
-			}
-		}
-
-		var filePaths = environment{}
-
-		// Rewrite 'vault-file:' prefixed env vars to 'vault:' prefixed env vars. Store the
-		// paths to which they should be written to in filePaths. When no path is
-		// provided, use "" as a placeholder.
-		//
-		// For reference, the expected formats are 'vault-file:tls-key/2021010100' and
-		// 'vault-file:ssh-key/2021010100:/home/user/.ssh/id_rsa'
-		for key, value := range env {
-			if strings.HasPrefix(value, "vault-file:") {
-				trimmed := strings.TrimSpace(
-					strings.TrimPrefix(value, "vault-file:"),
-				)
-				if len(trimmed) == 0 {
-					return fmt.Errorf("empty vault-file env var: %v", value)
-				}
-
-				split := strings.SplitN(trimmed, ":", 2)
-
-				// determine if we define a path at which to place the file. For SplitN,
-				// N=2 so we only have two cases
-				switch len(split) {
-				case 2: // path and key
-					filePaths[key] = split[1]
-					env[key] = fmt.Sprintf("vault:%s", split[0])
-				case 1: // just key
-					filePaths[key] = ""
-					env[key] = fmt.Sprintf("vault:%s", trimmed)
-				}
-			}
 		}
 
-		var secretEnv = environment{}
-
-		// For all the environment values that look like they should be vault references, we
-		// can place them in secretEnv so we can render an envconsul configuration file for
-		// them.
-		for key, value := range env {
-			if strings.HasPrefix(value, "vault:") {
-				secretEnv[key] = strings.TrimPrefix(value, "vault:")
-			}
+		login, err := loginToVault(execVaultOptions, *execServiceAccountTokenFile)
+		if err != nil {
+			return err
 		}
 
-		envconsulConfig := execVaultOptions.EnvconsulConfig(
-			secretEnv, vaultToken, *execTheatreEnvconsulBinary,
-			[]string{*execTheatreEnvconsulBinary, "env"},
-		)
-		configJSONContents, err := json.Marshal(envconsulConfig)
+		env, templates, err := loadEnvironment(*execConfigFile)
 		if err != nil {
 			return err
 		}
 
-		tempConfigFile, err := ioutil.TempFile("", "envconsul-config-*.json")
+		client, err := execVaultOptions.Client()
 		if err != nil {
-			return errors.Wrap(err, "failed to create temporary file for envconsul")
+			return errors.Wrap(err, "failed to create vault client")
 		}
 
-		logger.Info(
-			"creating envconsul config file",
-			"event", "envconsul_config_file.create",
-			"path", tempConfigFile.Name(),
-		)
-		if err := ioutil.WriteFile(tempConfigFile.Name(), configJSONContents, 0444); err != nil {
-			return errors.Wrap(err, "failed to write temporary file for envconsul")
+		resolver := &vault.Resolver{
+			Client:     client,
+			PathPrefix: execVaultOptions.PathPrefix,
+			Logger:     logger,
 		}
 
-		// Set all our environment variables which will proxy through to our exec'd process
-		for key, value := range env {
-			os.Setenv(key, value)
+		// Dynamic secrets have leases that must be renewed and revoked, which requires us to
+		// remain the parent of the command. Unless asked to supervise, secrets are only read
+		// again when the leases expire, and the command is restarted with the new values.
+		supervise, superviseInterval := *execSupervise, *execSuperviseInterval
+		if !supervise && vault.HasDynamicReferences(env) {
+			supervise, superviseInterval = true, 0
+			superviseAction = vault.SuperviseAction{Restart: true}
 		}
 
-		envconsulBinaryPath := path.Join(*execInstallPath, "envconsul")
-		envconsulArgs := []string{"-once", "-config", tempConfigFile.Name()}
-
-		logger.Info(
-			"executing envconsul",
-			"event", "envconsul.exec",
-			"binary", envconsulBinaryPath,
-			"path", tempConfigFile.Name(),
-		)
+		if supervise {
+			supervisor := &vault.Supervisor{
+				Resolver:       resolver,
+				Logger:         logger,
+				Env:            env,
+				Command:        *execCommand,
+				Templates:      templates,
+				OnChange:       superviseAction,
+				Interval:       superviseInterval,
+				RestartTimeout: *execSuperviseRestartTimeout,
+				Login:          login,
+				Reap:           os.Getpid() == 1,
+			}
 
-		output, err := execpkg.CommandContext(ctx, envconsulBinaryPath, envconsulArgs...).Output()
-		if err != nil {
-			if ee, ok := err.(*execpkg.ExitError); ok {
-				output = ee.Stderr
+			logger.Info(
+				"supervising wrapped application",
+				"event", "theatre_envconsul.supervise",
+				"on_change", superviseAction.String(),
+			)
+			code, err := supervisor.Run(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to supervise application")
 			}
 
-			return errors.Wrapf(err, "failed to get envconsul environment variables: %s", output)
+			os.Exit(code)
 		}
 
-		envMap := map[string]string{}
-		err = json.Unmarshal(output, &envMap)
+		logger.Info("resolving vault secrets", "event", "secrets.resolve")
+		secretEnv, err := resolver.ResolveEnvironment(env)
 		if err != nil {
-			return errors.Wrap(err, "failed to decode envconsul environment variables")
+			return errors.Wrap(err, "failed to resolve vault secrets")
 		}
 
-		// For every file reference in filePaths, write the value resolved by envconsul to
-		// the path in filePaths. Returns the path of the written file in the env var that
-		// requested it.
-		for key, path := range filePaths {
-			if path == "" {
-				// generate file path prefixed by key
-				tempFilePath, err := ioutil.TempFile("", fmt.Sprintf("%s-*", key))
-				if err != nil {
-					return errors.Wrap(err, fmt.Sprintf("failed to write temporary file for key %s", key))
-				}
-
-				path = tempFilePath.Name()
-			}
-			// ensure the path structure is available
-			err := os.MkdirAll(filepath.Dir(path), 0600)
-			if err != nil {
-				return fmt.Errorf("failed to ensure path structure is available: %s", err.Error())
-			}
-
-			logger.Info(
-				"creating vault secret file",
-				"event", "envconsul_secret_file.create",
-				"path", path,
-			)
-			// write file with value of envMap[key]
-			if err := ioutil.WriteFile(path, []byte(envMap[key]), 0600); err != nil {
-				return errors.Wrap(err,
-					fmt.Sprintf("failed to write file with key %s to path %s", key, path))
-			}
+		if _, err := resolver.RenderTemplates(templates); err != nil {
+			return errors.Wrap(err, "failed to render templates")
+		}
 
-			// update the env with the location of the file we've written
-			envMap[key] = path
+		// Resolved secrets replace their references in the environment of our exec'd
+		// process
+		for key, value := range secretEnv {
+			env[key] = value
 		}
 
-		// Update the environment variables based on updated environment variables
-		for key, value := range envMap {
+		for key, value := range env {
 			os.Setenv(key, value)
 		}
 
@@ -288,47 +195,64 @@ func mainError(ctx context.Context, command string) (err error) {
 
 		// Run the command directly
 		if err := syscall.Exec(binary, args, os.Environ()); err != nil {
-			return errors.Wrap(err, "failed to execute envconsul")
+			return errors.Wrap(err, "failed to execute application")
 		}
 
-	// Hidden command that allows us to exec a command using base64 encoded arguments. As
-	// envconsul, the Hashicorp tool, only allows us to specify a command string, we have to
-	// ensure we preserve the original commands shellword split.
-	//
-	// We use the exec command to generate an envconsul config with base64 encoded
-	// arguments, passed to this base64-exec command, that we know will be split correctly.
-	// This command then does the final execution, ensuring the split remains consistent.
-	case base64Exec.FullCommand():
-		args := []string{}
-		for _, base64arg := range *base64ExecCommand {
-			arg, err := base64.StdEncoding.DecodeString(base64arg)
-			if err != nil {
-				app.Fatalf("failed to decode base64 argument: %s", arg)
-			}
+	// Resolve secrets once and write them to files, rather than running a command. This is
+	// run in an init container, for containers that can't have theatre-envconsul run their
+	// command, and which read their secrets from a volume shared with it instead.
+	case resolve.FullCommand():
+		if _, err := loginToVault(resolveVaultOptions, *resolveServiceAccountTokenFile); err != nil {
+			return err
+		}
 
-			args = append(args, string(arg))
+		env, templates, err := loadEnvironment(*resolveConfigFile)
+		if err != nil {
+			return err
 		}
 
-		var err error
-		args[0], err = execpkg.LookPath(args[0])
+		client, err := resolveVaultOptions.Client()
 		if err != nil {
-			app.Fatalf("could not resolve binary for command: %v", err)
+			return errors.Wrap(err, "failed to create vault client")
+		}
+
+		// Files for vault-file references without a path must be in the output path, as
+		// anywhere else disappears when we exit
+		filesPath := path.Join(*resolveOutputPath, "files")
+		if err := os.MkdirAll(filesPath, 0700); err != nil {
+			return errors.Wrap(err, "failed to create output path")
 		}
 
-		if err := syscall.Exec(args[0], args, os.Environ()); err != nil {
-			return errors.Wrap(err, "failed to execute decoded arguments")
+		resolver := &vault.Resolver{
+			Client:     client,
+			PathPrefix: resolveVaultOptions.PathPrefix,
+			Logger:     logger,
+			TempDir:    filesPath,
 		}
-	case envCmd.FullCommand():
-		envMap := map[string]string{}
-		for _, envEntry := range os.Environ() {
-			vals := strings.SplitN(envEntry, "=", 2)
-			envMap[vals[0]] = vals[1]
+
+		if vault.HasDynamicReferences(env) {
+			logger.Info(
+				"dynamic secrets will not be renewed once resolved, and will expire at the end of their TTL",
+				"event", "secrets.dynamic_unsupervised",
+			)
 		}
-		err := json.NewEncoder(os.Stdout).Encode(envMap)
+
+		logger.Info("resolving vault secrets", "event", "secrets.resolve")
+		secretEnv, err := resolver.ResolveEnvironment(env)
 		if err != nil {
-			return fmt.Errorf("failed to encode environment: %w", err)
+			return errors.Wrap(err, "failed to resolve vault secrets")
 		}
 
+		if _, err := resolver.RenderTemplates(templates); err != nil {
+			return errors.Wrap(err, "failed to render templates")
+		}
+
+		if err := vault.WriteOutput(*resolveOutputPath, secretEnv); err != nil {
+			return errors.Wrap(err, "failed to write resolved secrets")
+		}
+
+		logger.Info("wrote resolved secrets", "event", "secrets.write", "path", *resolveOutputPath)
+
 	default:
 		panic("unrecognised command")
 	}
@@ -336,6 +260,80 @@ func mainError(ctx context.Context, command string) (err error) {
 	return nil
 }
 
+// loginToVault obtains a Vault token with the auth method selected by our options,
+// unless one was given explicitly. It returns a function to log in again once the token
+// can no longer be renewed, or nil if the token was given explicitly, as it can't be
+// replaced.
+func loginToVault(opts *vaultOptions, serviceAccountTokenFile string) (func() (string, error), error) {
+	authMethod, err := opts.Auth(serviceAccountTokenFile)
+	if err != nil {
+		return nil, err
+	}
+
+	login := func() (string, error) {
+		client, err := opts.Client()
+		if err != nil {
+			return "", errors.Wrap(err, "failed to create vault client")
+		}
+
+		// Logging in again happens once our token has expired, so it mustn't be sent
+		client.ClearToken()
+
+		opts.Decorate(logger).Info("logging into vault", "event", "vault.login")
+		token, err := authMethod.Login(client)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to login to vault")
+		}
+
+		return token, nil
+	}
+
+	if opts.Token != "" {
+		return nil, nil
+	}
+
+	opts.Token, err = login()
+	if err != nil {
+		return nil, err
+	}
+
+	return login, nil
+}
+
+// loadEnvironment returns the environment variables of our process, overridden by those
+// of the config file if one is given, along with the templates of the config file
+func loadEnvironment(configFile string) (environment, []vault.Template, error) {
+	var env = environment{}
+
+	// Load all the environment variables we currently know from our process
+	for _, element := range os.Environ() {
+		nameValue := strings.SplitN(element, "=", 2)
+		env[nameValue[0]] = nameValue[1]
+	}
+
+	if configFile == "" {
+		return env, nil, nil
+	}
+
+	logger.Info(
+		fmt.Sprintf("loading config from %s", configFile),
+		"event", "config.load",
+		"file_path", configFile,
+	)
+	config, err := loadConfigFromFile(configFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Load all the values from our config, which will now override what is set in the
+	// environment variables of the current process
+	for key, value := range config.Environment {
+		env[key] = value
+	}
+
+	return env, config.Templates, nil
+}
+
 // getKubernetesToken attempts to construct a Kubernetes client configuration, preferring
 // in cluster auth but falling back to other detection methods if that fails.
 func getKubernetesToken(tokenFileOverride string) (string, error) {
@@ -388,21 +386,33 @@ func copyExecutable(src, dst string) error {
 }
 
 type vaultOptions struct {
-	Address               string
-	UseTLS                bool
-	InsecureSkipVerify    bool
-	Token                 string
-	AuthBackendMountPoint string
-	AuthBackendRole       string
-	PathPrefix            string
+	Address                 string
+	Namespace               string
+	UseTLS                  bool
+	InsecureSkipVerify      bool
+	Token                   string
+	AuthMethod              string
+	AuthBackendMountPoint   string
+	AuthBackendRole         string
+	AuthJWTFile             string
+	AuthAppRoleRoleID       string
+	AuthAppRoleSecretIDFile string
+	AuthTokenFile           string
+	PathPrefix              string
 }
 
 func newVaultOptions(cmd *kingpin.CmdClause) *vaultOptions {
 	opt := &vaultOptions{}
 
-	cmd.Flag("auth-backend-mount-path", "Vault auth backend mount path").Default("kubernetes").StringVar(&opt.AuthBackendMountPoint)
-	cmd.Flag("auth-backend-role", "Vault auth backend role").Default("default").StringVar(&opt.AuthBackendRole)
+	cmd.Flag("auth-method", "Vault auth method, one of: "+strings.Join(vault.AuthMethods, ", ")).Default(vault.AuthMethodKubernetes).EnumVar(&opt.AuthMethod, vault.AuthMethods...)
+	cmd.Flag("auth-backend-mount-path", "Vault auth backend mount path, defaulting to the name of the auth method").StringVar(&opt.AuthBackendMountPoint)
+	cmd.Flag("auth-backend-role", "Vault auth backend role, for the kubernetes and jwt auth methods").Default("default").StringVar(&opt.AuthBackendRole)
+	cmd.Flag("auth-jwt-file", "Path to the JWT for the jwt auth method, defaulting to the service account token").StringVar(&opt.AuthJWTFile)
+	cmd.Flag("auth-approle-role-id", "Role ID for the approle auth method").StringVar(&opt.AuthAppRoleRoleID)
+	cmd.Flag("auth-approle-secret-id-file", "Path to the secret ID for the approle auth method").StringVar(&opt.AuthAppRoleSecretIDFile)
+	cmd.Flag("auth-token-file", "Path to the Vault token for the token-file auth method").StringVar(&opt.AuthTokenFile)
 	cmd.Flag("vault-address", "Address of vault (format: scheme://host:port)").Required().StringVar(&opt.Address)
+	cmd.Flag("vault-namespace", "Vault Enterprise namespace to use, sent as the namespace header of every request").OverrideDefaultFromEnvar("VAULT_NAMESPACE").StringVar(&opt.Namespace)
 	cmd.Flag("vault-token", "Vault token to use, instead of Kubernetes auth").OverrideDefaultFromEnvar("VAULT_TOKEN").StringVar(&opt.Token)
 	cmd.Flag("vault-use-tls", "Use TLS when connecting to Vault").Default("true").BoolVar(&opt.UseTLS)
 	cmd.Flag("vault-insecure-skip-verify", "Skip TLS certificate verification when connecting to Vault").Default("false").BoolVar(&opt.InsecureSkipVerify)
@@ -429,6 +439,10 @@ func (o *vaultOptions) Client() (*api.Client, error) {
 		return nil, err
 	}
 
+	if o.Namespace != "" {
+		client.SetNamespace(o.Namespace)
+	}
+
 	if o.Token != "" {
 		client.SetToken(o.Token)
 	}
@@ -439,49 +453,71 @@ func (o *vaultOptions) Client() (*api.Client, error) {
 func (o *vaultOptions) Decorate(logger logr.Logger) logr.Logger {
 	return logger.WithValues(
 		"address", o.Address,
+		"namespace", o.Namespace,
+		"method", o.AuthMethod,
 		"backend", o.AuthBackendMountPoint,
 		"role", o.AuthBackendRole,
 	)
 }
 
-// Login uses the kubernetes service account token to authenticate against the Vault
-// server. The Vault server is configured with a specific authentication backend that can
-// validate the service account token we provide is valid. We are asking Vault to assign
-// us the specified role.
-func (o *vaultOptions) Login(jwt string) (string, error) {
-	client, err := o.Client()
-	if err != nil {
-		return "", err
-	}
+// Auth returns the auth method selected by our flags, which is used to obtain a Vault
+// token. Kubernetes auth uses the service account token from the given file, or our
+// cluster config if no file is given, while JWT auth falls back to that file if no
+// other JWT is provided.
+func (o *vaultOptions) Auth(serviceAccountTokenFile string) (vault.AuthMethod, error) {
+	switch o.AuthMethod {
+	case vault.AuthMethodKubernetes:
+		return vault.KubernetesAuth{
+			MountPath: o.AuthBackendMountPoint,
+			Role:      o.AuthBackendRole,
+			ServiceAccountToken: func() (string, error) {
+				token, err := getKubernetesToken(serviceAccountTokenFile)
+				if err != nil {
+					return "", errors.Wrap(err, "failed to authenticate within kubernetes")
+				}
 
-	req := client.NewRequest("POST", fmt.Sprintf("/v1/auth/%s/login", o.AuthBackendMountPoint))
-	req.SetJSONBody(map[string]string{
-		"jwt":  jwt,
-		"role": o.AuthBackendRole,
-	})
+				return token, nil
+			},
+		}, nil
+	case vault.AuthMethodJWT:
+		tokenFile := o.AuthJWTFile
+		if tokenFile == "" {
+			tokenFile = serviceAccountTokenFile
+		}
 
-	resp, err := client.RawRequest(req)
-	if err != nil {
-		return "", err
-	}
+		if tokenFile == "" {
+			return nil, fmt.Errorf("--auth-jwt-file or --service-account-token-file is required for jwt auth")
+		}
 
-	if err := resp.Error(); err != nil {
-		return "", err
-	}
+		return vault.JWTAuth{MountPath: o.AuthBackendMountPoint, Role: o.AuthBackendRole, TokenFile: tokenFile}, nil
+	case vault.AuthMethodAppRole:
+		if o.AuthAppRoleRoleID == "" {
+			return nil, fmt.Errorf("--auth-approle-role-id is required for approle auth")
+		}
 
-	var secret api.Secret
-	if err := resp.DecodeJSON(&secret); err != nil {
-		return "", errors.Wrap(err, "failed to decode vault login response")
+		return vault.AppRoleAuth{
+			MountPath:    o.AuthBackendMountPoint,
+			RoleID:       o.AuthAppRoleRoleID,
+			SecretIDFile: o.AuthAppRoleSecretIDFile,
+		}, nil
+	case vault.AuthMethodTokenFile:
+		if o.AuthTokenFile == "" {
+			return nil, fmt.Errorf("--auth-token-file is required for token-file auth")
+		}
+
+		return vault.TokenFileAuth{Path: o.AuthTokenFile}, nil
 	}
 
-	return secret.Auth.ClientToken, nil
+	return nil, vault.ValidateAuthMethod(o.AuthMethod)
 }
 
 // Config is the configuration file format that the exec command will use to parse the
-// Vault references that it will pass onto the envconsul command. We expect application
-// developers to include this file within their applications.
+// Vault references that it will resolve into the environment of the command. We expect
+// application developers to include this file within their applications.
 type Config struct {
 	Environment environment `yaml:"environment"`
+	// Templates are rendered with secrets and written to files before running the command
+	Templates []vault.Template `yaml:"templates"`
 }
 
 type environment map[string]string
@@ -498,84 +534,9 @@ func loadConfigFromFile(configFile string) (Config, error) {
 		return cfg, errors.Wrap(err, "failed to parse config")
 	}
 
-	if cfg.Environment == nil {
-		return cfg, fmt.Errorf("missing 'environment' key in configuration file")
+	if cfg.Environment == nil && len(cfg.Templates) == 0 {
+		return cfg, fmt.Errorf("missing 'environment' or 'templates' key in configuration file")
 	}
 
 	return cfg, nil
 }
-
-// EnvconsulConfig generates a configuration file that envconsul (hashicorp) can read, and
-// will use to resolve secret values into environment variables.
-//
-// This will only work if your vault secrets have exactly one key. The format specifier we
-// pass to envconsul uses no interpolation, so multiple keys in a vault secret would be
-// assigned the same environment variable. This is undefined behaviour, resulting in
-// subsequent executions setting different values for the same env var.
-func (o *vaultOptions) EnvconsulConfig(env environment, token string, theatreEnvconsulPath string, args []string) *EnvconsulConfig {
-	base64args := []string{}
-	for _, arg := range args {
-		base64args = append(base64args, base64.StdEncoding.EncodeToString([]byte(arg)))
-	}
-
-	cfg := &EnvconsulConfig{
-		Vault: envconsulVault{
-			Address: o.Address,
-			Token:   token,
-			Retry: envconsulRetry{
-				Enabled: false,
-			},
-			SSL: envconsulSSL{
-				Enabled: o.UseTLS,
-				Verify:  !o.InsecureSkipVerify,
-			},
-		},
-		Exec: envconsulExec{
-			// Base64 encode the command and pass it to theatre-envconsul base64-exec. This
-			// ensures we preserve command splitting, instead of relying on envconsul's shell
-			// splitting to do the right thing.
-			Command: fmt.Sprintf("%s %s %s", theatreEnvconsulPath, base64Exec.FullCommand(), strings.Join(base64args, " ")),
-		},
-		Secret: []envconsulSecret{},
-	}
-
-	for key, value := range env {
-		path := path.Join(o.PathPrefix, value)
-		cfg.Secret = append(cfg.Secret, envconsulSecret{Format: key, Path: path})
-	}
-
-	return cfg
-}
-
-// EnvconsulConfig defines the subset of the configuration we use for envconsul:
-// https://github.com/hashicorp/envconsul/blob/master/config.go
-type EnvconsulConfig struct {
-	Vault  envconsulVault    `json:"vault"`
-	Exec   envconsulExec     `json:"exec"`
-	Secret []envconsulSecret `json:"secret"`
-}
-
-type envconsulVault struct {
-	Address string         `json:"address"`
-	Token   string         `json:"token"`
-	Retry   envconsulRetry `json:"retry"`
-	SSL     envconsulSSL   `json:"ssl"`
-}
-
-type envconsulRetry struct {
-	Enabled bool `json:"enabled"`
-}
-
-type envconsulSSL struct {
-	Enabled bool `json:"enabled"`
-	Verify  bool `json:"verify"`
-}
-
-type envconsulExec struct {
-	Command string `json:"command"`
-}
-
-type envconsulSecret struct {
-	Format string `json:"format"`
-	Path   string `json:"path"`
-}
@@ -25,7 +25,13 @@ var (
 	webhookName             = app.Flag("webhook-name", "Name of webhook").Default("theatre-vault").String()
 	theatreImage            = app.Flag("theatre-image", "Set to the same image as current binary").Required().String()
 	installPath             = app.Flag("install-path", "Location to install theatre binaries").Default("/var/run/theatre").String()
+	secretsPath             = app.Flag("secrets-path", "Location of resolved secrets, for pods whose secrets are resolved by init containers").Default("/var/run/theatre-secrets").String()
+	registryCredentialsFile = app.Flag("registry-credentials-file", "Docker config file with credentials for looking up the entrypoints of images, used when pods have no image pull secret for the registry").String()
+	readImagePullSecrets    = app.Flag("read-image-pull-secrets", "Look up the entrypoints of images with the credentials of pods' image pull secrets. Requires get on secrets in the namespaces of injected pods, which isn't granted by default").Bool()
+	imageCacheTTL           = app.Flag("image-cache-ttl", "How long the entrypoints of images are cached for").Default("10m").Duration()
+	imageLookupTimeout      = app.Flag("image-lookup-timeout", "Maximum time spent looking up the entrypoints of a pod's images, which must be less than the webhook's timeout").Default("5s").Duration()
 	namespaceLabel          = app.Flag("namespace-label", "Namespace label that enables webhook to operate on").Default("theatre-envconsul-injector").String()
+	rejectDisabledNamespace = app.Flag("reject-disabled-namespaces", "Reject annotated pods in namespaces without the namespace label, rather than skipping them. Only takes effect if the webhook's namespace selector lets such pods through, as in config/overlays/reject-disabled-namespaces").Bool()
 	vaultConfigMapName      = app.Flag("vault-configmap-name", "Vault configMap name containing vault configuration").Default("vault-config").String()
 	vaultConfigMapNamespace = app.Flag("vault-configmap-namespace", "Namespace of vault configMap").Default("vault-system").String()
 
@@ -61,9 +67,15 @@ func main() {
 	}
 
 	injectorOpts := vaultv1alpha1.EnvconsulInjectorOptions{
-		Image:          *theatreImage,
-		InstallPath:    *installPath,
-		NamespaceLabel: *namespaceLabel,
+		Image:                    *theatreImage,
+		InstallPath:              *installPath,
+		SecretsPath:              *secretsPath,
+		RegistryCredentialsFile:  *registryCredentialsFile,
+		ReadImagePullSecrets:     *readImagePullSecrets,
+		ImageCacheTTL:            *imageCacheTTL,
+		ImageLookupTimeout:       *imageLookupTimeout,
+		NamespaceLabel:           *namespaceLabel,
+		RejectDisabledNamespaces: *rejectDisabledNamespace,
 		VaultConfigMapKey: client.ObjectKey{
 			Namespace: *vaultConfigMapNamespace,
 			Name:      *vaultConfigMapName,
@@ -76,6 +88,7 @@ func main() {
 	mgr.GetWebhookServer().Register("/mutate-pods", &admission.Webhook{
 		Handler: vaultv1alpha1.NewEnvconsulInjector(
 			mgr.GetClient(),
+			mgr.GetAPIReader(),
 			logger.WithName("webhooks").WithName("envconsul-injector"),
 			injectorOpts,
 		),
@@ -6,16 +6,21 @@ import (
 
 	"github.com/alecthomas/kingpin"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp" // this is required to auth against GCP
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	rbacv1alpha1 "github.com/gocardless/theatre/v2/apis/rbac/v1alpha1"
 	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
 	"github.com/gocardless/theatre/v2/cmd"
 	consolecontroller "github.com/gocardless/theatre/v2/controllers/workloads/console"
+	consolelogscontroller "github.com/gocardless/theatre/v2/controllers/workloads/consolelogs"
+	consoleschedulecontroller "github.com/gocardless/theatre/v2/controllers/workloads/consoleschedule"
 	"github.com/gocardless/theatre/v2/pkg/signals"
+	"github.com/gocardless/theatre/v2/pkg/workloads/console/archive"
 )
 
 var (
@@ -24,6 +29,13 @@ var (
 	app = kingpin.New("workloads-manager", "Manages workloads.crd.gocardless.com resources").Version(cmd.VersionStanza())
 
 	commonOpts = cmd.NewCommonOptions(app).WithMetrics(app)
+
+	consoleArchiveLocation                  = app.Flag("console-archive-location", "Directory, or file:// URL, to archive consoles to before they are deleted. If not set, consoles aren't archived").String()
+	consoleLogArchiveLocation               = app.Flag("console-log-archive-location", "Directory, file:// URL or s3://<bucket>/<prefix> URL to archive the logs of consoles to once they stop. If not set, logs aren't archived").String()
+	consoleTemplatePolicy                   = app.Flag("console-template-policy", "Action for console templates that violate the pod security policy, unless configured per check by the policy configMap: allow, warn or reject").Default("warn").Enum("allow", "warn", "reject")
+	consoleTemplatePolicyConfigMapName      = app.Flag("console-template-policy-configmap-name", "Name of the configMap configuring the console template policy. If not set, the policy is configured by flags alone").String()
+	consoleTemplatePolicyConfigMapNamespace = app.Flag("console-template-policy-configmap-namespace", "Namespace of the console template policy configMap").Default("theatre-system").String()
+	managerUsername                         = app.Flag("manager-username", "User that the manager authenticates as, which webhooks trust to act on behalf of console schedules. Defaults to the service account given by the POD_NAMESPACE and POD_SERVICE_ACCOUNT environment variables").String()
 )
 
 func init() {
@@ -50,19 +62,80 @@ func main() {
 		app.Fatalf("failed to create manager: %v", err)
 	}
 
+	var consoleArchive archive.Sink
+	if *consoleArchiveLocation != "" {
+		if consoleArchive, err = archive.NewSink(*consoleArchiveLocation); err != nil {
+			app.Fatalf("failed to configure console archive: %v", err)
+		}
+	}
+
+	var consoleLogStore archive.LogStore
+	if *consoleLogArchiveLocation != "" {
+		if consoleLogStore, err = archive.NewLogStore(*consoleLogArchiveLocation); err != nil {
+			app.Fatalf("failed to configure console log archive: %v", err)
+		}
+	}
+
+	kubeClient, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		app.Fatalf("failed to create kubernetes client: %v", err)
+	}
+
+	if *managerUsername == "" {
+		namespace, serviceAccount := os.Getenv("POD_NAMESPACE"), os.Getenv("POD_SERVICE_ACCOUNT")
+		if namespace == "" || serviceAccount == "" {
+			app.Fatalf("--manager-username must be set when not running with POD_NAMESPACE and POD_SERVICE_ACCOUNT")
+		}
+
+		*managerUsername = fmt.Sprintf("system:serviceaccount:%s:%s", namespace, serviceAccount)
+	}
+
+	policyOpts := workloadsv1alpha1.ConsoleTemplatePolicyOptions{
+		DefaultAction: workloadsv1alpha1.PolicyAction(*consoleTemplatePolicy),
+	}
+	if *consoleTemplatePolicyConfigMapName != "" {
+		policyOpts.ConfigMapKey = &client.ObjectKey{
+			Namespace: *consoleTemplatePolicyConfigMapNamespace,
+			Name:      *consoleTemplatePolicyConfigMapName,
+		}
+	}
+
 	// controller
 	if err = (&consolecontroller.ConsoleReconciler{
+		Client:     mgr.GetClient(),
+		Log:        ctrl.Log.WithName("controllers").WithName("console"),
+		Scheme:     mgr.GetScheme(),
+		Archive:    consoleArchive,
+		KubeClient: kubeClient,
+	}).SetupWithManager(ctx, mgr); err != nil {
+		app.Fatalf("failed to create controller: %v", err)
+	}
+
+	if err = (&consoleschedulecontroller.ConsoleScheduleReconciler{
 		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("console"),
+		Log:    ctrl.Log.WithName("controllers").WithName("consoleschedule"),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(ctx, mgr); err != nil {
 		app.Fatalf("failed to create controller: %v", err)
 	}
 
+	if consoleLogStore != nil {
+		if err = (&consolelogscontroller.ConsoleLogsReconciler{
+			Client:     mgr.GetClient(),
+			Log:        ctrl.Log.WithName("controllers").WithName("consolelogs"),
+			Scheme:     mgr.GetScheme(),
+			Store:      consoleLogStore,
+			KubeClient: kubeClient,
+		}).SetupWithManager(ctx, mgr); err != nil {
+			app.Fatalf("failed to create controller: %v", err)
+		}
+	}
+
 	// console authenticator webhook
 	mgr.GetWebhookServer().Register("/mutate-consoles", &admission.Webhook{
 		Handler: workloadsv1alpha1.NewConsoleAuthenticatorWebhook(
 			logger.WithName("webhooks").WithName("console-authenticator"),
+			*managerUsername,
 		),
 	})
 
@@ -71,13 +144,31 @@ func main() {
 		Handler: workloadsv1alpha1.NewConsoleAuthorisationWebhook(
 			mgr.GetClient(),
 			logger.WithName("webhooks").WithName("console-authorisation"),
+			*managerUsername,
+		),
+	})
+
+	// console validation webhook
+	mgr.GetWebhookServer().Register("/validate-consoles", &admission.Webhook{
+		Handler: workloadsv1alpha1.NewConsoleValidationWebhook(
+			mgr.GetClient(),
+			logger.WithName("webhooks").WithName("console-validation"),
 		),
 	})
 
 	// console template webhook
 	mgr.GetWebhookServer().Register("/validate-consoletemplates", &admission.Webhook{
 		Handler: workloadsv1alpha1.NewConsoleTemplateValidationWebhook(
+			mgr.GetAPIReader(),
 			logger.WithName("webhooks").WithName("console-template"),
+			policyOpts,
+		),
+	})
+
+	// console schedule webhook
+	mgr.GetWebhookServer().Register("/validate-consoleschedules", &admission.Webhook{
+		Handler: workloadsv1alpha1.NewConsoleScheduleValidationWebhook(
+			logger.WithName("webhooks").WithName("console-schedule"),
 		),
 	})
 
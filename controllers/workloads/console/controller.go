@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -13,11 +14,15 @@ import (
 	"github.com/pkg/errors"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -32,6 +37,7 @@ import (
 	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
 	"github.com/gocardless/theatre/v2/pkg/logging"
 	"github.com/gocardless/theatre/v2/pkg/recutil"
+	"github.com/gocardless/theatre/v2/pkg/workloads/console/archive"
 )
 
 const (
@@ -52,9 +58,12 @@ const (
 
 	ConsolePendingAuthorisation = "ConsolePendingAuthorisation"
 	ConsoleAuthorised           = "ConsoleAuthorised"
+	ConsoleScheduled            = "ConsoleScheduled"
+	ConsoleQueued               = "ConsoleQueued"
 	ConsoleStarted              = "ConsoleStarted"
 	ConsoleEnded                = "ConsoleEnded"
 	ConsoleDestroyed            = "ConsoleDestroyed"
+	ConsoleArchived             = "ConsoleArchived"
 
 	Job                  = "job"
 	Console              = "console"
@@ -62,9 +71,21 @@ const (
 	ConsoleTemplate      = "consoletemplate"
 	Role                 = "role"
 	DirectoryRoleBinding = "directoryrolebinding"
+	NetworkPolicy        = "networkpolicy"
 
 	DefaultTTLBeforeRunning = 1 * time.Hour
 	DefaultTTLAfterFinished = 24 * time.Hour
+
+	// How often queued consoles are reconciled to check whether there is now
+	// capacity for them to start
+	QueuedRequeueInterval = 10 * time.Second
+
+	// Finalizer that prevents consoles from being deleted until they have been
+	// archived
+	ConsoleArchiveFinalizer = "workloads.crd.gocardless.com/console-archive"
+
+	// Maximum size of the logs archived for each container of a console
+	ArchivedLogLimitBytes = 10 * 1024 * 1024
 )
 
 type IgnoreCreatePredicate struct {
@@ -75,10 +96,29 @@ func (IgnoreCreatePredicate) Create(e event.CreateEvent) bool {
 	return false
 }
 
+// IgnoreUnauthorisedCreatePredicate ignores the creation of authorisations that
+// don't yet contain any authorisations, which is how the controller creates
+// them, but not those created by a console schedule with its standing
+// authorisation.
+type IgnoreUnauthorisedCreatePredicate struct {
+	predicate.Funcs
+}
+
+func (IgnoreUnauthorisedCreatePredicate) Create(e event.CreateEvent) bool {
+	auth, ok := e.Object.(*workloadsv1alpha1.ConsoleAuthorisation)
+	return ok && len(auth.Spec.Authorisations) > 0
+}
+
 type ConsoleReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+
+	// Sink to archive consoles to before they are deleted. If not set, consoles
+	// aren't archived.
+	Archive archive.Sink
+	// Used to retrieve the logs of console pods when archiving them
+	KubeClient kubernetes.Interface
 }
 
 func (r *ConsoleReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
@@ -97,7 +137,7 @@ func (r *ConsoleReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manag
 			},
 			// Don't unnecessarily reconcile when the controller initially creates the
 			// authorisation object.
-			builder.WithPredicates(IgnoreCreatePredicate{}),
+			builder.WithPredicates(IgnoreUnauthorisedCreatePredicate{}),
 		).
 		Watches(
 			&source.Kind{Type: &batchv1.Job{}},
@@ -106,14 +146,31 @@ func (r *ConsoleReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manag
 				OwnerType:    &workloadsv1alpha1.Console{},
 			},
 		).
-		Complete(
-			recutil.ResolveAndReconcile(
-				ctx, logger, mgr, &workloadsv1alpha1.Console{},
-				func(logger logr.Logger, request reconcile.Request, obj runtime.Object) (reconcile.Result, error) {
-					return r.Reconcile(logger, ctx, request, obj.(*workloadsv1alpha1.Console))
-				},
-			),
-		)
+		Watches(
+			&source.Kind{Type: &networkingv1.NetworkPolicy{}},
+			&handler.EnqueueRequestForOwner{
+				IsController: true,
+				OwnerType:    &workloadsv1alpha1.Console{},
+			},
+		).
+		Complete(r.reconciler(ctx, logger, mgr))
+}
+
+func (r *ConsoleReconciler) reconciler(ctx context.Context, logger logr.Logger, mgr ctrl.Manager) reconcile.Reconciler {
+	inner := func(logger logr.Logger, request reconcile.Request, obj runtime.Object) (reconcile.Result, error) {
+		return r.Reconcile(logger, ctx, request, obj.(*workloadsv1alpha1.Console))
+	}
+
+	if r.Archive == nil {
+		return recutil.ResolveAndReconcile(ctx, logger, mgr, &workloadsv1alpha1.Console{}, inner)
+	}
+
+	return recutil.ResolveAndReconcileWithFinalizer(
+		ctx, logger, mgr, &workloadsv1alpha1.Console{}, ConsoleArchiveFinalizer, inner,
+		func(logger logr.Logger, request reconcile.Request, obj runtime.Object) error {
+			return r.Finalize(logger, ctx, request, obj.(*workloadsv1alpha1.Console))
+		},
+	)
 }
 
 func (r *ConsoleReconciler) Reconcile(logger logr.Logger, ctx context.Context, req ctrl.Request, csl *workloadsv1alpha1.Console) (ctrl.Result, error) {
@@ -165,13 +222,28 @@ func (r *ConsoleReconciler) Reconcile(logger logr.Logger, ctx context.Context, r
 		authorisation *workloadsv1alpha1.ConsoleAuthorisation
 	)
 
-	if tpl.HasAuthorisationRules() {
-		rule, err := tpl.GetAuthorisationRuleForCommand(command)
+	authRule, err = tpl.GetAuthorisationRule(command, csl.Spec.Resources)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to determine authorisation rule for console")
+	}
+
+	job, err := r.getJob(ctx, req.NamespacedName)
+	if err != nil {
+		job = nil
+	}
+
+	switch {
+	case authRule != nil && csl.GetConsoleSchedule() != nil:
+		// Consoles created by a schedule are authorised by the schedule's
+		// standing authorisation, which the schedule controller copies into an
+		// authorisation object for the console once it has created it. Until then
+		// (or forever, if the console wasn't really created by the schedule) the
+		// console remains pending authorisation.
+		authorisation, err = r.getScheduledConsoleAuthorisation(ctx, logger, csl, job != nil)
 		if err != nil {
-			return ctrl.Result{}, errors.Wrap(err, "failed to determine authorisation rule for console command")
+			return ctrl.Result{}, errors.Wrap(err, "failed to retrieve console authorisation")
 		}
-
-		authRule = &rule
+	case authRule != nil:
 		if err := r.createAuthorisationObjects(ctx, logger, csl, req.NamespacedName, authRule.Subjects); err != nil {
 			return ctrl.Result{}, err
 		}
@@ -182,17 +254,38 @@ func (r *ConsoleReconciler) Reconcile(logger logr.Logger, ctx context.Context, r
 		}
 	}
 
-	job, err := r.getJob(ctx, req.NamespacedName)
-	if err != nil {
-		job = nil
-	}
-
 	// Only create/update a job when the console is authorised and pending job
 	// creation or when a job already exists, i.e. if we've already passed the
 	// Creating phase, but the job no longer exists (it's been destroyed external
 	// to this controller) then don't recreate it.
 	authorised := isConsoleAuthorised(authRule, authorisation)
-	if (authorised && csl.PendingJob()) || job != nil {
+
+	// Consoles can be authorised ahead of their scheduled start, but we won't
+	// create a job for them until then.
+	scheduled := job == nil && time.Now().Before(csl.ScheduledStartTime())
+
+	// Hold back consoles that would exceed the template's concurrency limits,
+	// if the template queues them rather than having them rejected on creation.
+	var queuePosition int
+	if authorised && csl.PendingJob() && job == nil && !scheduled {
+		queuePosition, err = r.getQueuePosition(ctx, csl, tpl)
+		if err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to determine console queue position")
+		}
+	}
+
+	if (authorised && csl.PendingJob() && !scheduled && queuePosition == 0) || job != nil {
+		// Restrict the network access of the console before its pod can start,
+		// or remove the restriction if the template no longer declares one
+		if tpl.Spec.NetworkPolicy != nil {
+			policy := buildNetworkPolicy(req.NamespacedName, csl, tpl.Spec.NetworkPolicy)
+			if err := r.createOrUpdate(ctx, logger, csl, policy, NetworkPolicy, recutil.NetworkPolicyDiff); err != nil {
+				return ctrl.Result{}, err
+			}
+		} else if err := r.deleteNetworkPolicy(ctx, logger, req.NamespacedName, csl); err != nil {
+			return ctrl.Result{}, err
+		}
+
 		job = r.buildJob(logger, req.NamespacedName, csl, tpl)
 		if err := r.createOrUpdate(ctx, logger, csl, job, Job, jobDiff); err != nil {
 			return ctrl.Result{}, err
@@ -206,6 +299,8 @@ func (r *ConsoleReconciler) Reconcile(logger logr.Logger, ctx context.Context, r
 		IsAuthorised:      authorised,
 		Authorisation:     authorisation,
 		AuthorisationRule: authRule,
+		IsScheduled:       scheduled,
+		QueuePosition:     queuePosition,
 		Job:               job,
 	}
 
@@ -224,6 +319,17 @@ func (r *ConsoleReconciler) Reconcile(logger logr.Logger, ctx context.Context, r
 		// Requeue for when the console has reached its before-running TTL, so that
 		// it can be deleted if it has not yet been authorised by that point.
 		res = requeueAfterInterval(logger, time.Until(*csl.GetGCTime()))
+	case csl.Scheduled():
+		// Requeue for when the console is due to start
+		res = requeueAfterInterval(logger, time.Until(csl.ScheduledStartTime()))
+	case csl.Queued():
+		// We don't watch for other consoles finishing, so poll for capacity to
+		// start the console, unless it will reach its before-running TTL first.
+		interval := QueuedRequeueInterval
+		if untilGC := time.Until(*csl.GetGCTime()); untilGC < interval {
+			interval = untilGC
+		}
+		res = requeueAfterInterval(logger, interval)
 	case csl.Pending():
 		// Requeue every second while job has been created but there is not yet a
 		// running pod: we won't receive an event via the job watcher when this
@@ -234,7 +340,7 @@ func (r *ConsoleReconciler) Reconcile(logger logr.Logger, ctx context.Context, r
 		// Create or update the role
 		// Role grants permissions for a specific resource name, we need to
 		// wait until the Pod is running to know the resource name
-		role := buildRole(req.NamespacedName, csl.Status.PodName)
+		role := buildRole(req.NamespacedName, csl.Status.PodName, tpl)
 		if err := r.createOrUpdate(ctx, logger, csl, role, Role, recutil.RoleDiff); err != nil {
 			return res, err
 		}
@@ -266,6 +372,76 @@ func (r *ConsoleReconciler) Reconcile(logger logr.Logger, ctx context.Context, r
 	return res, err
 }
 
+// Finalize archives the final state of a console that is being deleted, along
+// with its authorisation, job, pod and container logs. Consoles are deleted with
+// background propagation, so these still exist at this point.
+func (r *ConsoleReconciler) Finalize(logger logr.Logger, ctx context.Context, req ctrl.Request, csl *workloadsv1alpha1.Console) error {
+	logger = logger.WithValues("console", req.NamespacedName)
+
+	record := &archive.Record{
+		ArchivedAt: time.Now(),
+		Console:    csl,
+	}
+
+	authorisation, err := r.getConsoleAuthorisation(ctx, req.NamespacedName)
+	if err == nil {
+		record.Authorisation = authorisation
+	} else if !apierrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to retrieve console authorisation")
+	}
+
+	job, err := r.getJob(ctx, req.NamespacedName)
+	if err == nil {
+		record.Job = job
+	} else if !apierrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to retrieve console job")
+	}
+
+	if csl.Status.PodName != "" {
+		pod := &corev1.Pod{}
+		err := r.Get(ctx, types.NamespacedName{Name: csl.Status.PodName, Namespace: req.Namespace}, pod)
+		if err == nil {
+			record.Pod = pod
+			record.Logs = r.getPodLogs(ctx, logger, pod)
+		} else if !apierrors.IsNotFound(err) {
+			return errors.Wrap(err, "failed to retrieve console pod")
+		}
+	}
+
+	if err := r.Archive.Write(ctx, record); err != nil {
+		return errors.Wrap(err, "failed to archive console")
+	}
+
+	logger.Info("Console archived", "event", ConsoleArchived)
+	return nil
+}
+
+// getPodLogs retrieves the logs of each container in the pod. Logs can't be
+// retrieved for containers that never started, or if the node has gone away,
+// in which case we archive what we can rather than blocking deletion.
+func (r *ConsoleReconciler) getPodLogs(ctx context.Context, logger logr.Logger, pod *corev1.Pod) map[string]string {
+	limitBytes := int64(ArchivedLogLimitBytes)
+	logs := map[string]string{}
+
+	for _, container := range pod.Spec.Containers {
+		opts := &corev1.PodLogOptions{Container: container.Name, LimitBytes: &limitBytes}
+		data, err := r.KubeClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts).DoRaw(ctx)
+		if err != nil {
+			logging.WithNoRecord(logger).Info(
+				"Failed to retrieve container logs for archive",
+				"event", ConsoleArchived,
+				"container", container.Name,
+				"error", err,
+			)
+			continue
+		}
+
+		logs[container.Name] = string(data)
+	}
+
+	return logs
+}
+
 func (r *ConsoleReconciler) getConsoleTemplate(ctx context.Context, csl *workloadsv1alpha1.Console, name types.NamespacedName) (*workloadsv1alpha1.ConsoleTemplate, error) {
 	tplName := types.NamespacedName{
 		Name:      csl.Spec.ConsoleTemplateRef.Name,
@@ -276,6 +452,112 @@ func (r *ConsoleReconciler) getConsoleTemplate(ctx context.Context, csl *workloa
 	return tpl, r.Get(ctx, tplName, tpl)
 }
 
+// getQueuePosition returns the position of the console in its template's queue,
+// or 0 if it can start without exceeding the template's concurrency limits.
+func (r *ConsoleReconciler) getQueuePosition(ctx context.Context, csl *workloadsv1alpha1.Console, template *workloadsv1alpha1.ConsoleTemplate) (int, error) {
+	if !template.Spec.QueueWhenAtCapacity {
+		return 0, nil
+	}
+	if template.Spec.MaxConcurrent == 0 && template.Spec.MaxConcurrentPerUser == 0 {
+		return 0, nil
+	}
+
+	var consoles workloadsv1alpha1.ConsoleList
+	if err := r.List(ctx, &consoles, client.InNamespace(csl.Namespace)); err != nil {
+		return 0, errors.Wrap(err, "failed to list consoles")
+	}
+
+	// The status of a console can lag behind the creation of its job, so we also
+	// count consoles by whether they have an unfinished job
+	var jobs batchv1.JobList
+	if err := r.List(ctx, &jobs, client.InNamespace(csl.Namespace)); err != nil {
+		return 0, errors.Wrap(err, "failed to list jobs")
+	}
+
+	started := map[string]bool{}
+	for _, job := range jobs.Items {
+		owner := metav1.GetControllerOf(&job)
+		if owner == nil || owner.APIVersion != workloadsv1alpha1.GroupVersion.String() || owner.Kind != "Console" {
+			continue
+		}
+
+		if !jobFinished(&job) {
+			started[owner.Name] = true
+		}
+	}
+
+	return calculateQueuePosition(csl, template, consoles.Items, started), nil
+}
+
+// calculateQueuePosition admits queued consoles in order of creation. Consoles use
+// capacity if they have an unfinished job, are Pending or Running, or are still
+// being created, as they may be about to start without passing through the queue.
+// A console that is held back by the per-user limit doesn't hold back the consoles
+// of other users that are behind it in the queue.
+func calculateQueuePosition(csl *workloadsv1alpha1.Console, template *workloadsv1alpha1.ConsoleTemplate, consoles []workloadsv1alpha1.Console, started map[string]bool) int {
+	var (
+		active        int
+		activeForUser = map[string]int{}
+		queue         = []workloadsv1alpha1.Console{*csl}
+	)
+
+	for _, other := range consoles {
+		if other.Spec.ConsoleTemplateRef.Name != template.Name || other.Name == csl.Name || other.DeletionTimestamp != nil {
+			continue
+		}
+
+		switch {
+		case started[other.Name] || other.Pending() || other.Running() || other.Creating():
+			active++
+			activeForUser[other.Spec.User]++
+		case other.Queued():
+			queue = append(queue, other)
+		}
+	}
+
+	sort.SliceStable(queue, func(i, j int) bool {
+		a, b := queue[i].CreationTimestamp, queue[j].CreationTimestamp
+		if !a.Equal(&b) {
+			return a.Before(&b)
+		}
+		return queue[i].Name < queue[j].Name
+	})
+
+	var position int
+	for _, queued := range queue {
+		atCapacity := (template.Spec.MaxConcurrent > 0 && active >= template.Spec.MaxConcurrent) ||
+			(template.Spec.MaxConcurrentPerUser > 0 && activeForUser[queued.Spec.User] >= template.Spec.MaxConcurrentPerUser)
+
+		if atCapacity {
+			position++
+		} else {
+			active++
+			activeForUser[queued.Spec.User]++
+		}
+
+		if queued.Name == csl.Name {
+			if atCapacity {
+				return position
+			}
+			return 0
+		}
+	}
+
+	return 0
+}
+
+// jobFinished returns true if the job has completed or failed, which are the only
+// conditions a job can have
+func jobFinished(job *batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (r *ConsoleReconciler) getCommand(csl *workloadsv1alpha1.Console, template *workloadsv1alpha1.ConsoleTemplate) ([]string, error) {
 	if len(csl.Spec.Command) > 0 {
 		return csl.Spec.Command, nil
@@ -288,6 +570,58 @@ func (r *ConsoleReconciler) getConsoleAuthorisation(ctx context.Context, name ty
 	return auth, r.Get(ctx, name, auth)
 }
 
+// getScheduledConsoleAuthorisation returns the authorisation of a console that
+// claims to have been created by a console schedule, or nil if it has none that
+// we can trust. Anybody can create a console with a schedule as its owner, so we
+// only accept an authorisation that the schedule controller created for the
+// console, from the standing authorisation of the schedule that really owns it.
+// The standing authorisation is only checked before the console starts, so that
+// altering the schedule doesn't affect consoles that are already running.
+func (r *ConsoleReconciler) getScheduledConsoleAuthorisation(ctx context.Context, logger logr.Logger, csl *workloadsv1alpha1.Console, started bool) (*workloadsv1alpha1.ConsoleAuthorisation, error) {
+	ref := csl.GetConsoleSchedule()
+
+	schedule := &workloadsv1alpha1.ConsoleSchedule{}
+	err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: csl.Namespace}, schedule)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve console schedule")
+	}
+
+	if schedule.UID != ref.UID {
+		logging.WithNoRecord(logger).Info(
+			"Console schedule does not match the console's owner reference",
+			"event", ConsolePendingAuthorisation,
+			"console_schedule", ref.Name,
+		)
+		return nil, nil
+	}
+
+	if !started && !schedule.Status.Authorised {
+		return nil, nil
+	}
+
+	authorisation, err := r.getConsoleAuthorisation(ctx, types.NamespacedName{Name: csl.Name, Namespace: csl.Namespace})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !metav1.IsControlledBy(authorisation, csl) || authorisation.Labels[workloadsv1alpha1.ScheduledAuthorisationLabel] != string(schedule.UID) {
+		logging.WithNoRecord(logger).Info(
+			"Ignoring console authorisation that was not created by the console schedule",
+			"event", ConsolePendingAuthorisation,
+			"console_schedule", ref.Name,
+		)
+		return nil, nil
+	}
+
+	return authorisation, nil
+}
+
 func (r *ConsoleReconciler) getJob(ctx context.Context, name types.NamespacedName) (*batchv1.Job, error) {
 	jobName := types.NamespacedName{
 		Name:      getJobName(name.Name),
@@ -374,6 +708,29 @@ func (r *ConsoleReconciler) createOrUpdate(ctx context.Context, logger logr.Logg
 	return nil
 }
 
+// deleteNetworkPolicy removes the NetworkPolicy of a console, if it has one
+func (r *ConsoleReconciler) deleteNetworkPolicy(ctx context.Context, logger logr.Logger, name types.NamespacedName, csl *workloadsv1alpha1.Console) error {
+	policy := &networkingv1.NetworkPolicy{}
+	if err := r.Get(ctx, name, policy); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrap(err, "failed to retrieve console network policy")
+	}
+
+	// Don't touch policies that happen to share the console's name
+	if !metav1.IsControlledBy(policy, csl) {
+		return nil
+	}
+
+	logger.Info("Deleting network policy no longer declared by template", "event", EventDelete, "kind", NetworkPolicy)
+	if err := r.Delete(ctx, policy); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to delete console network policy")
+	}
+
+	return nil
+}
+
 // Ensure the console timeout is between [0, template.MaxTimeoutSeconds]
 func (r *ConsoleReconciler) setConsoleTimeout(logger logr.Logger, console *workloadsv1alpha1.Console, template *workloadsv1alpha1.ConsoleTemplate) *workloadsv1alpha1.Console {
 	var timeout int
@@ -423,6 +780,8 @@ type consoleStatusContext struct {
 	IsAuthorised      bool
 	Authorisation     *workloadsv1alpha1.ConsoleAuthorisation
 	AuthorisationRule *workloadsv1alpha1.ConsoleAuthorisationRule
+	IsScheduled       bool
+	QueuePosition     int
 	Pod               *corev1.Pod
 	Job               *batchv1.Job
 }
@@ -460,6 +819,16 @@ func (r *ConsoleReconciler) generateStatusAndAuditEvents(ctx context.Context, lo
 		logger.Info("Console authorised", "event", ConsoleAuthorised)
 	}
 
+	// Console phase to Scheduled, either on creation or once authorised
+	if !csl.Scheduled() && newStatus.Phase == workloadsv1alpha1.ConsoleScheduled {
+		logger.Info("Console scheduled", "event", ConsoleScheduled, "start_after", csl.Spec.StartAfter)
+	}
+
+	// Console phase to Queued, either on creation or once authorised
+	if !csl.Queued() && newStatus.Phase == workloadsv1alpha1.ConsoleQueued {
+		logger.Info("Console queued", "event", ConsoleQueued, "queue_position", newStatus.QueuePosition)
+	}
+
 	// Console phase from Pending to Running
 	if csl.Pending() && newStatus.Phase == workloadsv1alpha1.ConsoleRunning {
 		logger.Info("Console started", "event", ConsoleStarted)
@@ -496,6 +865,11 @@ func (r *ConsoleReconciler) generateStatusAndAuditEvents(ctx context.Context, lo
 		logger.Info("Console expired due to lack of authorisation", "event", ConsoleEnded)
 	}
 
+	// Console was in Queued phase, but is about to be deleted.
+	if csl.Queued() && csl.EligibleForGC() {
+		logger.Info("Console expired while queued", "event", ConsoleEnded)
+	}
+
 	// Console phase has changed to destroyed (i.e. the job has been removed)
 	if !csl.Destroyed() && newStatus.Phase == workloadsv1alpha1.ConsoleDestroyed {
 		logger.Info("Console destroyed", "event", ConsoleDestroyed)
@@ -528,6 +902,7 @@ func calculateStatus(csl *workloadsv1alpha1.Console, statusCtx consoleStatusCont
 		newStatus.PodName = statusCtx.Pod.ObjectMeta.Name
 	}
 
+	newStatus.QueuePosition = statusCtx.QueuePosition
 	newStatus.Phase = calculatePhase(statusCtx)
 
 	return newStatus
@@ -538,6 +913,14 @@ func calculatePhase(statusCtx consoleStatusContext) workloadsv1alpha1.ConsolePha
 		return workloadsv1alpha1.ConsolePendingAuthorisation
 	}
 
+	if statusCtx.Job == nil && statusCtx.IsScheduled {
+		return workloadsv1alpha1.ConsoleScheduled
+	}
+
+	if statusCtx.Job == nil && statusCtx.QueuePosition > 0 {
+		return workloadsv1alpha1.ConsoleQueued
+	}
+
 	if statusCtx.Job == nil {
 		return workloadsv1alpha1.ConsoleDestroyed
 	}
@@ -568,6 +951,24 @@ func requeueAfterInterval(logger logr.Logger, interval time.Duration) reconcile.
 	return reconcile.Result{Requeue: true, RequeueAfter: interval}
 }
 
+// overrideResource sets both the request and limit of a resource, if a quantity is
+// provided
+func overrideResource(requirements *corev1.ResourceRequirements, name corev1.ResourceName, quantity *resource.Quantity) {
+	if quantity == nil {
+		return
+	}
+
+	if requirements.Requests == nil {
+		requirements.Requests = corev1.ResourceList{}
+	}
+	if requirements.Limits == nil {
+		requirements.Limits = corev1.ResourceList{}
+	}
+
+	requirements.Requests[name] = *quantity
+	requirements.Limits[name] = *quantity
+}
+
 func (r *ConsoleReconciler) buildJob(logger logr.Logger, name types.NamespacedName, csl *workloadsv1alpha1.Console, template *workloadsv1alpha1.ConsoleTemplate) *batchv1.Job {
 	timeout := int64(csl.Spec.TimeoutSeconds)
 
@@ -593,6 +994,13 @@ func (r *ConsoleReconciler) buildJob(logger logr.Logger, name types.NamespacedNa
 			container.Stdin = true
 			container.TTY = true
 		}
+
+		// The bounds of any overrides are enforced by the validation webhook when
+		// the console is created
+		if resources := csl.Spec.Resources; resources != nil {
+			overrideResource(&container.Resources, corev1.ResourceCPU, resources.CPU)
+			overrideResource(&container.Resources, corev1.ResourceMemory, resources.Memory)
+		}
 	}
 
 	if numContainers > 1 {
@@ -652,8 +1060,8 @@ func (r *ConsoleReconciler) buildJob(logger logr.Logger, name types.NamespacedNa
 	}
 }
 
-func buildRole(name types.NamespacedName, podName string) *rbacv1.Role {
-	return &rbacv1.Role{
+func buildRole(name types.NamespacedName, podName string, template *workloadsv1alpha1.ConsoleTemplate) *rbacv1.Role {
+	role := &rbacv1.Role{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name.Name,
 			Namespace: name.Namespace,
@@ -679,6 +1087,52 @@ func buildRole(name types.NamespacedName, podName string) *rbacv1.Role {
 			},
 		},
 	}
+
+	if template.Spec.AllowPortForward {
+		role.Rules = append(role.Rules, rbacv1.PolicyRule{
+			Verbs:         []string{"create"},
+			APIGroups:     []string{""},
+			Resources:     []string{"pods/portforward"},
+			ResourceNames: []string{podName},
+		})
+	}
+
+	return role
+}
+
+// buildNetworkPolicy isolates the pod of a console, selected by the console-name
+// label set on it in buildJob, denying all egress other than that declared by
+// the template. NetworkPolicies are additive, so this can't prevent egress that
+// other policies selecting the pod allow.
+func buildNetworkPolicy(name types.NamespacedName, csl *workloadsv1alpha1.Console, policy *workloadsv1alpha1.ConsoleNetworkPolicy) *networkingv1.NetworkPolicy {
+	// Default the protocol of ports as the API server would, so that the policy
+	// doesn't differ from what's stored and get updated on every reconcile
+	var egress []networkingv1.NetworkPolicyEgressRule
+	for _, rule := range policy.Egress {
+		rule := *rule.DeepCopy()
+		for idx := range rule.Ports {
+			if rule.Ports[idx].Protocol == nil {
+				tcp := corev1.ProtocolTCP
+				rule.Ports[idx].Protocol = &tcp
+			}
+		}
+		egress = append(egress, rule)
+	}
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+			Labels:    csl.Labels,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"console-name": sanitiseLabel(csl.Name)},
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+			Egress:      egress,
+		},
+	}
 }
 
 func buildDirectoryRoleBinding(name types.NamespacedName, role *rbacv1.Role, subjects []rbacv1.Subject) *rbacv1alpha1.DirectoryRoleBinding {
@@ -873,6 +1327,15 @@ func getAuditLogger(logger logr.Logger, c *workloadsv1alpha1.Console, statusCtx
 		loggerCtx = loggerCtx.WithValues("console_pod_name", statusCtx.Pod.Name)
 	}
 
+	if resources := c.Spec.Resources; resources != nil {
+		if resources.CPU != nil {
+			loggerCtx = loggerCtx.WithValues("console_cpu", resources.CPU.String())
+		}
+		if resources.Memory != nil {
+			loggerCtx = loggerCtx.WithValues("console_memory", resources.Memory.String())
+		}
+	}
+
 	if statusCtx.AuthorisationRule != nil {
 		loggerCtx = loggerCtx.WithValues(
 			"console_authorisation_rule_name", statusCtx.AuthorisationRule.Name,
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+)
+
+var _ = Describe("calculateQueuePosition", func() {
+	var (
+		template *workloadsv1alpha1.ConsoleTemplate
+		consoles []workloadsv1alpha1.Console
+		started  map[string]bool
+		now      = time.Now()
+	)
+
+	newConsole := func(name, user string, phase workloadsv1alpha1.ConsolePhase, age time.Duration) workloadsv1alpha1.Console {
+		return workloadsv1alpha1.Console{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "staging",
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			},
+			Spec: workloadsv1alpha1.ConsoleSpec{
+				ConsoleTemplateRef: corev1.LocalObjectReference{Name: template.Name},
+				User:               user,
+			},
+			Status: workloadsv1alpha1.ConsoleStatus{Phase: phase},
+		}
+	}
+
+	positionOf := func(name string) int {
+		for idx := range consoles {
+			if consoles[idx].Name == name {
+				return calculateQueuePosition(&consoles[idx], template, consoles, started)
+			}
+		}
+
+		Fail("no console named " + name)
+		return -1
+	}
+
+	BeforeEach(func() {
+		template = &workloadsv1alpha1.ConsoleTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "template", Namespace: "staging"},
+			Spec:       workloadsv1alpha1.ConsoleTemplateSpec{MaxConcurrent: 1},
+		}
+		started = map[string]bool{}
+	})
+
+	Context("With no active consoles", func() {
+		BeforeEach(func() {
+			consoles = []workloadsv1alpha1.Console{
+				newConsole("first", "alice", workloadsv1alpha1.ConsoleQueued, 2*time.Minute),
+				newConsole("second", "bob", workloadsv1alpha1.ConsoleQueued, time.Minute),
+			}
+		})
+
+		It("Admits the oldest queued console", func() {
+			Expect(positionOf("first")).To(Equal(0))
+		})
+
+		It("Queues the others behind it", func() {
+			Expect(positionOf("second")).To(Equal(1))
+		})
+	})
+
+	Context("With a running console", func() {
+		BeforeEach(func() {
+			consoles = []workloadsv1alpha1.Console{
+				newConsole("running", "alice", workloadsv1alpha1.ConsoleRunning, time.Hour),
+				newConsole("queued", "bob", workloadsv1alpha1.ConsoleQueued, time.Minute),
+			}
+		})
+
+		It("Queues", func() {
+			Expect(positionOf("queued")).To(Equal(1))
+		})
+	})
+
+	Context("With a console that is still being created", func() {
+		BeforeEach(func() {
+			consoles = []workloadsv1alpha1.Console{
+				newConsole("creating", "alice", "", time.Hour),
+				newConsole("queued", "bob", workloadsv1alpha1.ConsoleQueued, time.Minute),
+			}
+		})
+
+		It("Counts it against the limit", func() {
+			Expect(positionOf("queued")).To(Equal(1))
+		})
+	})
+
+	Context("With a console whose job has started but whose status is still queued", func() {
+		BeforeEach(func() {
+			consoles = []workloadsv1alpha1.Console{
+				newConsole("admitted", "alice", workloadsv1alpha1.ConsoleQueued, time.Hour),
+				newConsole("queued", "bob", workloadsv1alpha1.ConsoleQueued, time.Minute),
+			}
+			started["admitted"] = true
+		})
+
+		It("Counts it against the limit", func() {
+			Expect(positionOf("queued")).To(Equal(1))
+		})
+	})
+
+	Context("With consoles that are stopped or awaiting authorisation", func() {
+		BeforeEach(func() {
+			consoles = []workloadsv1alpha1.Console{
+				newConsole("stopped", "alice", workloadsv1alpha1.ConsoleStopped, time.Hour),
+				newConsole("unauthorised", "alice", workloadsv1alpha1.ConsolePendingAuthorisation, time.Hour),
+				newConsole("queued", "bob", workloadsv1alpha1.ConsoleQueued, time.Minute),
+			}
+		})
+
+		It("Doesn't count them against the limit", func() {
+			Expect(positionOf("queued")).To(Equal(0))
+		})
+	})
+
+	Context("With a limit per user", func() {
+		BeforeEach(func() {
+			template.Spec.MaxConcurrent = 0
+			template.Spec.MaxConcurrentPerUser = 1
+			consoles = []workloadsv1alpha1.Console{
+				newConsole("running", "alice", workloadsv1alpha1.ConsoleRunning, time.Hour),
+				newConsole("alice-queued", "alice", workloadsv1alpha1.ConsoleQueued, 2*time.Minute),
+				newConsole("bob-queued", "bob", workloadsv1alpha1.ConsoleQueued, time.Minute),
+			}
+		})
+
+		It("Queues consoles of the user at the limit", func() {
+			Expect(positionOf("alice-queued")).To(Equal(1))
+		})
+
+		It("Doesn't hold back other users", func() {
+			Expect(positionOf("bob-queued")).To(Equal(0))
+		})
+	})
+})
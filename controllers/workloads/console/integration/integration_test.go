@@ -2,7 +2,10 @@ package integration
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,14 +13,19 @@ import (
 	. "github.com/onsi/gomega"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	rbacv1alpha1 "github.com/gocardless/theatre/v2/apis/rbac/v1alpha1"
 	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	consolecontroller "github.com/gocardless/theatre/v2/controllers/workloads/console"
+	"github.com/gocardless/theatre/v2/pkg/workloads/console/archive"
 )
 
 var _ = Describe("Console", func() {
@@ -250,6 +258,40 @@ var _ = Describe("Console", func() {
 			)
 		})
 
+		It("Archives the console before it is deleted", func() {
+			identifier, _ := client.ObjectKeyFromObject(csl)
+
+			By("Expect the console has the archive finalizer")
+			Eventually(func() []string {
+				mgr.GetClient().Get(context.TODO(), identifier, csl)
+				return csl.Finalizers
+			}).Should(ContainElement(consolecontroller.ConsoleArchiveFinalizer))
+
+			Eventually(func() error {
+				jobIdentifier := identifier
+				jobIdentifier.Name += "-console"
+				return mgr.GetClient().Get(context.TODO(), jobIdentifier, &batchv1.Job{})
+			}).ShouldNot(HaveOccurred(), "failed to find associated Job for Console")
+
+			By("Deleting the console")
+			Expect(mgr.GetClient().Delete(context.TODO(), csl)).To(Succeed())
+
+			By("Expect the console is archived and then deleted")
+			Eventually(func() bool {
+				err := mgr.GetClient().Get(context.TODO(), identifier, csl)
+				return apierrors.IsNotFound(err)
+			}).Should(BeTrue(), "console should have been deleted")
+
+			archived := filepath.Join(archiveDir, namespaceName, fmt.Sprintf("%s-%s.json", csl.Name, csl.UID))
+			data, err := ioutil.ReadFile(archived)
+			Expect(err).NotTo(HaveOccurred(), "console should have been archived")
+
+			record := &archive.Record{}
+			Expect(json.Unmarshal(data, record)).To(Succeed())
+			Expect(record.Console.Name).To(Equal(csl.Name))
+			Expect(record.Job).NotTo(BeNil(), "archive should include the console's job")
+		})
+
 		Context("with Noninteractive = true", func() {
 			BeforeEach(func() {
 				csl.Spec.Noninteractive = true
@@ -442,6 +484,154 @@ var _ = Describe("Console", func() {
 			Expect(drb.ObjectMeta.OwnerReferences[0].Name).To(Equal(csl.ObjectMeta.Name))
 		})
 
+		Context("with a template that allows port-forwarding", func() {
+			BeforeEach(func() {
+				consoleTemplate.Spec.AllowPortForward = true
+			})
+
+			It("Grants port-forwarding to the console pod", func() {
+				podName := fmt.Sprintf("%s-console-abcde", consoleName)
+				jobName := fmt.Sprintf("%s-console", consoleName)
+
+				By("Create a fake pod (to simulate a real job controller)")
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      podName,
+						Namespace: namespaceName,
+						Labels:    labels.Set{"job-name": jobName},
+					},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{
+								Image: "alpine:latest",
+								Name:  "console-container-0",
+							},
+						},
+					},
+				}
+				err := mgr.GetClient().Create(context.TODO(), pod)
+				Expect(err).NotTo(HaveOccurred(), "failed to create fake pod")
+
+				pod.Status.Phase = corev1.PodRunning
+
+				err = mgr.GetClient().Status().Update(context.TODO(), pod)
+				Expect(err).NotTo(HaveOccurred(), "failed to update fake pod status")
+
+				By("Expect role was created with port-forwarding")
+				role := &rbacv1.Role{}
+				Eventually(func() error {
+					identifier, _ := client.ObjectKeyFromObject(csl)
+					err := mgr.GetClient().Get(context.TODO(), identifier, role)
+					return err
+				}).ShouldNot(HaveOccurred(),
+					"failed to find role")
+
+				Expect(role.Rules).To(
+					ContainElement(
+						rbacv1.PolicyRule{
+							Verbs:         []string{"create"},
+							APIGroups:     []string{""},
+							Resources:     []string{"pods/portforward"},
+							ResourceNames: []string{podName},
+						},
+					),
+					"role should allow port-forwarding to the console pod",
+				)
+			})
+		})
+
+		Context("with a template that restricts network egress", func() {
+			BeforeEach(func() {
+				dnsPort := intstr.FromInt(53)
+				consoleTemplate.Spec.NetworkPolicy = &workloadsv1alpha1.ConsoleNetworkPolicy{
+					Egress: []networkingv1.NetworkPolicyEgressRule{
+						{Ports: []networkingv1.NetworkPolicyPort{{Port: &dnsPort}}},
+					},
+				}
+			})
+
+			It("Creates a network policy for the console pod", func() {
+				policy := &networkingv1.NetworkPolicy{}
+				Eventually(func() error {
+					identifier, _ := client.ObjectKeyFromObject(csl)
+					return mgr.GetClient().Get(context.TODO(), identifier, policy)
+				}).ShouldNot(HaveOccurred(), "failed to find network policy for Console")
+
+				Expect(policy.Spec.PodSelector.MatchLabels).To(Equal(map[string]string{"console-name": consoleName}))
+				Expect(policy.Spec.PolicyTypes).To(Equal([]networkingv1.PolicyType{networkingv1.PolicyTypeEgress}))
+				Expect(policy.Spec.Egress).To(HaveLen(1))
+				Expect(policy.Spec.Egress[0].Ports[0].Port.IntValue()).To(Equal(53))
+
+				Expect(policy.ObjectMeta.OwnerReferences).To(HaveLen(1))
+				Expect(policy.ObjectMeta.OwnerReferences[0].Name).To(Equal(csl.Name))
+			})
+
+			It("Deletes the network policy once the template no longer restricts egress", func() {
+				identifier, _ := client.ObjectKeyFromObject(csl)
+				Eventually(func() error {
+					return mgr.GetClient().Get(context.TODO(), identifier, &networkingv1.NetworkPolicy{})
+				}).ShouldNot(HaveOccurred(), "failed to find network policy for Console")
+
+				By("Removing the network policy from the template")
+				templateIdentifier, _ := client.ObjectKeyFromObject(consoleTemplate)
+				Expect(mgr.GetClient().Get(context.TODO(), templateIdentifier, consoleTemplate)).To(Succeed())
+				consoleTemplate.Spec.NetworkPolicy = nil
+				Expect(mgr.GetClient().Update(context.TODO(), consoleTemplate)).To(Succeed())
+
+				By("Triggering a reconcile of the console")
+				Expect(mgr.GetClient().Get(context.TODO(), identifier, csl)).To(Succeed())
+				csl.Annotations = map[string]string{"reconcile": "now"}
+				Expect(mgr.GetClient().Update(context.TODO(), csl)).To(Succeed())
+
+				Eventually(func() bool {
+					err := mgr.GetClient().Get(context.TODO(), identifier, &networkingv1.NetworkPolicy{})
+					return apierrors.IsNotFound(err)
+				}).Should(BeTrue(), "network policy should have been deleted")
+			})
+		})
+
+		Context("with a template that denies all network egress", func() {
+			BeforeEach(func() {
+				consoleTemplate.Spec.NetworkPolicy = &workloadsv1alpha1.ConsoleNetworkPolicy{}
+			})
+
+			It("Creates a network policy without egress rules", func() {
+				policy := &networkingv1.NetworkPolicy{}
+				Eventually(func() error {
+					identifier, _ := client.ObjectKeyFromObject(csl)
+					return mgr.GetClient().Get(context.TODO(), identifier, policy)
+				}).ShouldNot(HaveOccurred(), "failed to find network policy for Console")
+
+				Expect(policy.Spec.PolicyTypes).To(Equal([]networkingv1.PolicyType{networkingv1.PolicyTypeEgress}))
+				Expect(policy.Spec.Egress).To(BeEmpty())
+			})
+		})
+
+		Context("with resource overrides", func() {
+			BeforeEach(func() {
+				consoleTemplate.Spec.ResourceOverrides = &workloadsv1alpha1.ConsoleResourceOverrides{
+					Memory: &workloadsv1alpha1.ConsoleResourceBounds{Max: resource.MustParse("8Gi")},
+				}
+
+				memory := resource.MustParse("4Gi")
+				csl.Spec.Resources = &workloadsv1alpha1.ConsoleResources{Memory: &memory}
+			})
+
+			It("Applies the overrides to the console container", func() {
+				job := &batchv1.Job{}
+				Eventually(func() error {
+					identifier, _ := client.ObjectKeyFromObject(csl)
+					identifier.Name += "-console"
+					return mgr.GetClient().Get(context.TODO(), identifier, job)
+				}).ShouldNot(HaveOccurred(), "failed to find associated Job for Console")
+
+				container := job.Spec.Template.Spec.Containers[0]
+				Expect(container.Resources.Requests.Memory().String()).To(Equal("4Gi"))
+				Expect(container.Resources.Limits.Memory().String()).To(Equal("4Gi"))
+			})
+
+		})
+
 		It("Updates the status with expiry time", func() {
 			updatedCsl := &workloadsv1alpha1.Console{}
 			identifier, _ := client.ObjectKeyFromObject(csl)
@@ -651,6 +841,265 @@ var _ = Describe("Console", func() {
 			})
 		})
 	})
+
+	Describe("Enforcing concurrency limits", func() {
+		var (
+			secondCsl *workloadsv1alpha1.Console
+		)
+
+		BeforeEach(func() {
+			consoleTemplate.Spec.MaxConcurrent = 1
+
+			secondCsl = csl.DeepCopy()
+			secondCsl.ObjectMeta.Name = "console-1"
+		})
+
+		JustBeforeEach(func() {
+			mustCreateResources()
+
+			By("Expect job was created for the first console")
+			Eventually(func() error {
+				identifier, _ := client.ObjectKeyFromObject(csl)
+				identifier.Name += "-console"
+				return mgr.GetClient().Get(context.TODO(), identifier, &batchv1.Job{})
+			}).ShouldNot(HaveOccurred(), "failed to find associated Job for Console")
+		})
+
+		It("Rejects consoles over the limit", func() {
+			err := mgr.GetClient().Create(context.TODO(), secondCsl)
+			Expect(err).To(HaveOccurred(), "expected second console to be rejected")
+			Expect(err.Error()).To(ContainSubstring("which allows at most 1 at once"))
+		})
+
+		Context("with a template that queues when at capacity", func() {
+			BeforeEach(func() {
+				consoleTemplate.Spec.QueueWhenAtCapacity = true
+			})
+
+			It("Starts queued consoles once capacity is available", func() {
+				By("Creating a second console")
+				Expect(mgr.GetClient().Create(context.TODO(), secondCsl)).To(Succeed())
+
+				By("Expect the second console is queued")
+				identifier, _ := client.ObjectKeyFromObject(secondCsl)
+				Eventually(func() workloadsv1alpha1.ConsolePhase {
+					mgr.GetClient().Get(context.TODO(), identifier, secondCsl)
+					return secondCsl.Status.Phase
+				}).Should(Equal(workloadsv1alpha1.ConsoleQueued))
+				Expect(secondCsl.Status.QueuePosition).To(Equal(1))
+
+				jobIdentifier := identifier
+				jobIdentifier.Name += "-console"
+				err := mgr.GetClient().Get(context.TODO(), jobIdentifier, &batchv1.Job{})
+				Expect(apierrors.IsNotFound(err)).To(BeTrue(), "queued console should not have a job")
+
+				By("Completing the first console's job")
+				job := &batchv1.Job{}
+				firstJobIdentifier, _ := client.ObjectKeyFromObject(csl)
+				firstJobIdentifier.Name += "-console"
+				Expect(mgr.GetClient().Get(context.TODO(), firstJobIdentifier, job)).To(Succeed())
+
+				now := metav1.Now()
+				job.Status = batchv1.JobStatus{
+					CompletionTime: &now,
+					Conditions:     []batchv1.JobCondition{{Type: batchv1.JobComplete}},
+				}
+				Expect(mgr.GetClient().Status().Update(context.TODO(), job)).To(Succeed())
+
+				By("Expect a job is created for the second console")
+				Eventually(func() error {
+					return mgr.GetClient().Get(context.TODO(), jobIdentifier, &batchv1.Job{})
+				}, 2*consolecontroller.QueuedRequeueInterval).ShouldNot(HaveOccurred(), "failed to find associated Job for queued Console")
+
+				Eventually(func() int {
+					mgr.GetClient().Get(context.TODO(), identifier, secondCsl)
+					return secondCsl.Status.QueuePosition
+				}).Should(Equal(0))
+			})
+		})
+	})
+
+	Describe("Scheduling consoles", func() {
+		BeforeEach(func() {
+			startAfter := metav1.NewTime(time.Now().Add(5 * time.Second))
+			csl.Spec.StartAfter = &startAfter
+		})
+
+		JustBeforeEach(func() {
+			mustCreateResources()
+		})
+
+		It("Creates a job once the scheduled start time arrives", func() {
+			identifier, _ := client.ObjectKeyFromObject(csl)
+			jobIdentifier := identifier
+			jobIdentifier.Name += "-console"
+
+			By("Expect the console is scheduled")
+			Eventually(func() workloadsv1alpha1.ConsolePhase {
+				mgr.GetClient().Get(context.TODO(), identifier, csl)
+				return csl.Status.Phase
+			}).Should(Equal(workloadsv1alpha1.ConsoleScheduled))
+
+			err := mgr.GetClient().Get(context.TODO(), jobIdentifier, &batchv1.Job{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue(), "scheduled console should not have a job")
+
+			By("Expect a job is created after the scheduled start")
+			Eventually(func() error {
+				return mgr.GetClient().Get(context.TODO(), jobIdentifier, &batchv1.Job{})
+			}, 10*time.Second).ShouldNot(HaveOccurred(), "failed to find associated Job for Console")
+		})
+	})
+
+	Describe("Validating console resources", func() {
+		BeforeEach(func() {
+			consoleTemplate.Spec.ResourceOverrides = &workloadsv1alpha1.ConsoleResourceOverrides{
+				Memory: &workloadsv1alpha1.ConsoleResourceBounds{Max: resource.MustParse("8Gi")},
+			}
+
+			memory := resource.MustParse("16Gi")
+			csl.Spec.Resources = &workloadsv1alpha1.ConsoleResources{Memory: &memory}
+		})
+
+		It("Rejects consoles with resources above the template's bounds", func() {
+			mustCreateNamespace()
+			Expect(mgr.GetClient().Create(context.TODO(), consoleTemplate)).To(Succeed())
+
+			err := mgr.GetClient().Create(context.TODO(), csl)
+			Expect(err).To(HaveOccurred(), "expected console to be rejected")
+			Expect(err.Error()).To(ContainSubstring(".spec.resources.memory: 16Gi is above the maximum of 8Gi"))
+		})
+	})
+
+	Describe("Console schedules", func() {
+		var (
+			schedule *workloadsv1alpha1.ConsoleSchedule
+		)
+
+		BeforeEach(func() {
+			schedule = &workloadsv1alpha1.ConsoleSchedule{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "nightly",
+					Namespace: namespaceName,
+				},
+				Spec: workloadsv1alpha1.ConsoleScheduleSpec{
+					Schedule:           "* * * * *",
+					ConsoleTemplateRef: corev1.LocalObjectReference{Name: "console-template-0"},
+					Command:            []string{"bin/rails", "runner", "Cleanup.run"},
+					Reason:             "nightly cleanup",
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			mustCreateNamespace()
+			Expect(mgr.GetClient().Create(context.TODO(), consoleTemplate)).To(Succeed())
+		})
+
+		It("Rejects schedules with an invalid cron expression", func() {
+			schedule.Spec.Schedule = "every night"
+
+			err := mgr.GetClient().Create(context.TODO(), schedule)
+			Expect(err).To(HaveOccurred(), "expected schedule to be rejected")
+			Expect(err.Error()).To(ContainSubstring(".spec.schedule is invalid"))
+		})
+
+		It("Creates consoles when due", func() {
+			Expect(mgr.GetClient().Create(context.TODO(), schedule)).To(Succeed())
+			Expect(schedule.Spec.User).To(Equal("system:unsecured"))
+
+			By("Making the schedule due")
+			identifier, _ := client.ObjectKeyFromObject(schedule)
+			Eventually(func() error {
+				if err := mgr.GetClient().Get(context.TODO(), identifier, schedule); err != nil {
+					return err
+				}
+				lastScheduleTime := metav1.NewTime(time.Now().Add(-2 * time.Minute))
+				schedule.Status.LastScheduleTime = &lastScheduleTime
+				return mgr.GetClient().Status().Update(context.TODO(), schedule)
+			}).Should(Succeed())
+
+			By("Expect a console is created for the schedule")
+			Eventually(func() []corev1.ObjectReference {
+				mgr.GetClient().Get(context.TODO(), identifier, schedule)
+				return schedule.Status.Active
+			}).Should(HaveLen(1))
+
+			scheduled := &workloadsv1alpha1.Console{}
+			cslIdentifier := client.ObjectKey{Name: schedule.Status.Active[0].Name, Namespace: namespaceName}
+			Expect(mgr.GetClient().Get(context.TODO(), cslIdentifier, scheduled)).To(Succeed())
+			Expect(scheduled.Labels["console-schedule"]).To(Equal("nightly"))
+			Expect(scheduled.Spec.Command).To(Equal(schedule.Spec.Command))
+			Expect(scheduled.Spec.Reason).To(Equal(schedule.Spec.Reason))
+			Expect(scheduled.Spec.Noninteractive).To(BeTrue())
+			Expect(scheduled.Spec.User).To(Equal(schedule.Spec.User))
+
+			By("Expect a job is created for the console")
+			Eventually(func() error {
+				jobIdentifier := cslIdentifier
+				jobIdentifier.Name += "-console"
+				return mgr.GetClient().Get(context.TODO(), jobIdentifier, &batchv1.Job{})
+			}).ShouldNot(HaveOccurred(), "failed to find associated Job for scheduled Console")
+		})
+
+		Context("with a template that requires authorisation", func() {
+			BeforeEach(func() {
+				consoleTemplate.Spec.DefaultAuthorisationRule = &workloadsv1alpha1.ConsoleAuthorisers{
+					AuthorisationsRequired: 1,
+					Subjects:               []rbacv1.Subject{{Kind: "User", Name: "authoriser@example.com"}},
+				}
+			})
+
+			It("Creates a standing authorisation for the schedule", func() {
+				Expect(mgr.GetClient().Create(context.TODO(), schedule)).To(Succeed())
+
+				auth := &workloadsv1alpha1.ConsoleAuthorisation{}
+				authIdentifier := client.ObjectKey{Name: "nightly-schedule", Namespace: namespaceName}
+				Eventually(func() error {
+					return mgr.GetClient().Get(context.TODO(), authIdentifier, auth)
+				}).ShouldNot(HaveOccurred(), "failed to find standing authorisation")
+
+				Expect(auth.Spec.ConsoleScheduleRef).To(Equal(&corev1.LocalObjectReference{Name: "nightly"}))
+				Expect(auth.Spec.Authorisations).To(BeEmpty())
+
+				identifier, _ := client.ObjectKeyFromObject(schedule)
+				Expect(mgr.GetClient().Get(context.TODO(), identifier, schedule)).To(Succeed())
+				Expect(schedule.Status.Authorised).To(BeFalse())
+			})
+
+			It("Doesn't trust authorisations of consoles that claim to belong to the schedule", func() {
+				Expect(mgr.GetClient().Create(context.TODO(), schedule)).To(Succeed())
+
+				By("Creating a console owned by the schedule, with its own authorisation")
+				csl.Spec.Command = schedule.Spec.Command
+				csl.OwnerReferences = []metav1.OwnerReference{{
+					APIVersion: workloadsv1alpha1.GroupVersion.String(),
+					Kind:       "ConsoleSchedule",
+					Name:       schedule.Name,
+					UID:        schedule.UID,
+				}}
+				Expect(mgr.GetClient().Create(context.TODO(), csl)).To(Succeed())
+
+				Expect(mgr.GetClient().Create(context.TODO(), &workloadsv1alpha1.ConsoleAuthorisation{
+					ObjectMeta: metav1.ObjectMeta{Name: csl.Name, Namespace: namespaceName},
+					Spec: workloadsv1alpha1.ConsoleAuthorisationSpec{
+						ConsoleRef:     corev1.LocalObjectReference{Name: csl.Name},
+						Authorisations: []rbacv1.Subject{{Kind: "User", Name: "authoriser@example.com"}},
+					},
+				})).To(Succeed())
+
+				By("Expect the console remains pending authorisation")
+				identifier, _ := client.ObjectKeyFromObject(csl)
+				Consistently(func() workloadsv1alpha1.ConsolePhase {
+					mgr.GetClient().Get(context.TODO(), identifier, csl)
+					return csl.Status.Phase
+				}, time.Second).Should(Or(
+					Equal(workloadsv1alpha1.ConsolePendingAuthorisation),
+					BeEmpty(),
+				))
+			})
+		})
+	})
+
 	Describe("Enforcing job name", func() {
 		BeforeEach(func() {
 			consoleName = "very-very-very-very-long-long-long-long-name-very-very-very-very-long-long-long-long-name"
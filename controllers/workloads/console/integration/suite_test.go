@@ -2,6 +2,8 @@ package integration
 
 import (
 	"context"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
@@ -10,6 +12,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gexec"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -21,11 +24,14 @@ import (
 	rbacv1alpha1 "github.com/gocardless/theatre/v2/apis/rbac/v1alpha1"
 	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
 	consolecontroller "github.com/gocardless/theatre/v2/controllers/workloads/console"
+	consoleschedulecontroller "github.com/gocardless/theatre/v2/controllers/workloads/consoleschedule"
+	"github.com/gocardless/theatre/v2/pkg/workloads/console/archive"
 )
 
 var (
-	mgr     ctrl.Manager
-	testEnv *envtest.Environment
+	mgr        ctrl.Manager
+	testEnv    *envtest.Environment
+	archiveDir string
 
 	finished = make(chan struct{})
 )
@@ -76,6 +82,7 @@ var _ = BeforeSuite(func(done Done) {
 	mgr.GetWebhookServer().Register("/mutate-consoles", &admission.Webhook{
 		Handler: workloadsv1alpha1.NewConsoleAuthenticatorWebhook(
 			ctrl.Log.WithName("webhooks").WithName("console-authenticator"),
+			"system:unsecured",
 		),
 	})
 
@@ -84,13 +91,31 @@ var _ = BeforeSuite(func(done Done) {
 		Handler: workloadsv1alpha1.NewConsoleAuthorisationWebhook(
 			mgr.GetClient(),
 			ctrl.Log.WithName("webhooks").WithName("console-authorisation"),
+			"system:unsecured",
+		),
+	})
+
+	// console validation webhook
+	mgr.GetWebhookServer().Register("/validate-consoles", &admission.Webhook{
+		Handler: workloadsv1alpha1.NewConsoleValidationWebhook(
+			mgr.GetClient(),
+			ctrl.Log.WithName("webhooks").WithName("console-validation"),
 		),
 	})
 
 	// console template webhook
 	mgr.GetWebhookServer().Register("/validate-consoletemplates", &admission.Webhook{
 		Handler: workloadsv1alpha1.NewConsoleTemplateValidationWebhook(
+			mgr.GetAPIReader(),
 			ctrl.Log.WithName("webhooks").WithName("console-template"),
+			workloadsv1alpha1.ConsoleTemplatePolicyOptions{},
+		),
+	})
+
+	// console schedule webhook
+	mgr.GetWebhookServer().Register("/validate-consoleschedules", &admission.Webhook{
+		Handler: workloadsv1alpha1.NewConsoleScheduleValidationWebhook(
+			ctrl.Log.WithName("webhooks").WithName("console-schedule"),
 		),
 	})
 
@@ -102,9 +127,21 @@ var _ = BeforeSuite(func(done Done) {
 		),
 	})
 
+	archiveDir, err = ioutil.TempDir("", "console-archive")
+	Expect(err).ToNot(HaveOccurred())
+
 	err = (&consolecontroller.ConsoleReconciler{
+		Client:     mgr.GetClient(),
+		Log:        ctrl.Log.WithName("controllers").WithName("console"),
+		Scheme:     mgr.GetScheme(),
+		Archive:    &archive.DirectorySink{Path: archiveDir},
+		KubeClient: kubernetes.NewForConfigOrDie(cfg),
+	}).SetupWithManager(context.TODO(), mgr)
+	Expect(err).ToNot(HaveOccurred())
+
+	err = (&consoleschedulecontroller.ConsoleScheduleReconciler{
 		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("console"),
+		Log:    ctrl.Log.WithName("controllers").WithName("consoleschedule"),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(context.TODO(), mgr)
 	Expect(err).ToNot(HaveOccurred())
@@ -128,4 +165,5 @@ var _ = BeforeSuite(func(done Done) {
 
 var _ = AfterSuite(func() {
 	close(finished)
+	os.RemoveAll(archiveDir)
 })
@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	"github.com/gocardless/theatre/v2/pkg/recutil"
+	"github.com/gocardless/theatre/v2/pkg/workloads/console/archive"
+)
+
+const (
+	// Console log keys
+
+	ConsoleLogsArchived    = "ConsoleLogsArchived"
+	ConsoleLogsUnavailable = "ConsoleLogsUnavailable"
+
+	// Annotation recording the key that a console's logs were archived at, which
+	// prevents them from being archived again
+	LogsArchivedAnnotation = "workloads.crd.gocardless.com/logs-archived"
+
+	// Finalizer that prevents consoles from being deleted, taking their pods with
+	// them, until their logs have been archived
+	ConsoleLogsFinalizer = "workloads.crd.gocardless.com/console-logs"
+)
+
+// ConsoleLogsReconciler archives the logs of each console once it has stopped,
+// so that they remain available after the console and its pod have been deleted
+type ConsoleLogsReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	Store      archive.LogStore
+	KubeClient kubernetes.Interface
+}
+
+func (r *ConsoleLogsReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	logger := r.Log.WithValues("component", "ConsoleLogs")
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("consolelogs").
+		For(&workloadsv1alpha1.Console{}).
+		Complete(
+			recutil.ResolveAndReconcileWithFinalizer(
+				ctx, logger, mgr, &workloadsv1alpha1.Console{}, ConsoleLogsFinalizer,
+				func(logger logr.Logger, request reconcile.Request, obj runtime.Object) (reconcile.Result, error) {
+					return r.Reconcile(logger, ctx, request, obj.(*workloadsv1alpha1.Console))
+				},
+				func(logger logr.Logger, request reconcile.Request, obj runtime.Object) error {
+					return r.Finalize(logger, ctx, request, obj.(*workloadsv1alpha1.Console))
+				},
+			),
+		)
+}
+
+func (r *ConsoleLogsReconciler) Reconcile(logger logr.Logger, ctx context.Context, req ctrl.Request, csl *workloadsv1alpha1.Console) (ctrl.Result, error) {
+	logger = logger.WithValues("console", req.NamespacedName)
+
+	if !csl.Stopped() || csl.Annotations[LogsArchivedAnnotation] != "" {
+		return ctrl.Result{}, nil
+	}
+
+	return ctrl.Result{}, r.archive(logger, ctx, csl)
+}
+
+// Finalize archives the logs of a console that's being deleted, if they haven't
+// been already. Consoles can be deleted before they stop, so this archives
+// whatever the console has logged so far.
+func (r *ConsoleLogsReconciler) Finalize(logger logr.Logger, ctx context.Context, req ctrl.Request, csl *workloadsv1alpha1.Console) error {
+	logger = logger.WithValues("console", req.NamespacedName)
+
+	if csl.Annotations[LogsArchivedAnnotation] != "" {
+		return nil
+	}
+
+	return r.archive(logger, ctx, csl)
+}
+
+// archive archives the logs of the console container and annotates the console
+// with where they were archived. Consoles whose pods no longer exist, or never
+// started, have no logs to archive, and are left as they are.
+func (r *ConsoleLogsReconciler) archive(logger logr.Logger, ctx context.Context, csl *workloadsv1alpha1.Console) error {
+	// The pod is deleted along with the job if the console reaches its deadline,
+	// in which case there are no logs left to archive.
+	if csl.Status.PodName == "" {
+		logger.Info("Console has no pod, so logs can't be archived", "event", ConsoleLogsUnavailable)
+		return nil
+	}
+
+	pod := &corev1.Pod{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: csl.Namespace, Name: csl.Status.PodName}, pod)
+	if apierrors.IsNotFound(err) {
+		logger.Info("Console pod no longer exists, so logs can't be archived", "event", ConsoleLogsUnavailable)
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to get console pod")
+	}
+
+	// Logs can't be read from containers that haven't started, which would
+	// otherwise prevent consoles deleted before starting from being finalized
+	if len(pod.Spec.Containers) == 0 || pod.Status.Phase == corev1.PodPending {
+		logger.Info("Console pod hasn't started, so has no logs to archive", "event", ConsoleLogsUnavailable)
+		return nil
+	}
+
+	key := archive.LogKey(csl.Namespace, csl.Name, string(csl.UID))
+	size, err := r.archiveLogs(ctx, key, csl, pod, pod.Spec.Containers[0].Name)
+	if err != nil {
+		return err
+	}
+
+	original := csl.DeepCopy()
+	if csl.Annotations == nil {
+		csl.Annotations = map[string]string{}
+	}
+	csl.Annotations[LogsArchivedAnnotation] = key
+
+	if err := r.Patch(ctx, csl, client.MergeFrom(original)); err != nil {
+		return errors.Wrap(err, "failed to annotate console")
+	}
+
+	logger.Info("Console logs archived", "event", ConsoleLogsArchived, "key", key, "bytes", size)
+
+	return nil
+}
+
+// archiveLogs streams the logs of the console container to a temporary file,
+// as stores need to know the size of what they're storing in advance, and then
+// puts it in the store
+func (r *ConsoleLogsReconciler) archiveLogs(ctx context.Context, key string, csl *workloadsv1alpha1.Console, pod *corev1.Pod, container string) (int64, error) {
+	logs, err := r.KubeClient.CoreV1().Pods(pod.Namespace).
+		GetLogs(pod.Name, &corev1.PodLogOptions{Container: container}).
+		Stream(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to stream console logs")
+	}
+	defer logs.Close()
+
+	tmp, err := ioutil.TempFile("", "console-logs-")
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create temporary file")
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	meta := archive.LogMetadata{
+		Console:    csl.Name,
+		Namespace:  csl.Namespace,
+		UID:        string(csl.UID),
+		User:       csl.Spec.User,
+		Template:   csl.Spec.ConsoleTemplateRef.Name,
+		Command:    csl.Spec.Command,
+		Reason:     csl.Spec.Reason,
+		Pod:        pod.Name,
+		Container:  container,
+		CreatedAt:  csl.CreationTimestamp.Time,
+		ArchivedAt: time.Now(),
+	}
+	if csl.Status.CompletionTime != nil {
+		meta.CompletedAt = &csl.Status.CompletionTime.Time
+	}
+
+	if err := archive.WriteLogs(tmp, meta, logs); err != nil {
+		return 0, errors.Wrap(err, "failed to write console logs")
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to write console logs")
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return 0, errors.Wrap(err, "failed to write console logs")
+	}
+
+	if err := r.Store.Put(ctx, key, tmp, size); err != nil {
+		return 0, errors.Wrap(err, "failed to archive console logs")
+	}
+
+	return size, nil
+}
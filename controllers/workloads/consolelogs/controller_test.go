@@ -0,0 +1,275 @@
+package controllers
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	"github.com/gocardless/theatre/v2/pkg/workloads/console/archive"
+)
+
+var _ = Describe("ConsoleLogsReconciler", func() {
+	const key = "staging/console/console-uid.log.gz"
+
+	var (
+		dir        string
+		apiserver  *httptest.Server
+		store      *archive.DirectoryLogStore
+		kube       client.Client
+		reconciler *ConsoleLogsReconciler
+		csl        *workloadsv1alpha1.Console
+		pod        *corev1.Pod
+		objects    []runtime.Object
+		request    ctrl.Request
+	)
+
+	getConsole := func() *workloadsv1alpha1.Console {
+		updated := &workloadsv1alpha1.Console{}
+		Expect(kube.Get(context.TODO(), request.NamespacedName, updated)).To(Succeed())
+		return updated
+	}
+
+	// archived returns the metadata and logs archived for the console, or nil if
+	// nothing was archived
+	archived := func() *archive.LogMetadata {
+		objects, err := store.List(context.TODO(), "staging/console/")
+		Expect(err).NotTo(HaveOccurred())
+		if len(objects) == 0 {
+			return nil
+		}
+
+		Expect(objects).To(HaveLen(1))
+		Expect(objects[0].Key).To(Equal(key))
+
+		body, err := store.Get(context.TODO(), key)
+		Expect(err).NotTo(HaveOccurred())
+		defer body.Close()
+
+		meta, logs, err := archive.ReadLogs(body)
+		Expect(err).NotTo(HaveOccurred())
+
+		data, err := ioutil.ReadAll(logs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("fake logs"))
+
+		return meta
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "console-logs-")
+		Expect(err).NotTo(HaveOccurred())
+		store = &archive.DirectoryLogStore{Path: dir}
+
+		csl = &workloadsv1alpha1.Console{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "console",
+				Namespace:         "staging",
+				UID:               "console-uid",
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+			},
+			Spec: workloadsv1alpha1.ConsoleSpec{
+				User:               "alice@example.com",
+				Reason:             "Investigating an incident",
+				Command:            []string{"bin/rails", "console"},
+				ConsoleTemplateRef: corev1.LocalObjectReference{Name: "template"},
+			},
+			Status: workloadsv1alpha1.ConsoleStatus{
+				Phase:   workloadsv1alpha1.ConsoleStopped,
+				PodName: "console-pod",
+			},
+		}
+
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "console-pod", Namespace: "staging"},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "console-container-0"}, {Name: "sidecar"}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodSucceeded},
+		}
+
+		objects = []runtime.Object{pod}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "staging", Name: "console"}}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+		apiserver.Close()
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(workloadsv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+		kube = fake.NewFakeClientWithScheme(scheme, append(objects, csl)...)
+
+		// The fake clientset can't stream logs, so serve them from a stand-in for
+		// the API server instead
+		apiserver = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/v1/namespaces/staging/pods/console-pod/log" || r.URL.Query().Get("container") != "console-container-0" {
+				http.NotFound(w, r)
+				return
+			}
+
+			w.Write([]byte("fake logs"))
+		}))
+		kubeClient, err := kubernetes.NewForConfig(&rest.Config{Host: apiserver.URL})
+		Expect(err).NotTo(HaveOccurred())
+
+		reconciler = &ConsoleLogsReconciler{
+			Client:     kube,
+			Log:        zap.LoggerTo(GinkgoWriter, true),
+			Scheme:     scheme,
+			Store:      store,
+			KubeClient: kubeClient,
+		}
+	})
+
+	Describe("Reconcile", func() {
+		reconcile := func() {
+			_, err := reconciler.Reconcile(reconciler.Log, context.TODO(), request, getConsole())
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		It("Archives the logs of the console container, and annotates the console", func() {
+			reconcile()
+
+			meta := archived()
+			Expect(meta).NotTo(BeNil())
+			Expect(meta.Console).To(Equal("console"))
+			Expect(meta.UID).To(Equal("console-uid"))
+			Expect(meta.User).To(Equal("alice@example.com"))
+			Expect(meta.Template).To(Equal("template"))
+			Expect(meta.Command).To(Equal([]string{"bin/rails", "console"}))
+			Expect(meta.Pod).To(Equal("console-pod"))
+			Expect(meta.Container).To(Equal("console-container-0"))
+
+			Expect(getConsole().Annotations).To(HaveKeyWithValue(LogsArchivedAnnotation, key))
+		})
+
+		Context("When the console is still running", func() {
+			BeforeEach(func() {
+				csl.Status.Phase = workloadsv1alpha1.ConsoleRunning
+			})
+
+			It("Doesn't archive its logs yet", func() {
+				reconcile()
+
+				Expect(archived()).To(BeNil())
+				Expect(getConsole().Annotations).NotTo(HaveKey(LogsArchivedAnnotation))
+			})
+		})
+
+		Context("When the logs have already been archived", func() {
+			BeforeEach(func() {
+				csl.Annotations = map[string]string{LogsArchivedAnnotation: key}
+			})
+
+			It("Doesn't archive them again", func() {
+				reconcile()
+
+				Expect(archived()).To(BeNil())
+			})
+		})
+
+		Context("When the pod no longer exists", func() {
+			BeforeEach(func() {
+				objects = nil
+			})
+
+			It("Leaves the console as it is", func() {
+				reconcile()
+
+				Expect(archived()).To(BeNil())
+				Expect(getConsole().Annotations).NotTo(HaveKey(LogsArchivedAnnotation))
+			})
+		})
+	})
+
+	Describe("Finalize", func() {
+		var err error
+
+		JustBeforeEach(func() {
+			err = reconciler.Finalize(reconciler.Log, context.TODO(), request, getConsole())
+		})
+
+		Context("When the console is deleted before it stops", func() {
+			BeforeEach(func() {
+				csl.Status.Phase = workloadsv1alpha1.ConsoleRunning
+				pod.Status.Phase = corev1.PodRunning
+			})
+
+			It("Archives the logs so far", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(archived()).NotTo(BeNil())
+				Expect(getConsole().Annotations).To(HaveKeyWithValue(LogsArchivedAnnotation, key))
+			})
+		})
+
+		Context("When the logs have already been archived", func() {
+			BeforeEach(func() {
+				csl.Annotations = map[string]string{LogsArchivedAnnotation: key}
+			})
+
+			It("Allows the console to be deleted", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(archived()).To(BeNil())
+			})
+		})
+
+		Context("When the pod never started", func() {
+			BeforeEach(func() {
+				csl.Status.Phase = workloadsv1alpha1.ConsolePendingAuthorisation
+				pod.Status.Phase = corev1.PodPending
+			})
+
+			It("Allows the console to be deleted without its logs", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(archived()).To(BeNil())
+			})
+		})
+
+		Context("When the pod no longer exists", func() {
+			BeforeEach(func() {
+				objects = nil
+			})
+
+			It("Allows the console to be deleted without its logs", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(archived()).To(BeNil())
+			})
+		})
+
+		Context("When the logs can't be archived", func() {
+			BeforeEach(func() {
+				// A file where the store expects a directory prevents writing to it
+				store.Path = dir + "/store"
+				Expect(ioutil.WriteFile(store.Path, nil, 0644)).To(Succeed())
+			})
+
+			It("Holds the deletion until they can be", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(getConsole().Annotations).NotTo(HaveKey(LogsArchivedAnnotation))
+			})
+		})
+	})
+})
@@ -0,0 +1,582 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	rbacv1alpha1 "github.com/gocardless/theatre/v2/apis/rbac/v1alpha1"
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	"github.com/gocardless/theatre/v2/pkg/logging"
+	"github.com/gocardless/theatre/v2/pkg/recutil"
+)
+
+const (
+	// Resource-level events
+
+	EventDelete           = "Delete"
+	EventSuccessfulCreate = "SuccessfulCreate"
+	EventSuccessfulUpdate = "SuccessfulUpdate"
+	EventNoCreateOrUpdate = "NoCreateOrUpdate"
+
+	// Warning events
+
+	EventUnknownOutcome       = "UnknownOutcome"
+	EventInvalidSpecification = "InvalidSpecification"
+
+	// Console schedule log keys
+
+	ConsoleScheduled       = "ConsoleScheduled"
+	ConsoleSkipped         = "ConsoleSkipped"
+	ConsoleReplaced        = "ConsoleReplaced"
+	ConsoleScheduleAltered = "ConsoleScheduleAltered"
+
+	Console              = "console"
+	ConsoleAuthorisation = "consoleauthorisation"
+	Role                 = "role"
+	DirectoryRoleBinding = "directoryrolebinding"
+
+	// Label applied to the consoles created by a schedule
+	ConsoleScheduleLabel = "console-schedule"
+
+	// Annotation recording the version of the schedule that a standing
+	// authorisation applies to
+	ScheduleHashAnnotation = "workloads.crd.gocardless.com/schedule-hash"
+)
+
+type ConsoleScheduleReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+func (r *ConsoleScheduleReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	logger := r.Log.WithValues("component", "ConsoleSchedule")
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&workloadsv1alpha1.ConsoleSchedule{}).
+		Watches(
+			&source.Kind{Type: &workloadsv1alpha1.ConsoleAuthorisation{}},
+			&handler.EnqueueRequestForOwner{
+				IsController: true,
+				OwnerType:    &workloadsv1alpha1.ConsoleSchedule{},
+			},
+		).
+		// Consoles are controlled by their template, so the schedule is only one
+		// of their owners
+		Watches(
+			&source.Kind{Type: &workloadsv1alpha1.Console{}},
+			&handler.EnqueueRequestForOwner{
+				IsController: false,
+				OwnerType:    &workloadsv1alpha1.ConsoleSchedule{},
+			},
+		).
+		Complete(
+			recutil.ResolveAndReconcile(
+				ctx, logger, mgr, &workloadsv1alpha1.ConsoleSchedule{},
+				func(logger logr.Logger, request reconcile.Request, obj runtime.Object) (reconcile.Result, error) {
+					return r.Reconcile(logger, ctx, request, obj.(*workloadsv1alpha1.ConsoleSchedule))
+				},
+			),
+		)
+}
+
+func (r *ConsoleScheduleReconciler) Reconcile(logger logr.Logger, ctx context.Context, req ctrl.Request, schedule *workloadsv1alpha1.ConsoleSchedule) (ctrl.Result, error) {
+	logger = logger.WithValues("consoleschedule", req.NamespacedName)
+	now := time.Now()
+
+	// The schedule is validated on admission, so this should only happen if the
+	// webhook isn't running. There's nothing to do until the schedule is fixed.
+	cronSchedule, err := schedule.CronSchedule()
+	if err != nil {
+		msg := fmt.Sprintf("Invalid schedule: %v", err)
+		logger.Info(msg, "event", EventInvalidSpecification, "error", msg)
+		return ctrl.Result{}, nil
+	}
+
+	tpl := &workloadsv1alpha1.ConsoleTemplate{}
+	tplName := types.NamespacedName{Name: schedule.Spec.ConsoleTemplateRef.Name, Namespace: req.Namespace}
+	if err := r.Get(ctx, tplName, tpl); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to retrieve console template")
+	}
+
+	command := schedule.Spec.Command
+	if len(command) == 0 {
+		if command, err = tpl.GetDefaultCommandWithArgs(); err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "neither the schedule or template have a command to evaluate")
+		}
+	}
+
+	// The standing authorisation is subject to the same rule as a console
+	// created by hand with the same command
+	authRule, err := tpl.GetAuthorisationRule(command, nil)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to determine authorisation rule for schedule")
+	}
+
+	var authorisation *workloadsv1alpha1.ConsoleAuthorisation
+	if authRule != nil {
+		authorisation, err = r.reconcileStandingAuthorisation(ctx, logger, schedule, command, authRule.Subjects)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	authorised := authRule == nil || len(authorisation.Spec.Authorisations) >= authRule.AuthorisationsRequired
+
+	consoles, err := r.getConsoles(ctx, schedule)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to list consoles for schedule")
+	}
+
+	// Only consoles that we've recorded as being created by the schedule are
+	// considered active, as anybody could create a console that claims to
+	// belong to it.
+	active := []workloadsv1alpha1.Console{}
+	for _, csl := range consoles {
+		if schedule.IsActive(&csl) && !csl.PostRunning() {
+			active = append(active, csl)
+		}
+	}
+
+	if authorisation != nil {
+		for _, csl := range active {
+			if err := r.authoriseConsole(ctx, logger, schedule, &csl, authorisation); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	if err := r.pruneHistory(ctx, logger, schedule, consoles); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	status := schedule.Status.DeepCopy()
+	status.Authorised = authorised
+	status.Active = []corev1.ObjectReference{}
+	for _, csl := range active {
+		status.Active = append(status.Active, consoleReference(&csl))
+	}
+
+	scheduledTime, tooManyMissed := schedule.GetMostRecentScheduleTime(cronSchedule, now)
+	if tooManyMissed {
+		logger.Info(
+			fmt.Sprintf("Missed more than %d scheduled times, skipping those before the starting deadline", workloadsv1alpha1.MaxMissedScheduleTimes),
+			"event", ConsoleSkipped,
+		)
+
+		// Record that the times have been given up on, so they're not counted again
+		if scheduledTime == nil {
+			status.LastScheduleTime = &metav1.Time{Time: now}
+		}
+	}
+
+	if scheduledTime != nil {
+		logger := logger.WithValues("scheduled_time", scheduledTime.UTC().Format(time.RFC3339))
+
+		switch {
+		case schedule.Spec.Suspend:
+			// Leave the time unrecorded, so that the console can still be created
+			// if the schedule is resumed before its starting deadline
+			logging.WithNoRecord(logger).Info("Schedule is suspended", "event", ConsoleSkipped)
+		case schedule.MissedStartingDeadline(*scheduledTime, now):
+			logger.Info("Missed starting deadline for console", "event", ConsoleSkipped)
+			status.LastScheduleTime = &metav1.Time{Time: *scheduledTime}
+		case !authorised:
+			logger.Info("Skipping console as schedule is not authorised", "event", ConsoleSkipped)
+			status.LastScheduleTime = &metav1.Time{Time: *scheduledTime}
+		case len(active) > 0 && schedule.GetConcurrencyPolicy() == workloadsv1alpha1.ForbidConcurrent:
+			// Leave the time unrecorded, so that the console is created once the
+			// active consoles have finished, unless it misses its starting deadline
+			logging.WithNoRecord(logger).Info(
+				"Waiting for active consoles to finish before creating console",
+				"event", ConsoleSkipped,
+			)
+		default:
+			if schedule.GetConcurrencyPolicy() == workloadsv1alpha1.ReplaceConcurrent {
+				for _, csl := range active {
+					logger.Info("Replacing active console", "event", ConsoleReplaced, "console_name", csl.Name)
+					if err := r.Delete(ctx, &csl, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+						return ctrl.Result{}, errors.Wrap(err, "failed to delete active console")
+					}
+				}
+				status.Active = []corev1.ObjectReference{}
+			}
+
+			csl, err := r.createConsole(ctx, logger, schedule, *scheduledTime)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+
+			if csl != nil {
+				status.Active = append(status.Active, consoleReference(csl))
+			}
+			status.LastScheduleTime = &metav1.Time{Time: *scheduledTime}
+		}
+	}
+
+	if !reflect.DeepEqual(schedule.Status, *status) {
+		schedule.Status = *status
+		if err := r.Status().Update(ctx, schedule); err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to update console schedule status")
+		}
+	}
+
+	// The new console will be authorised when we reconcile again in response
+	// to it being created, once it's recorded in the schedule's status
+	return requeueAfterInterval(logger, time.Until(cronSchedule.Next(now))), nil
+}
+
+// reconcileStandingAuthorisation ensures that the schedule has an authorisation
+// object, which may be updated by the subjects of the authorisation rule, and
+// returns it. The authorisation is reset whenever the schedule changes in a way
+// that would change what it runs, or on whose behalf.
+func (r *ConsoleScheduleReconciler) reconcileStandingAuthorisation(ctx context.Context, logger logr.Logger, schedule *workloadsv1alpha1.ConsoleSchedule, command []string, subjects []rbacv1.Subject) (*workloadsv1alpha1.ConsoleAuthorisation, error) {
+	name := types.NamespacedName{Name: schedule.GetStandingAuthorisationName(), Namespace: schedule.Namespace}
+	hash := scheduleHash(schedule, command)
+
+	authorisation := &workloadsv1alpha1.ConsoleAuthorisation{}
+	err := r.Get(ctx, name, authorisation)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, errors.Wrap(err, "failed to retrieve standing authorisation")
+	}
+
+	// Authorisations can only be appended to, so reset them by replacing the
+	// object
+	if err == nil && authorisation.Annotations[ScheduleHashAnnotation] != hash {
+		logger.Info(
+			"Schedule altered, resetting standing authorisation",
+			"event", ConsoleScheduleAltered,
+			"user", schedule.Spec.User,
+		)
+		if err := r.Delete(ctx, authorisation); err != nil && !apierrors.IsNotFound(err) {
+			return nil, errors.Wrap(err, "failed to reset standing authorisation")
+		}
+		err = apierrors.NewNotFound(workloadsv1alpha1.GroupVersion.WithResource("consoleauthorisations").GroupResource(), name.Name)
+	}
+
+	if apierrors.IsNotFound(err) {
+		authorisation = &workloadsv1alpha1.ConsoleAuthorisation{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name.Name,
+				Namespace:   name.Namespace,
+				Labels:      schedule.Labels,
+				Annotations: map[string]string{ScheduleHashAnnotation: hash},
+			},
+			Spec: workloadsv1alpha1.ConsoleAuthorisationSpec{
+				ConsoleScheduleRef: &corev1.LocalObjectReference{Name: schedule.Name},
+				Authorisations:     []rbacv1.Subject{},
+			},
+		}
+		if err := controllerutil.SetControllerReference(schedule, authorisation, r.Scheme); err != nil {
+			return nil, err
+		}
+		if err := r.Create(ctx, authorisation); err != nil {
+			return nil, errors.Wrap(err, "failed to create standing authorisation")
+		}
+		logger.Info("Created "+objDesc(ConsoleAuthorisation, authorisation), "event", EventSuccessfulCreate)
+	}
+
+	// Allow the authorisers to update the standing authorisation, in the same
+	// way as for a console
+	rbacName := types.NamespacedName{
+		Name:      fmt.Sprintf("%s-%s", name.Name, "authorisation"),
+		Namespace: name.Namespace,
+	}
+
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rbacName.Name,
+			Namespace: name.Namespace,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				Verbs:         []string{"get", "patch", "update"},
+				APIGroups:     []string{"workloads.crd.gocardless.com"},
+				Resources:     []string{"consoleauthorisations"},
+				ResourceNames: []string{name.Name},
+			},
+		},
+	}
+
+	if err := r.createOrUpdate(ctx, logger, schedule, role, Role, recutil.RoleDiff); err != nil {
+		return nil, errors.Wrap(err, "failed to create role for standing authorisation")
+	}
+
+	drb := &rbacv1alpha1.DirectoryRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rbacName.Name,
+			Namespace: name.Namespace,
+		},
+		Spec: rbacv1alpha1.DirectoryRoleBindingSpec{
+			Subjects: subjects,
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "Role",
+				Name:     rbacName.Name,
+			},
+		},
+	}
+
+	if err := r.createOrUpdate(ctx, logger, schedule, drb, DirectoryRoleBinding, recutil.DirectoryRoleBindingDiff); err != nil {
+		return nil, errors.Wrap(err, "failed to create directory rolebinding for standing authorisation")
+	}
+
+	return authorisation, nil
+}
+
+// authoriseConsole gives a console created by the schedule the authorisations
+// of the schedule's standing authorisation. The console controller doesn't
+// create an authorisation object for these consoles, so they remain pending
+// authorisation until this happens, and it only trusts an authorisation that is
+// labelled with the UID of the schedule.
+func (r *ConsoleScheduleReconciler) authoriseConsole(ctx context.Context, logger logr.Logger, schedule *workloadsv1alpha1.ConsoleSchedule, csl *workloadsv1alpha1.Console, standing *workloadsv1alpha1.ConsoleAuthorisation) error {
+	labels := map[string]string{}
+	for k, v := range csl.Labels {
+		labels[k] = v
+	}
+	labels[workloadsv1alpha1.ScheduledAuthorisationLabel] = string(schedule.UID)
+
+	authorisation := &workloadsv1alpha1.ConsoleAuthorisation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      csl.Name,
+			Namespace: csl.Namespace,
+			Labels:    labels,
+		},
+		Spec: workloadsv1alpha1.ConsoleAuthorisationSpec{
+			ConsoleRef:     corev1.LocalObjectReference{Name: csl.Name},
+			Authorisations: standing.Spec.Authorisations,
+		},
+	}
+
+	if err := controllerutil.SetControllerReference(csl, authorisation, r.Scheme); err != nil {
+		return err
+	}
+
+	if err := r.Create(ctx, authorisation); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			return nil
+		}
+
+		return errors.Wrap(err, "failed to create console authorisation")
+	}
+
+	logger.Info("Created "+objDesc(ConsoleAuthorisation, authorisation), "event", EventSuccessfulCreate, "console_name", csl.Name)
+	return nil
+}
+
+func (r *ConsoleScheduleReconciler) createConsole(ctx context.Context, logger logr.Logger, schedule *workloadsv1alpha1.ConsoleSchedule, scheduledTime time.Time) (*workloadsv1alpha1.Console, error) {
+	csl := buildConsole(schedule, scheduledTime)
+
+	// Consoles are controlled by their template, so the schedule is only an
+	// owner for the purposes of garbage collection
+	if err := controllerutil.SetOwnerReference(schedule, csl, r.Scheme); err != nil {
+		return nil, err
+	}
+
+	if err := r.Create(ctx, csl); err != nil {
+		// We can't tell whether we created this console before failing to
+		// record it, or somebody else did, so it won't be authorised
+		if apierrors.IsAlreadyExists(err) {
+			logger.Info(
+				"Console for scheduled time already exists",
+				"event", ConsoleSkipped,
+				"console_name", csl.Name,
+			)
+			return nil, nil
+		}
+
+		return nil, errors.Wrap(err, "failed to create console")
+	}
+
+	logger.Info(
+		"Created "+objDesc(Console, csl),
+		"event", ConsoleScheduled,
+		"console_name", csl.Name,
+		"user", schedule.Spec.User,
+	)
+
+	return csl, nil
+}
+
+// pruneHistory deletes the oldest finished consoles, beyond the history limits
+// of the schedule
+func (r *ConsoleScheduleReconciler) pruneHistory(ctx context.Context, logger logr.Logger, schedule *workloadsv1alpha1.ConsoleSchedule, consoles []workloadsv1alpha1.Console) error {
+	succeeded := []workloadsv1alpha1.Console{}
+	failed := []workloadsv1alpha1.Console{}
+	for _, csl := range consoles {
+		switch {
+		case csl.Succeeded():
+			succeeded = append(succeeded, csl)
+		case csl.PostRunning():
+			failed = append(failed, csl)
+		}
+	}
+
+	for _, history := range []struct {
+		consoles []workloadsv1alpha1.Console
+		limit    int
+	}{
+		{succeeded, schedule.GetSuccessfulHistoryLimit()},
+		{failed, schedule.GetFailedHistoryLimit()},
+	} {
+		sort.Slice(history.consoles, func(i, j int) bool {
+			return history.consoles[i].CreationTimestamp.Before(&history.consoles[j].CreationTimestamp)
+		})
+
+		for i := 0; i < len(history.consoles)-history.limit; i++ {
+			csl := &history.consoles[i]
+			logger.Info("Deleting "+objDesc(Console, csl), "event", EventDelete, "kind", Console)
+			if err := r.Delete(ctx, csl, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+				return errors.Wrap(err, "failed to delete console from history")
+			}
+		}
+	}
+
+	return nil
+}
+
+// getConsoles lists the consoles owned by the schedule
+func (r *ConsoleScheduleReconciler) getConsoles(ctx context.Context, schedule *workloadsv1alpha1.ConsoleSchedule) ([]workloadsv1alpha1.Console, error) {
+	list := &workloadsv1alpha1.ConsoleList{}
+	if err := r.List(ctx, list, client.InNamespace(schedule.Namespace)); err != nil {
+		return nil, err
+	}
+
+	consoles := []workloadsv1alpha1.Console{}
+	for _, csl := range list.Items {
+		if ref := csl.GetConsoleSchedule(); ref != nil && ref.UID == schedule.UID && csl.DeletionTimestamp.IsZero() {
+			consoles = append(consoles, csl)
+		}
+	}
+
+	return consoles, nil
+}
+
+func (r *ConsoleScheduleReconciler) createOrUpdate(ctx context.Context, logger logr.Logger, schedule *workloadsv1alpha1.ConsoleSchedule, expected recutil.ObjWithMeta, kind string, diffFunc recutil.DiffFunc) error {
+	if err := controllerutil.SetControllerReference(schedule, expected, r.Scheme); err != nil {
+		return err
+	}
+
+	outcome, err := recutil.CreateOrUpdate(ctx, r, expected, diffFunc)
+	if err != nil {
+		return errors.Wrap(err, "CreateOrUpdate failed")
+	}
+
+	switch outcome {
+	case recutil.Create:
+		logger.Info("Created "+objDesc(kind, expected), "event", EventSuccessfulCreate)
+	case recutil.Update:
+		logger.Info("Updated "+objDesc(kind, expected), "event", EventSuccessfulUpdate)
+	case recutil.None:
+		logging.WithNoRecord(logger).Info(
+			"Nothing to do for "+objDesc(kind, expected),
+			"event", EventNoCreateOrUpdate,
+		)
+	default:
+		msg := fmt.Sprintf("Unknown outcome %s for %s", outcome, objDesc(kind, expected))
+		logger.Info(
+			msg,
+			"event", EventUnknownOutcome,
+			"error", msg,
+		)
+	}
+
+	return nil
+}
+
+// buildConsole returns the console to create for the given scheduled time. The
+// name is derived from the time so that we never create two consoles for the
+// same time, and the schedule name is truncated so that the console's job name
+// remains unique.
+func buildConsole(schedule *workloadsv1alpha1.ConsoleSchedule, scheduledTime time.Time) *workloadsv1alpha1.Console {
+	name := fmt.Sprintf("%s-%d", truncateString(schedule.Name, 44), scheduledTime.Unix()/60)
+
+	labels := map[string]string{}
+	for k, v := range schedule.Labels {
+		labels[k] = v
+	}
+	labels[ConsoleScheduleLabel] = truncateString(schedule.Name, 63)
+
+	return &workloadsv1alpha1.Console{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: schedule.Namespace,
+			Labels:    labels,
+		},
+		Spec: workloadsv1alpha1.ConsoleSpec{
+			ConsoleTemplateRef: schedule.Spec.ConsoleTemplateRef,
+			Command:            schedule.Spec.Command,
+			Reason:             schedule.Spec.Reason,
+			TimeoutSeconds:     schedule.Spec.TimeoutSeconds,
+			// The console runs on behalf of the schedule's user, which the
+			// authenticator webhook keeps as we create it
+			User: schedule.Spec.User,
+			// Nobody attaches to a scheduled console, and its standing
+			// authorisation shouldn't extend to an interactive session
+			Noninteractive: true,
+		},
+	}
+}
+
+// scheduleHash identifies what a schedule runs and on whose behalf, which is
+// what its standing authorisation is given for
+func scheduleHash(schedule *workloadsv1alpha1.ConsoleSchedule, command []string) string {
+	data, _ := json.Marshal(struct {
+		Template string   `json:"template"`
+		Command  []string `json:"command"`
+		User     string   `json:"user"`
+	}{schedule.Spec.ConsoleTemplateRef.Name, command, schedule.Spec.User})
+
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+func consoleReference(csl *workloadsv1alpha1.Console) corev1.ObjectReference {
+	return corev1.ObjectReference{
+		APIVersion: workloadsv1alpha1.GroupVersion.String(),
+		Kind:       "Console",
+		Name:       csl.Name,
+		Namespace:  csl.Namespace,
+		UID:        csl.UID,
+	}
+}
+
+// Use the same 'kind: obj-name' format as in the core controllers, when
+// emitting events.
+func objDesc(kind string, obj metav1.Object) string {
+	return fmt.Sprintf("%s: %s", kind, obj.GetName())
+}
+
+func requeueAfterInterval(logger logr.Logger, interval time.Duration) reconcile.Result {
+	logging.WithNoRecord(logger).Info(
+		"Reconciliation requeued",
+		"event", recutil.EventRequeued,
+		"reconcile_after", interval,
+	)
+	return reconcile.Result{Requeue: true, RequeueAfter: interval}
+}
+
+func truncateString(str string, length int) string {
+	if len(str) > length {
+		return str[0:length]
+	}
+	return str
+}
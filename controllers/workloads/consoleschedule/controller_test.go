@@ -0,0 +1,351 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	rbacv1alpha1 "github.com/gocardless/theatre/v2/apis/rbac/v1alpha1"
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+)
+
+var _ = Describe("ConsoleScheduleReconciler", func() {
+	const namespace = "staging"
+
+	var (
+		scheme   *runtime.Scheme
+		kube     client.Client
+		schedule *workloadsv1alpha1.ConsoleSchedule
+		template *workloadsv1alpha1.ConsoleTemplate
+		objects  []runtime.Object
+		now      time.Time
+	)
+
+	// scheduledTime is the most recent time the every-minute schedule was due
+	scheduledTime := func() time.Time {
+		return now.Truncate(time.Minute)
+	}
+
+	scheduledName := func() string {
+		return fmt.Sprintf("nightly-%d", scheduledTime().Unix()/60)
+	}
+
+	ownedConsole := func(name string, phase workloadsv1alpha1.ConsolePhase, created time.Time) *workloadsv1alpha1.Console {
+		return &workloadsv1alpha1.Console{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         namespace,
+				UID:               types.UID(name + "-uid"),
+				CreationTimestamp: metav1.NewTime(created),
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: workloadsv1alpha1.GroupVersion.String(),
+					Kind:       "ConsoleSchedule",
+					Name:       schedule.Name,
+					UID:        schedule.UID,
+				}},
+			},
+			Spec:   workloadsv1alpha1.ConsoleSpec{ConsoleTemplateRef: corev1.LocalObjectReference{Name: "template"}},
+			Status: workloadsv1alpha1.ConsoleStatus{Phase: phase},
+		}
+	}
+
+	active := func(consoles ...*workloadsv1alpha1.Console) {
+		for _, csl := range consoles {
+			schedule.Status.Active = append(schedule.Status.Active, consoleReference(csl))
+			objects = append(objects, csl)
+		}
+	}
+
+	getConsoles := func() []string {
+		list := &workloadsv1alpha1.ConsoleList{}
+		Expect(kube.List(context.TODO(), list, client.InNamespace(namespace))).To(Succeed())
+
+		names := []string{}
+		for _, csl := range list.Items {
+			names = append(names, csl.Name)
+		}
+
+		return names
+	}
+
+	getSchedule := func() *workloadsv1alpha1.ConsoleSchedule {
+		updated := &workloadsv1alpha1.ConsoleSchedule{}
+		Expect(kube.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: "nightly"}, updated)).To(Succeed())
+		return updated
+	}
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(workloadsv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(rbacv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+		now = time.Now()
+
+		template = &workloadsv1alpha1.ConsoleTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "template", Namespace: namespace},
+			Spec: workloadsv1alpha1.ConsoleTemplateSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "app", Image: "app", Command: []string{"rake", "nightly"}}},
+					},
+				},
+			},
+		}
+
+		schedule = &workloadsv1alpha1.ConsoleSchedule{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "nightly",
+				Namespace: namespace,
+				UID:       "schedule-uid",
+				// Created after the schedule was last due, so that only the most
+				// recent time is due
+				CreationTimestamp: metav1.NewTime(scheduledTime().Add(-time.Second)),
+			},
+			Spec: workloadsv1alpha1.ConsoleScheduleSpec{
+				Schedule:           "* * * * *",
+				ConsoleTemplateRef: corev1.LocalObjectReference{Name: "template"},
+				User:               "alice@example.com",
+				Reason:             "Nightly maintenance",
+			},
+		}
+
+		objects = nil
+	})
+
+	JustBeforeEach(func() {
+		kube = fake.NewFakeClientWithScheme(scheme, append(objects, template, schedule)...)
+
+		reconciler := &ConsoleScheduleReconciler{
+			Client: kube,
+			Log:    zap.LoggerTo(GinkgoWriter, true),
+			Scheme: scheme,
+		}
+
+		_, err := reconciler.Reconcile(
+			reconciler.Log, context.TODO(),
+			ctrl.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: "nightly"}},
+			getSchedule(),
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("Creates a console for the scheduled time, on behalf of the schedule's user", func() {
+		csl := &workloadsv1alpha1.Console{}
+		Expect(kube.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: scheduledName()}, csl)).To(Succeed())
+
+		Expect(csl.Spec.User).To(Equal("alice@example.com"))
+		Expect(csl.Spec.Reason).To(Equal("Nightly maintenance"))
+		Expect(csl.Spec.Noninteractive).To(BeTrue())
+		Expect(csl.Labels).To(HaveKeyWithValue(ConsoleScheduleLabel, "nightly"))
+		Expect(csl.GetConsoleSchedule()).NotTo(BeNil())
+		Expect(csl.GetConsoleSchedule().UID).To(Equal(schedule.UID))
+
+		status := getSchedule().Status
+		Expect(status.LastScheduleTime.Time).To(BeTemporally("==", scheduledTime()))
+		Expect(status.Active).To(HaveLen(1))
+		Expect(status.Active[0].Name).To(Equal(scheduledName()))
+		Expect(status.Authorised).To(BeTrue())
+	})
+
+	Context("When the schedule has already run at the scheduled time", func() {
+		BeforeEach(func() {
+			lastScheduleTime := metav1.NewTime(scheduledTime())
+			schedule.Status.LastScheduleTime = &lastScheduleTime
+		})
+
+		It("Doesn't create a console", func() {
+			Expect(getConsoles()).To(BeEmpty())
+		})
+	})
+
+	Context("When suspended", func() {
+		BeforeEach(func() {
+			schedule.Spec.Suspend = true
+		})
+
+		It("Doesn't create a console, leaving the time unrecorded", func() {
+			Expect(getConsoles()).To(BeEmpty())
+			Expect(getSchedule().Status.LastScheduleTime).To(BeNil())
+		})
+	})
+
+	Context("With an active console", func() {
+		BeforeEach(func() {
+			active(ownedConsole("nightly-running", workloadsv1alpha1.ConsoleRunning, now.Add(-time.Hour)))
+		})
+
+		Context("And the Allow concurrency policy", func() {
+			BeforeEach(func() {
+				schedule.Spec.ConcurrencyPolicy = workloadsv1alpha1.AllowConcurrent
+			})
+
+			It("Creates a console alongside it", func() {
+				Expect(getConsoles()).To(ConsistOf("nightly-running", scheduledName()))
+				Expect(getSchedule().Status.Active).To(HaveLen(2))
+			})
+		})
+
+		Context("And the Forbid concurrency policy", func() {
+			BeforeEach(func() {
+				schedule.Spec.ConcurrencyPolicy = workloadsv1alpha1.ForbidConcurrent
+			})
+
+			It("Waits for it to finish, leaving the time unrecorded", func() {
+				Expect(getConsoles()).To(ConsistOf("nightly-running"))
+
+				status := getSchedule().Status
+				Expect(status.LastScheduleTime).To(BeNil())
+				Expect(status.Active).To(HaveLen(1))
+			})
+		})
+
+		Context("And the Replace concurrency policy", func() {
+			BeforeEach(func() {
+				schedule.Spec.ConcurrencyPolicy = workloadsv1alpha1.ReplaceConcurrent
+			})
+
+			It("Deletes it and creates a console", func() {
+				Expect(getConsoles()).To(ConsistOf(scheduledName()))
+
+				status := getSchedule().Status
+				Expect(status.Active).To(HaveLen(1))
+				Expect(status.Active[0].Name).To(Equal(scheduledName()))
+			})
+		})
+	})
+
+	Context("With a console claiming to belong to the schedule", func() {
+		BeforeEach(func() {
+			schedule.Spec.ConcurrencyPolicy = workloadsv1alpha1.ForbidConcurrent
+			objects = append(objects, ownedConsole("impostor", workloadsv1alpha1.ConsoleRunning, now.Add(-time.Hour)))
+		})
+
+		It("Doesn't consider it active", func() {
+			Expect(getConsoles()).To(ConsistOf("impostor", scheduledName()))
+		})
+	})
+
+	Context("With finished consoles beyond the history limits", func() {
+		BeforeEach(func() {
+			successfulLimit, failedLimit := int32(2), int32(1)
+			schedule.Spec.SuccessfulHistoryLimit = &successfulLimit
+			schedule.Spec.FailedHistoryLimit = &failedLimit
+
+			completionTime := metav1.NewTime(now.Add(-time.Hour))
+			for idx := 1; idx <= 3; idx++ {
+				succeeded := ownedConsole(fmt.Sprintf("succeeded-%d", idx), workloadsv1alpha1.ConsoleStopped, now.Add(time.Duration(idx-10)*time.Hour))
+				succeeded.Status.CompletionTime = &completionTime
+
+				failed := ownedConsole(fmt.Sprintf("failed-%d", idx), workloadsv1alpha1.ConsoleStopped, now.Add(time.Duration(idx-10)*time.Hour))
+
+				objects = append(objects, succeeded, failed)
+			}
+		})
+
+		It("Deletes the oldest of each", func() {
+			Expect(getConsoles()).To(ConsistOf("succeeded-2", "succeeded-3", "failed-3", scheduledName()))
+		})
+	})
+
+	Context("With a template that requires authorisation", func() {
+		var standing *workloadsv1alpha1.ConsoleAuthorisation
+
+		BeforeEach(func() {
+			template.Spec.DefaultAuthorisationRule = &workloadsv1alpha1.ConsoleAuthorisers{
+				AuthorisationsRequired: 1,
+				Subjects:               []rbacv1.Subject{{Kind: "User", Name: "bob@example.com"}},
+			}
+		})
+
+		It("Creates a standing authorisation, and skips the console until it's authorised", func() {
+			standing := &workloadsv1alpha1.ConsoleAuthorisation{}
+			Expect(kube.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: "nightly-schedule"}, standing)).To(Succeed())
+			Expect(standing.Spec.Authorisations).To(BeEmpty())
+
+			Expect(getConsoles()).To(BeEmpty())
+
+			status := getSchedule().Status
+			Expect(status.Authorised).To(BeFalse())
+			Expect(status.LastScheduleTime.Time).To(BeTemporally("==", scheduledTime()))
+		})
+
+		Context("Once authorised", func() {
+			BeforeEach(func() {
+				standing = &workloadsv1alpha1.ConsoleAuthorisation{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "nightly-schedule",
+						Namespace:   namespace,
+						Annotations: map[string]string{ScheduleHashAnnotation: scheduleHash(schedule, []string{"rake", "nightly"})},
+					},
+					Spec: workloadsv1alpha1.ConsoleAuthorisationSpec{
+						ConsoleScheduleRef: &corev1.LocalObjectReference{Name: "nightly"},
+						Authorisations:     []rbacv1.Subject{{Kind: "User", Name: "bob@example.com"}},
+					},
+				}
+				objects = append(objects, standing)
+
+				schedule.Spec.ConcurrencyPolicy = workloadsv1alpha1.AllowConcurrent
+				active(ownedConsole("nightly-pending", workloadsv1alpha1.ConsolePendingAuthorisation, now.Add(-time.Hour)))
+			})
+
+			It("Creates the console", func() {
+				Expect(getConsoles()).To(ConsistOf("nightly-pending", scheduledName()))
+				Expect(getSchedule().Status.Authorised).To(BeTrue())
+			})
+
+			It("Copies the standing authorisation to active consoles", func() {
+				authorisation := &workloadsv1alpha1.ConsoleAuthorisation{}
+				Expect(kube.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: "nightly-pending"}, authorisation)).To(Succeed())
+
+				Expect(authorisation.Labels).To(HaveKeyWithValue(workloadsv1alpha1.ScheduledAuthorisationLabel, "schedule-uid"))
+				Expect(authorisation.Spec.ConsoleRef.Name).To(Equal("nightly-pending"))
+				Expect(authorisation.Spec.Authorisations).To(Equal(standing.Spec.Authorisations))
+			})
+
+			Context("When the schedule's command changes", func() {
+				BeforeEach(func() {
+					schedule.Spec.Command = []string{"rake", "weekly"}
+				})
+
+				It("Resets the standing authorisation", func() {
+					reset := &workloadsv1alpha1.ConsoleAuthorisation{}
+					Expect(kube.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: "nightly-schedule"}, reset)).To(Succeed())
+					Expect(reset.Spec.Authorisations).To(BeEmpty())
+					Expect(getSchedule().Status.Authorised).To(BeFalse())
+				})
+			})
+		})
+	})
+})
+
+var _ = Describe("buildConsole", func() {
+	It("Names consoles after the schedule and the minute they were due", func() {
+		schedule := &workloadsv1alpha1.ConsoleSchedule{ObjectMeta: metav1.ObjectMeta{Name: "nightly"}}
+		scheduled := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+		Expect(buildConsole(schedule, scheduled).Name).To(Equal(fmt.Sprintf("nightly-%d", scheduled.Unix()/60)))
+	})
+
+	It("Truncates long schedule names", func() {
+		schedule := &workloadsv1alpha1.ConsoleSchedule{ObjectMeta: metav1.ObjectMeta{Name: strings.Repeat("a", 60)}}
+		csl := buildConsole(schedule, time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC))
+
+		Expect(csl.Name).To(HavePrefix(strings.Repeat("a", 44) + "-"))
+		Expect(csl.Labels[ConsoleScheduleLabel]).To(Equal(strings.Repeat("a", 60)))
+	})
+})
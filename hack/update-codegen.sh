@@ -0,0 +1,38 @@
+#!/usr/bin/env bash
+# Regenerates the typed clientset, listers and informers in pkg/client from the
+# types in apis/. Requires client-gen, lister-gen and informer-gen from
+# k8s.io/code-generator to be on the PATH: see `make code-generator`.
+
+set -euo pipefail
+
+MODULE="github.com/gocardless/theatre/v2"
+APIS="${MODULE}/apis/rbac/v1alpha1,${MODULE}/apis/workloads/v1alpha1"
+OUTPUT_BASE="$(mktemp -d)"
+trap 'rm -rf "${OUTPUT_BASE}"' EXIT
+
+cd "$(dirname "$0")/.."
+
+client-gen \
+  --go-header-file /dev/null \
+  --output-base "${OUTPUT_BASE}" \
+  --input-base "" \
+  --input "${APIS}" \
+  --clientset-name versioned \
+  --output-package "${MODULE}/pkg/client/clientset"
+
+lister-gen \
+  --go-header-file /dev/null \
+  --output-base "${OUTPUT_BASE}" \
+  --input-dirs "${APIS}" \
+  --output-package "${MODULE}/pkg/client/listers"
+
+informer-gen \
+  --go-header-file /dev/null \
+  --output-base "${OUTPUT_BASE}" \
+  --input-dirs "${APIS}" \
+  --versioned-clientset-package "${MODULE}/pkg/client/clientset/versioned" \
+  --listers-package "${MODULE}/pkg/client/listers" \
+  --output-package "${MODULE}/pkg/client/informers"
+
+rm -rf pkg/client
+cp -r "${OUTPUT_BASE}/${MODULE}/pkg/client" pkg/client
@@ -0,0 +1,162 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	v1alpha1 "github.com/gocardless/theatre/v2/apis/rbac/v1alpha1"
+	scheme "github.com/gocardless/theatre/v2/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// DirectoryRoleBindingsGetter has a method to return a DirectoryRoleBindingInterface.
+// A group's client should implement this interface.
+type DirectoryRoleBindingsGetter interface {
+	DirectoryRoleBindings(namespace string) DirectoryRoleBindingInterface
+}
+
+// DirectoryRoleBindingInterface has methods to work with DirectoryRoleBinding resources.
+type DirectoryRoleBindingInterface interface {
+	Create(ctx context.Context, directoryRoleBinding *v1alpha1.DirectoryRoleBinding, opts v1.CreateOptions) (*v1alpha1.DirectoryRoleBinding, error)
+	Update(ctx context.Context, directoryRoleBinding *v1alpha1.DirectoryRoleBinding, opts v1.UpdateOptions) (*v1alpha1.DirectoryRoleBinding, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1alpha1.DirectoryRoleBinding, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1alpha1.DirectoryRoleBindingList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.DirectoryRoleBinding, err error)
+	DirectoryRoleBindingExpansion
+}
+
+// directoryRoleBindings implements DirectoryRoleBindingInterface
+type directoryRoleBindings struct {
+	client rest.Interface
+	ns     string
+}
+
+// newDirectoryRoleBindings returns a DirectoryRoleBindings
+func newDirectoryRoleBindings(c *RbacV1alpha1Client, namespace string) *directoryRoleBindings {
+	return &directoryRoleBindings{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the directoryRoleBinding, and returns the corresponding directoryRoleBinding object, and an error if there is any.
+func (c *directoryRoleBindings) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.DirectoryRoleBinding, err error) {
+	result = &v1alpha1.DirectoryRoleBinding{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("directoryrolebindings").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of DirectoryRoleBindings that match those selectors.
+func (c *directoryRoleBindings) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.DirectoryRoleBindingList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.DirectoryRoleBindingList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("directoryrolebindings").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested directoryRoleBindings.
+func (c *directoryRoleBindings) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("directoryrolebindings").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a directoryRoleBinding and creates it.  Returns the server's representation of the directoryRoleBinding, and an error, if there is any.
+func (c *directoryRoleBindings) Create(ctx context.Context, directoryRoleBinding *v1alpha1.DirectoryRoleBinding, opts v1.CreateOptions) (result *v1alpha1.DirectoryRoleBinding, err error) {
+	result = &v1alpha1.DirectoryRoleBinding{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("directoryrolebindings").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(directoryRoleBinding).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a directoryRoleBinding and updates it. Returns the server's representation of the directoryRoleBinding, and an error, if there is any.
+func (c *directoryRoleBindings) Update(ctx context.Context, directoryRoleBinding *v1alpha1.DirectoryRoleBinding, opts v1.UpdateOptions) (result *v1alpha1.DirectoryRoleBinding, err error) {
+	result = &v1alpha1.DirectoryRoleBinding{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("directoryrolebindings").
+		Name(directoryRoleBinding.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(directoryRoleBinding).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the directoryRoleBinding and deletes it. Returns an error if one occurs.
+func (c *directoryRoleBindings) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("directoryrolebindings").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *directoryRoleBindings) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("directoryrolebindings").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched directoryRoleBinding.
+func (c *directoryRoleBindings) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.DirectoryRoleBinding, err error) {
+	result = &v1alpha1.DirectoryRoleBinding{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("directoryrolebindings").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
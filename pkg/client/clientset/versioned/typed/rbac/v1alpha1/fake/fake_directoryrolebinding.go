@@ -0,0 +1,114 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1alpha1 "github.com/gocardless/theatre/v2/apis/rbac/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeDirectoryRoleBindings implements DirectoryRoleBindingInterface
+type FakeDirectoryRoleBindings struct {
+	Fake *FakeRbacV1alpha1
+	ns   string
+}
+
+var directoryrolebindingsResource = schema.GroupVersionResource{Group: "rbac", Version: "v1alpha1", Resource: "directoryrolebindings"}
+
+var directoryrolebindingsKind = schema.GroupVersionKind{Group: "rbac", Version: "v1alpha1", Kind: "DirectoryRoleBinding"}
+
+// Get takes name of the directoryRoleBinding, and returns the corresponding directoryRoleBinding object, and an error if there is any.
+func (c *FakeDirectoryRoleBindings) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.DirectoryRoleBinding, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(directoryrolebindingsResource, c.ns, name), &v1alpha1.DirectoryRoleBinding{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.DirectoryRoleBinding), err
+}
+
+// List takes label and field selectors, and returns the list of DirectoryRoleBindings that match those selectors.
+func (c *FakeDirectoryRoleBindings) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.DirectoryRoleBindingList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(directoryrolebindingsResource, directoryrolebindingsKind, c.ns, opts), &v1alpha1.DirectoryRoleBindingList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.DirectoryRoleBindingList{ListMeta: obj.(*v1alpha1.DirectoryRoleBindingList).ListMeta}
+	for _, item := range obj.(*v1alpha1.DirectoryRoleBindingList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested directoryRoleBindings.
+func (c *FakeDirectoryRoleBindings) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(directoryrolebindingsResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a directoryRoleBinding and creates it.  Returns the server's representation of the directoryRoleBinding, and an error, if there is any.
+func (c *FakeDirectoryRoleBindings) Create(ctx context.Context, directoryRoleBinding *v1alpha1.DirectoryRoleBinding, opts v1.CreateOptions) (result *v1alpha1.DirectoryRoleBinding, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(directoryrolebindingsResource, c.ns, directoryRoleBinding), &v1alpha1.DirectoryRoleBinding{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.DirectoryRoleBinding), err
+}
+
+// Update takes the representation of a directoryRoleBinding and updates it. Returns the server's representation of the directoryRoleBinding, and an error, if there is any.
+func (c *FakeDirectoryRoleBindings) Update(ctx context.Context, directoryRoleBinding *v1alpha1.DirectoryRoleBinding, opts v1.UpdateOptions) (result *v1alpha1.DirectoryRoleBinding, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(directoryrolebindingsResource, c.ns, directoryRoleBinding), &v1alpha1.DirectoryRoleBinding{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.DirectoryRoleBinding), err
+}
+
+// Delete takes name of the directoryRoleBinding and deletes it. Returns an error if one occurs.
+func (c *FakeDirectoryRoleBindings) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(directoryrolebindingsResource, c.ns, name), &v1alpha1.DirectoryRoleBinding{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeDirectoryRoleBindings) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(directoryrolebindingsResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.DirectoryRoleBindingList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched directoryRoleBinding.
+func (c *FakeDirectoryRoleBindings) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.DirectoryRoleBinding, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(directoryrolebindingsResource, c.ns, name, pt, data, subresources...), &v1alpha1.DirectoryRoleBinding{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.DirectoryRoleBinding), err
+}
@@ -0,0 +1,24 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1alpha1 "github.com/gocardless/theatre/v2/pkg/client/clientset/versioned/typed/rbac/v1alpha1"
+	rest "k8s.io/client-go/rest"
+	testing "k8s.io/client-go/testing"
+)
+
+type FakeRbacV1alpha1 struct {
+	*testing.Fake
+}
+
+func (c *FakeRbacV1alpha1) DirectoryRoleBindings(namespace string) v1alpha1.DirectoryRoleBindingInterface {
+	return &FakeDirectoryRoleBindings{c, namespace}
+}
+
+// RESTClient returns a RESTClient that is used to communicate
+// with API server by this client implementation.
+func (c *FakeRbacV1alpha1) RESTClient() rest.Interface {
+	var ret *rest.RESTClient
+	return ret
+}
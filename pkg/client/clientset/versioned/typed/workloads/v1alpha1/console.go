@@ -0,0 +1,162 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	v1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	scheme "github.com/gocardless/theatre/v2/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// ConsolesGetter has a method to return a ConsoleInterface.
+// A group's client should implement this interface.
+type ConsolesGetter interface {
+	Consoles(namespace string) ConsoleInterface
+}
+
+// ConsoleInterface has methods to work with Console resources.
+type ConsoleInterface interface {
+	Create(ctx context.Context, console *v1alpha1.Console, opts v1.CreateOptions) (*v1alpha1.Console, error)
+	Update(ctx context.Context, console *v1alpha1.Console, opts v1.UpdateOptions) (*v1alpha1.Console, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1alpha1.Console, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1alpha1.ConsoleList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.Console, err error)
+	ConsoleExpansion
+}
+
+// consoles implements ConsoleInterface
+type consoles struct {
+	client rest.Interface
+	ns     string
+}
+
+// newConsoles returns a Consoles
+func newConsoles(c *WorkloadsV1alpha1Client, namespace string) *consoles {
+	return &consoles{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the console, and returns the corresponding console object, and an error if there is any.
+func (c *consoles) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.Console, err error) {
+	result = &v1alpha1.Console{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("consoles").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of Consoles that match those selectors.
+func (c *consoles) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.ConsoleList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.ConsoleList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("consoles").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested consoles.
+func (c *consoles) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("consoles").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a console and creates it.  Returns the server's representation of the console, and an error, if there is any.
+func (c *consoles) Create(ctx context.Context, console *v1alpha1.Console, opts v1.CreateOptions) (result *v1alpha1.Console, err error) {
+	result = &v1alpha1.Console{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("consoles").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(console).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a console and updates it. Returns the server's representation of the console, and an error, if there is any.
+func (c *consoles) Update(ctx context.Context, console *v1alpha1.Console, opts v1.UpdateOptions) (result *v1alpha1.Console, err error) {
+	result = &v1alpha1.Console{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("consoles").
+		Name(console.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(console).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the console and deletes it. Returns an error if one occurs.
+func (c *consoles) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("consoles").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *consoles) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("consoles").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched console.
+func (c *consoles) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.Console, err error) {
+	result = &v1alpha1.Console{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("consoles").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -0,0 +1,179 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	v1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	scheme "github.com/gocardless/theatre/v2/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// ConsoleSchedulesGetter has a method to return a ConsoleScheduleInterface.
+// A group's client should implement this interface.
+type ConsoleSchedulesGetter interface {
+	ConsoleSchedules(namespace string) ConsoleScheduleInterface
+}
+
+// ConsoleScheduleInterface has methods to work with ConsoleSchedule resources.
+type ConsoleScheduleInterface interface {
+	Create(ctx context.Context, consoleSchedule *v1alpha1.ConsoleSchedule, opts v1.CreateOptions) (*v1alpha1.ConsoleSchedule, error)
+	Update(ctx context.Context, consoleSchedule *v1alpha1.ConsoleSchedule, opts v1.UpdateOptions) (*v1alpha1.ConsoleSchedule, error)
+	UpdateStatus(ctx context.Context, consoleSchedule *v1alpha1.ConsoleSchedule, opts v1.UpdateOptions) (*v1alpha1.ConsoleSchedule, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1alpha1.ConsoleSchedule, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1alpha1.ConsoleScheduleList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.ConsoleSchedule, err error)
+	ConsoleScheduleExpansion
+}
+
+// consoleSchedules implements ConsoleScheduleInterface
+type consoleSchedules struct {
+	client rest.Interface
+	ns     string
+}
+
+// newConsoleSchedules returns a ConsoleSchedules
+func newConsoleSchedules(c *WorkloadsV1alpha1Client, namespace string) *consoleSchedules {
+	return &consoleSchedules{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the consoleSchedule, and returns the corresponding consoleSchedule object, and an error if there is any.
+func (c *consoleSchedules) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.ConsoleSchedule, err error) {
+	result = &v1alpha1.ConsoleSchedule{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("consoleschedules").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of ConsoleSchedules that match those selectors.
+func (c *consoleSchedules) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.ConsoleScheduleList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.ConsoleScheduleList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("consoleschedules").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested consoleSchedules.
+func (c *consoleSchedules) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("consoleschedules").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a consoleSchedule and creates it.  Returns the server's representation of the consoleSchedule, and an error, if there is any.
+func (c *consoleSchedules) Create(ctx context.Context, consoleSchedule *v1alpha1.ConsoleSchedule, opts v1.CreateOptions) (result *v1alpha1.ConsoleSchedule, err error) {
+	result = &v1alpha1.ConsoleSchedule{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("consoleschedules").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(consoleSchedule).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a consoleSchedule and updates it. Returns the server's representation of the consoleSchedule, and an error, if there is any.
+func (c *consoleSchedules) Update(ctx context.Context, consoleSchedule *v1alpha1.ConsoleSchedule, opts v1.UpdateOptions) (result *v1alpha1.ConsoleSchedule, err error) {
+	result = &v1alpha1.ConsoleSchedule{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("consoleschedules").
+		Name(consoleSchedule.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(consoleSchedule).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *consoleSchedules) UpdateStatus(ctx context.Context, consoleSchedule *v1alpha1.ConsoleSchedule, opts v1.UpdateOptions) (result *v1alpha1.ConsoleSchedule, err error) {
+	result = &v1alpha1.ConsoleSchedule{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("consoleschedules").
+		Name(consoleSchedule.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(consoleSchedule).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the consoleSchedule and deletes it. Returns an error if one occurs.
+func (c *consoleSchedules) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("consoleschedules").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *consoleSchedules) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("consoleschedules").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched consoleSchedule.
+func (c *consoleSchedules) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.ConsoleSchedule, err error) {
+	result = &v1alpha1.ConsoleSchedule{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("consoleschedules").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
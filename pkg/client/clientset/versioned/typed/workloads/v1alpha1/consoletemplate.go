@@ -0,0 +1,162 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	v1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	scheme "github.com/gocardless/theatre/v2/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// ConsoleTemplatesGetter has a method to return a ConsoleTemplateInterface.
+// A group's client should implement this interface.
+type ConsoleTemplatesGetter interface {
+	ConsoleTemplates(namespace string) ConsoleTemplateInterface
+}
+
+// ConsoleTemplateInterface has methods to work with ConsoleTemplate resources.
+type ConsoleTemplateInterface interface {
+	Create(ctx context.Context, consoleTemplate *v1alpha1.ConsoleTemplate, opts v1.CreateOptions) (*v1alpha1.ConsoleTemplate, error)
+	Update(ctx context.Context, consoleTemplate *v1alpha1.ConsoleTemplate, opts v1.UpdateOptions) (*v1alpha1.ConsoleTemplate, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1alpha1.ConsoleTemplate, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1alpha1.ConsoleTemplateList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.ConsoleTemplate, err error)
+	ConsoleTemplateExpansion
+}
+
+// consoleTemplates implements ConsoleTemplateInterface
+type consoleTemplates struct {
+	client rest.Interface
+	ns     string
+}
+
+// newConsoleTemplates returns a ConsoleTemplates
+func newConsoleTemplates(c *WorkloadsV1alpha1Client, namespace string) *consoleTemplates {
+	return &consoleTemplates{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the consoleTemplate, and returns the corresponding consoleTemplate object, and an error if there is any.
+func (c *consoleTemplates) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.ConsoleTemplate, err error) {
+	result = &v1alpha1.ConsoleTemplate{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("consoletemplates").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of ConsoleTemplates that match those selectors.
+func (c *consoleTemplates) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.ConsoleTemplateList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.ConsoleTemplateList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("consoletemplates").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested consoleTemplates.
+func (c *consoleTemplates) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("consoletemplates").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a consoleTemplate and creates it.  Returns the server's representation of the consoleTemplate, and an error, if there is any.
+func (c *consoleTemplates) Create(ctx context.Context, consoleTemplate *v1alpha1.ConsoleTemplate, opts v1.CreateOptions) (result *v1alpha1.ConsoleTemplate, err error) {
+	result = &v1alpha1.ConsoleTemplate{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("consoletemplates").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(consoleTemplate).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a consoleTemplate and updates it. Returns the server's representation of the consoleTemplate, and an error, if there is any.
+func (c *consoleTemplates) Update(ctx context.Context, consoleTemplate *v1alpha1.ConsoleTemplate, opts v1.UpdateOptions) (result *v1alpha1.ConsoleTemplate, err error) {
+	result = &v1alpha1.ConsoleTemplate{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("consoletemplates").
+		Name(consoleTemplate.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(consoleTemplate).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the consoleTemplate and deletes it. Returns an error if one occurs.
+func (c *consoleTemplates) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("consoletemplates").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *consoleTemplates) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("consoletemplates").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched consoleTemplate.
+func (c *consoleTemplates) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.ConsoleTemplate, err error) {
+	result = &v1alpha1.ConsoleTemplate{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("consoletemplates").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -0,0 +1,114 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeConsoleAuthorisations implements ConsoleAuthorisationInterface
+type FakeConsoleAuthorisations struct {
+	Fake *FakeWorkloadsV1alpha1
+	ns   string
+}
+
+var consoleauthorisationsResource = schema.GroupVersionResource{Group: "workloads", Version: "v1alpha1", Resource: "consoleauthorisations"}
+
+var consoleauthorisationsKind = schema.GroupVersionKind{Group: "workloads", Version: "v1alpha1", Kind: "ConsoleAuthorisation"}
+
+// Get takes name of the consoleAuthorisation, and returns the corresponding consoleAuthorisation object, and an error if there is any.
+func (c *FakeConsoleAuthorisations) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.ConsoleAuthorisation, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(consoleauthorisationsResource, c.ns, name), &v1alpha1.ConsoleAuthorisation{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ConsoleAuthorisation), err
+}
+
+// List takes label and field selectors, and returns the list of ConsoleAuthorisations that match those selectors.
+func (c *FakeConsoleAuthorisations) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.ConsoleAuthorisationList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(consoleauthorisationsResource, consoleauthorisationsKind, c.ns, opts), &v1alpha1.ConsoleAuthorisationList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.ConsoleAuthorisationList{ListMeta: obj.(*v1alpha1.ConsoleAuthorisationList).ListMeta}
+	for _, item := range obj.(*v1alpha1.ConsoleAuthorisationList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested consoleAuthorisations.
+func (c *FakeConsoleAuthorisations) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(consoleauthorisationsResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a consoleAuthorisation and creates it.  Returns the server's representation of the consoleAuthorisation, and an error, if there is any.
+func (c *FakeConsoleAuthorisations) Create(ctx context.Context, consoleAuthorisation *v1alpha1.ConsoleAuthorisation, opts v1.CreateOptions) (result *v1alpha1.ConsoleAuthorisation, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(consoleauthorisationsResource, c.ns, consoleAuthorisation), &v1alpha1.ConsoleAuthorisation{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ConsoleAuthorisation), err
+}
+
+// Update takes the representation of a consoleAuthorisation and updates it. Returns the server's representation of the consoleAuthorisation, and an error, if there is any.
+func (c *FakeConsoleAuthorisations) Update(ctx context.Context, consoleAuthorisation *v1alpha1.ConsoleAuthorisation, opts v1.UpdateOptions) (result *v1alpha1.ConsoleAuthorisation, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(consoleauthorisationsResource, c.ns, consoleAuthorisation), &v1alpha1.ConsoleAuthorisation{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ConsoleAuthorisation), err
+}
+
+// Delete takes name of the consoleAuthorisation and deletes it. Returns an error if one occurs.
+func (c *FakeConsoleAuthorisations) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(consoleauthorisationsResource, c.ns, name), &v1alpha1.ConsoleAuthorisation{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeConsoleAuthorisations) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(consoleauthorisationsResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.ConsoleAuthorisationList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched consoleAuthorisation.
+func (c *FakeConsoleAuthorisations) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.ConsoleAuthorisation, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(consoleauthorisationsResource, c.ns, name, pt, data, subresources...), &v1alpha1.ConsoleAuthorisation{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ConsoleAuthorisation), err
+}
@@ -0,0 +1,126 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeConsoleSchedules implements ConsoleScheduleInterface
+type FakeConsoleSchedules struct {
+	Fake *FakeWorkloadsV1alpha1
+	ns   string
+}
+
+var consoleschedulesResource = schema.GroupVersionResource{Group: "workloads", Version: "v1alpha1", Resource: "consoleschedules"}
+
+var consoleschedulesKind = schema.GroupVersionKind{Group: "workloads", Version: "v1alpha1", Kind: "ConsoleSchedule"}
+
+// Get takes name of the consoleSchedule, and returns the corresponding consoleSchedule object, and an error if there is any.
+func (c *FakeConsoleSchedules) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.ConsoleSchedule, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(consoleschedulesResource, c.ns, name), &v1alpha1.ConsoleSchedule{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ConsoleSchedule), err
+}
+
+// List takes label and field selectors, and returns the list of ConsoleSchedules that match those selectors.
+func (c *FakeConsoleSchedules) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.ConsoleScheduleList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(consoleschedulesResource, consoleschedulesKind, c.ns, opts), &v1alpha1.ConsoleScheduleList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.ConsoleScheduleList{ListMeta: obj.(*v1alpha1.ConsoleScheduleList).ListMeta}
+	for _, item := range obj.(*v1alpha1.ConsoleScheduleList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested consoleSchedules.
+func (c *FakeConsoleSchedules) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(consoleschedulesResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a consoleSchedule and creates it.  Returns the server's representation of the consoleSchedule, and an error, if there is any.
+func (c *FakeConsoleSchedules) Create(ctx context.Context, consoleSchedule *v1alpha1.ConsoleSchedule, opts v1.CreateOptions) (result *v1alpha1.ConsoleSchedule, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(consoleschedulesResource, c.ns, consoleSchedule), &v1alpha1.ConsoleSchedule{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ConsoleSchedule), err
+}
+
+// Update takes the representation of a consoleSchedule and updates it. Returns the server's representation of the consoleSchedule, and an error, if there is any.
+func (c *FakeConsoleSchedules) Update(ctx context.Context, consoleSchedule *v1alpha1.ConsoleSchedule, opts v1.UpdateOptions) (result *v1alpha1.ConsoleSchedule, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(consoleschedulesResource, c.ns, consoleSchedule), &v1alpha1.ConsoleSchedule{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ConsoleSchedule), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeConsoleSchedules) UpdateStatus(ctx context.Context, consoleSchedule *v1alpha1.ConsoleSchedule, opts v1.UpdateOptions) (*v1alpha1.ConsoleSchedule, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(consoleschedulesResource, "status", c.ns, consoleSchedule), &v1alpha1.ConsoleSchedule{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ConsoleSchedule), err
+}
+
+// Delete takes name of the consoleSchedule and deletes it. Returns an error if one occurs.
+func (c *FakeConsoleSchedules) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(consoleschedulesResource, c.ns, name), &v1alpha1.ConsoleSchedule{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeConsoleSchedules) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(consoleschedulesResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.ConsoleScheduleList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched consoleSchedule.
+func (c *FakeConsoleSchedules) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.ConsoleSchedule, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(consoleschedulesResource, c.ns, name, pt, data, subresources...), &v1alpha1.ConsoleSchedule{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ConsoleSchedule), err
+}
@@ -0,0 +1,36 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1alpha1 "github.com/gocardless/theatre/v2/pkg/client/clientset/versioned/typed/workloads/v1alpha1"
+	rest "k8s.io/client-go/rest"
+	testing "k8s.io/client-go/testing"
+)
+
+type FakeWorkloadsV1alpha1 struct {
+	*testing.Fake
+}
+
+func (c *FakeWorkloadsV1alpha1) Consoles(namespace string) v1alpha1.ConsoleInterface {
+	return &FakeConsoles{c, namespace}
+}
+
+func (c *FakeWorkloadsV1alpha1) ConsoleAuthorisations(namespace string) v1alpha1.ConsoleAuthorisationInterface {
+	return &FakeConsoleAuthorisations{c, namespace}
+}
+
+func (c *FakeWorkloadsV1alpha1) ConsoleSchedules(namespace string) v1alpha1.ConsoleScheduleInterface {
+	return &FakeConsoleSchedules{c, namespace}
+}
+
+func (c *FakeWorkloadsV1alpha1) ConsoleTemplates(namespace string) v1alpha1.ConsoleTemplateInterface {
+	return &FakeConsoleTemplates{c, namespace}
+}
+
+// RESTClient returns a RESTClient that is used to communicate
+// with API server by this client implementation.
+func (c *FakeWorkloadsV1alpha1) RESTClient() rest.Interface {
+	var ret *rest.RESTClient
+	return ret
+}
@@ -0,0 +1,11 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+type ConsoleExpansion interface{}
+
+type ConsoleAuthorisationExpansion interface{}
+
+type ConsoleScheduleExpansion interface{}
+
+type ConsoleTemplateExpansion interface{}
@@ -0,0 +1,88 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	"github.com/gocardless/theatre/v2/pkg/client/clientset/versioned/scheme"
+	rest "k8s.io/client-go/rest"
+)
+
+type WorkloadsV1alpha1Interface interface {
+	RESTClient() rest.Interface
+	ConsolesGetter
+	ConsoleAuthorisationsGetter
+	ConsoleSchedulesGetter
+	ConsoleTemplatesGetter
+}
+
+// WorkloadsV1alpha1Client is used to interact with features provided by the workloads group.
+type WorkloadsV1alpha1Client struct {
+	restClient rest.Interface
+}
+
+func (c *WorkloadsV1alpha1Client) Consoles(namespace string) ConsoleInterface {
+	return newConsoles(c, namespace)
+}
+
+func (c *WorkloadsV1alpha1Client) ConsoleAuthorisations(namespace string) ConsoleAuthorisationInterface {
+	return newConsoleAuthorisations(c, namespace)
+}
+
+func (c *WorkloadsV1alpha1Client) ConsoleSchedules(namespace string) ConsoleScheduleInterface {
+	return newConsoleSchedules(c, namespace)
+}
+
+func (c *WorkloadsV1alpha1Client) ConsoleTemplates(namespace string) ConsoleTemplateInterface {
+	return newConsoleTemplates(c, namespace)
+}
+
+// NewForConfig creates a new WorkloadsV1alpha1Client for the given config.
+func NewForConfig(c *rest.Config) (*WorkloadsV1alpha1Client, error) {
+	config := *c
+	if err := setConfigDefaults(&config); err != nil {
+		return nil, err
+	}
+	client, err := rest.RESTClientFor(&config)
+	if err != nil {
+		return nil, err
+	}
+	return &WorkloadsV1alpha1Client{client}, nil
+}
+
+// NewForConfigOrDie creates a new WorkloadsV1alpha1Client for the given config and
+// panics if there is an error in the config.
+func NewForConfigOrDie(c *rest.Config) *WorkloadsV1alpha1Client {
+	client, err := NewForConfig(c)
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// New creates a new WorkloadsV1alpha1Client for the given RESTClient.
+func New(c rest.Interface) *WorkloadsV1alpha1Client {
+	return &WorkloadsV1alpha1Client{c}
+}
+
+func setConfigDefaults(config *rest.Config) error {
+	gv := v1alpha1.SchemeGroupVersion
+	config.GroupVersion = &gv
+	config.APIPath = "/apis"
+	config.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
+
+	if config.UserAgent == "" {
+		config.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+
+	return nil
+}
+
+// RESTClient returns a RESTClient that is used to communicate
+// with API server by this client implementation.
+func (c *WorkloadsV1alpha1Client) RESTClient() rest.Interface {
+	if c == nil {
+		return nil
+	}
+	return c.restClient
+}
@@ -0,0 +1,57 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package externalversions
+
+import (
+	"fmt"
+
+	v1alpha1 "github.com/gocardless/theatre/v2/apis/rbac/v1alpha1"
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// GenericInformer is type of SharedIndexInformer which will locate and delegate to other
+// sharedInformers based on type
+type GenericInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() cache.GenericLister
+}
+
+type genericInformer struct {
+	informer cache.SharedIndexInformer
+	resource schema.GroupResource
+}
+
+// Informer returns the SharedIndexInformer.
+func (f *genericInformer) Informer() cache.SharedIndexInformer {
+	return f.informer
+}
+
+// Lister returns the GenericLister.
+func (f *genericInformer) Lister() cache.GenericLister {
+	return cache.NewGenericLister(f.Informer().GetIndexer(), f.resource)
+}
+
+// ForResource gives generic access to a shared informer of the matching type
+// TODO extend this to unknown resources with a client pool
+func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
+	switch resource {
+	// Group=rbac, Version=v1alpha1
+	case v1alpha1.SchemeGroupVersion.WithResource("directoryrolebindings"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Rbac().V1alpha1().DirectoryRoleBindings().Informer()}, nil
+
+		// Group=workloads, Version=v1alpha1
+	case workloadsv1alpha1.SchemeGroupVersion.WithResource("consoles"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Workloads().V1alpha1().Consoles().Informer()}, nil
+	case workloadsv1alpha1.SchemeGroupVersion.WithResource("consoleauthorisations"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Workloads().V1alpha1().ConsoleAuthorisations().Informer()}, nil
+	case workloadsv1alpha1.SchemeGroupVersion.WithResource("consoleschedules"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Workloads().V1alpha1().ConsoleSchedules().Informer()}, nil
+	case workloadsv1alpha1.SchemeGroupVersion.WithResource("consoletemplates"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Workloads().V1alpha1().ConsoleTemplates().Informer()}, nil
+
+	}
+
+	return nil, fmt.Errorf("no informer found for %v", resource)
+}
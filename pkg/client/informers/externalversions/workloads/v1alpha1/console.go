@@ -0,0 +1,74 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	time "time"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	versioned "github.com/gocardless/theatre/v2/pkg/client/clientset/versioned"
+	internalinterfaces "github.com/gocardless/theatre/v2/pkg/client/informers/externalversions/internalinterfaces"
+	v1alpha1 "github.com/gocardless/theatre/v2/pkg/client/listers/workloads/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// ConsoleInformer provides access to a shared informer and lister for
+// Consoles.
+type ConsoleInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1alpha1.ConsoleLister
+}
+
+type consoleInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewConsoleInformer constructs a new informer for Console type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewConsoleInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredConsoleInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredConsoleInformer constructs a new informer for Console type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredConsoleInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.WorkloadsV1alpha1().Consoles(namespace).List(context.TODO(), options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.WorkloadsV1alpha1().Consoles(namespace).Watch(context.TODO(), options)
+			},
+		},
+		&workloadsv1alpha1.Console{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *consoleInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredConsoleInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *consoleInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&workloadsv1alpha1.Console{}, f.defaultInformer)
+}
+
+func (f *consoleInformer) Lister() v1alpha1.ConsoleLister {
+	return v1alpha1.NewConsoleLister(f.Informer().GetIndexer())
+}
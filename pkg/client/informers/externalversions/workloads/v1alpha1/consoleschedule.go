@@ -0,0 +1,74 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	time "time"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	versioned "github.com/gocardless/theatre/v2/pkg/client/clientset/versioned"
+	internalinterfaces "github.com/gocardless/theatre/v2/pkg/client/informers/externalversions/internalinterfaces"
+	v1alpha1 "github.com/gocardless/theatre/v2/pkg/client/listers/workloads/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// ConsoleScheduleInformer provides access to a shared informer and lister for
+// ConsoleSchedules.
+type ConsoleScheduleInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1alpha1.ConsoleScheduleLister
+}
+
+type consoleScheduleInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewConsoleScheduleInformer constructs a new informer for ConsoleSchedule type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewConsoleScheduleInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredConsoleScheduleInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredConsoleScheduleInformer constructs a new informer for ConsoleSchedule type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredConsoleScheduleInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.WorkloadsV1alpha1().ConsoleSchedules(namespace).List(context.TODO(), options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.WorkloadsV1alpha1().ConsoleSchedules(namespace).Watch(context.TODO(), options)
+			},
+		},
+		&workloadsv1alpha1.ConsoleSchedule{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *consoleScheduleInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredConsoleScheduleInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *consoleScheduleInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&workloadsv1alpha1.ConsoleSchedule{}, f.defaultInformer)
+}
+
+func (f *consoleScheduleInformer) Lister() v1alpha1.ConsoleScheduleLister {
+	return v1alpha1.NewConsoleScheduleLister(f.Informer().GetIndexer())
+}
@@ -0,0 +1,74 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	time "time"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	versioned "github.com/gocardless/theatre/v2/pkg/client/clientset/versioned"
+	internalinterfaces "github.com/gocardless/theatre/v2/pkg/client/informers/externalversions/internalinterfaces"
+	v1alpha1 "github.com/gocardless/theatre/v2/pkg/client/listers/workloads/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// ConsoleTemplateInformer provides access to a shared informer and lister for
+// ConsoleTemplates.
+type ConsoleTemplateInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1alpha1.ConsoleTemplateLister
+}
+
+type consoleTemplateInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewConsoleTemplateInformer constructs a new informer for ConsoleTemplate type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewConsoleTemplateInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredConsoleTemplateInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredConsoleTemplateInformer constructs a new informer for ConsoleTemplate type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredConsoleTemplateInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.WorkloadsV1alpha1().ConsoleTemplates(namespace).List(context.TODO(), options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.WorkloadsV1alpha1().ConsoleTemplates(namespace).Watch(context.TODO(), options)
+			},
+		},
+		&workloadsv1alpha1.ConsoleTemplate{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *consoleTemplateInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredConsoleTemplateInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *consoleTemplateInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&workloadsv1alpha1.ConsoleTemplate{}, f.defaultInformer)
+}
+
+func (f *consoleTemplateInformer) Lister() v1alpha1.ConsoleTemplateLister {
+	return v1alpha1.NewConsoleTemplateLister(f.Informer().GetIndexer())
+}
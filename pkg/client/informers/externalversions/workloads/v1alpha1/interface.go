@@ -0,0 +1,50 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	internalinterfaces "github.com/gocardless/theatre/v2/pkg/client/informers/externalversions/internalinterfaces"
+)
+
+// Interface provides access to all the informers in this group version.
+type Interface interface {
+	// Consoles returns a ConsoleInformer.
+	Consoles() ConsoleInformer
+	// ConsoleAuthorisations returns a ConsoleAuthorisationInformer.
+	ConsoleAuthorisations() ConsoleAuthorisationInformer
+	// ConsoleSchedules returns a ConsoleScheduleInformer.
+	ConsoleSchedules() ConsoleScheduleInformer
+	// ConsoleTemplates returns a ConsoleTemplateInformer.
+	ConsoleTemplates() ConsoleTemplateInformer
+}
+
+type version struct {
+	factory          internalinterfaces.SharedInformerFactory
+	namespace        string
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// New returns a new Interface.
+func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakListOptions internalinterfaces.TweakListOptionsFunc) Interface {
+	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
+}
+
+// Consoles returns a ConsoleInformer.
+func (v *version) Consoles() ConsoleInformer {
+	return &consoleInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// ConsoleAuthorisations returns a ConsoleAuthorisationInformer.
+func (v *version) ConsoleAuthorisations() ConsoleAuthorisationInformer {
+	return &consoleAuthorisationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// ConsoleSchedules returns a ConsoleScheduleInformer.
+func (v *version) ConsoleSchedules() ConsoleScheduleInformer {
+	return &consoleScheduleInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// ConsoleTemplates returns a ConsoleTemplateInformer.
+func (v *version) ConsoleTemplates() ConsoleTemplateInformer {
+	return &consoleTemplateInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
@@ -0,0 +1,78 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/gocardless/theatre/v2/apis/rbac/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// DirectoryRoleBindingLister helps list DirectoryRoleBindings.
+type DirectoryRoleBindingLister interface {
+	// List lists all DirectoryRoleBindings in the indexer.
+	List(selector labels.Selector) (ret []*v1alpha1.DirectoryRoleBinding, err error)
+	// DirectoryRoleBindings returns an object that can list and get DirectoryRoleBindings.
+	DirectoryRoleBindings(namespace string) DirectoryRoleBindingNamespaceLister
+	DirectoryRoleBindingListerExpansion
+}
+
+// directoryRoleBindingLister implements the DirectoryRoleBindingLister interface.
+type directoryRoleBindingLister struct {
+	indexer cache.Indexer
+}
+
+// NewDirectoryRoleBindingLister returns a new DirectoryRoleBindingLister.
+func NewDirectoryRoleBindingLister(indexer cache.Indexer) DirectoryRoleBindingLister {
+	return &directoryRoleBindingLister{indexer: indexer}
+}
+
+// List lists all DirectoryRoleBindings in the indexer.
+func (s *directoryRoleBindingLister) List(selector labels.Selector) (ret []*v1alpha1.DirectoryRoleBinding, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.DirectoryRoleBinding))
+	})
+	return ret, err
+}
+
+// DirectoryRoleBindings returns an object that can list and get DirectoryRoleBindings.
+func (s *directoryRoleBindingLister) DirectoryRoleBindings(namespace string) DirectoryRoleBindingNamespaceLister {
+	return directoryRoleBindingNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// DirectoryRoleBindingNamespaceLister helps list and get DirectoryRoleBindings.
+type DirectoryRoleBindingNamespaceLister interface {
+	// List lists all DirectoryRoleBindings in the indexer for a given namespace.
+	List(selector labels.Selector) (ret []*v1alpha1.DirectoryRoleBinding, err error)
+	// Get retrieves the DirectoryRoleBinding from the indexer for a given namespace and name.
+	Get(name string) (*v1alpha1.DirectoryRoleBinding, error)
+	DirectoryRoleBindingNamespaceListerExpansion
+}
+
+// directoryRoleBindingNamespaceLister implements the DirectoryRoleBindingNamespaceLister
+// interface.
+type directoryRoleBindingNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all DirectoryRoleBindings in the indexer for a given namespace.
+func (s directoryRoleBindingNamespaceLister) List(selector labels.Selector) (ret []*v1alpha1.DirectoryRoleBinding, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.DirectoryRoleBinding))
+	})
+	return ret, err
+}
+
+// Get retrieves the DirectoryRoleBinding from the indexer for a given namespace and name.
+func (s directoryRoleBindingNamespaceLister) Get(name string) (*v1alpha1.DirectoryRoleBinding, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("directoryrolebinding"), name)
+	}
+	return obj.(*v1alpha1.DirectoryRoleBinding), nil
+}
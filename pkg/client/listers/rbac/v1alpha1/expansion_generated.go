@@ -0,0 +1,11 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+// DirectoryRoleBindingListerExpansion allows custom methods to be added to
+// DirectoryRoleBindingLister.
+type DirectoryRoleBindingListerExpansion interface{}
+
+// DirectoryRoleBindingNamespaceListerExpansion allows custom methods to be added to
+// DirectoryRoleBindingNamespaceLister.
+type DirectoryRoleBindingNamespaceListerExpansion interface{}
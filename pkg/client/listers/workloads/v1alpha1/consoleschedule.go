@@ -0,0 +1,78 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ConsoleScheduleLister helps list ConsoleSchedules.
+type ConsoleScheduleLister interface {
+	// List lists all ConsoleSchedules in the indexer.
+	List(selector labels.Selector) (ret []*v1alpha1.ConsoleSchedule, err error)
+	// ConsoleSchedules returns an object that can list and get ConsoleSchedules.
+	ConsoleSchedules(namespace string) ConsoleScheduleNamespaceLister
+	ConsoleScheduleListerExpansion
+}
+
+// consoleScheduleLister implements the ConsoleScheduleLister interface.
+type consoleScheduleLister struct {
+	indexer cache.Indexer
+}
+
+// NewConsoleScheduleLister returns a new ConsoleScheduleLister.
+func NewConsoleScheduleLister(indexer cache.Indexer) ConsoleScheduleLister {
+	return &consoleScheduleLister{indexer: indexer}
+}
+
+// List lists all ConsoleSchedules in the indexer.
+func (s *consoleScheduleLister) List(selector labels.Selector) (ret []*v1alpha1.ConsoleSchedule, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.ConsoleSchedule))
+	})
+	return ret, err
+}
+
+// ConsoleSchedules returns an object that can list and get ConsoleSchedules.
+func (s *consoleScheduleLister) ConsoleSchedules(namespace string) ConsoleScheduleNamespaceLister {
+	return consoleScheduleNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// ConsoleScheduleNamespaceLister helps list and get ConsoleSchedules.
+type ConsoleScheduleNamespaceLister interface {
+	// List lists all ConsoleSchedules in the indexer for a given namespace.
+	List(selector labels.Selector) (ret []*v1alpha1.ConsoleSchedule, err error)
+	// Get retrieves the ConsoleSchedule from the indexer for a given namespace and name.
+	Get(name string) (*v1alpha1.ConsoleSchedule, error)
+	ConsoleScheduleNamespaceListerExpansion
+}
+
+// consoleScheduleNamespaceLister implements the ConsoleScheduleNamespaceLister
+// interface.
+type consoleScheduleNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all ConsoleSchedules in the indexer for a given namespace.
+func (s consoleScheduleNamespaceLister) List(selector labels.Selector) (ret []*v1alpha1.ConsoleSchedule, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.ConsoleSchedule))
+	})
+	return ret, err
+}
+
+// Get retrieves the ConsoleSchedule from the indexer for a given namespace and name.
+func (s consoleScheduleNamespaceLister) Get(name string) (*v1alpha1.ConsoleSchedule, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("consoleschedule"), name)
+	}
+	return obj.(*v1alpha1.ConsoleSchedule), nil
+}
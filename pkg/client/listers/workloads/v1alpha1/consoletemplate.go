@@ -0,0 +1,78 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ConsoleTemplateLister helps list ConsoleTemplates.
+type ConsoleTemplateLister interface {
+	// List lists all ConsoleTemplates in the indexer.
+	List(selector labels.Selector) (ret []*v1alpha1.ConsoleTemplate, err error)
+	// ConsoleTemplates returns an object that can list and get ConsoleTemplates.
+	ConsoleTemplates(namespace string) ConsoleTemplateNamespaceLister
+	ConsoleTemplateListerExpansion
+}
+
+// consoleTemplateLister implements the ConsoleTemplateLister interface.
+type consoleTemplateLister struct {
+	indexer cache.Indexer
+}
+
+// NewConsoleTemplateLister returns a new ConsoleTemplateLister.
+func NewConsoleTemplateLister(indexer cache.Indexer) ConsoleTemplateLister {
+	return &consoleTemplateLister{indexer: indexer}
+}
+
+// List lists all ConsoleTemplates in the indexer.
+func (s *consoleTemplateLister) List(selector labels.Selector) (ret []*v1alpha1.ConsoleTemplate, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.ConsoleTemplate))
+	})
+	return ret, err
+}
+
+// ConsoleTemplates returns an object that can list and get ConsoleTemplates.
+func (s *consoleTemplateLister) ConsoleTemplates(namespace string) ConsoleTemplateNamespaceLister {
+	return consoleTemplateNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// ConsoleTemplateNamespaceLister helps list and get ConsoleTemplates.
+type ConsoleTemplateNamespaceLister interface {
+	// List lists all ConsoleTemplates in the indexer for a given namespace.
+	List(selector labels.Selector) (ret []*v1alpha1.ConsoleTemplate, err error)
+	// Get retrieves the ConsoleTemplate from the indexer for a given namespace and name.
+	Get(name string) (*v1alpha1.ConsoleTemplate, error)
+	ConsoleTemplateNamespaceListerExpansion
+}
+
+// consoleTemplateNamespaceLister implements the ConsoleTemplateNamespaceLister
+// interface.
+type consoleTemplateNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all ConsoleTemplates in the indexer for a given namespace.
+func (s consoleTemplateNamespaceLister) List(selector labels.Selector) (ret []*v1alpha1.ConsoleTemplate, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.ConsoleTemplate))
+	})
+	return ret, err
+}
+
+// Get retrieves the ConsoleTemplate from the indexer for a given namespace and name.
+func (s consoleTemplateNamespaceLister) Get(name string) (*v1alpha1.ConsoleTemplate, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("consoletemplate"), name)
+	}
+	return obj.(*v1alpha1.ConsoleTemplate), nil
+}
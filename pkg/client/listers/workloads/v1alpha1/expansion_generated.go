@@ -0,0 +1,35 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+// ConsoleListerExpansion allows custom methods to be added to
+// ConsoleLister.
+type ConsoleListerExpansion interface{}
+
+// ConsoleNamespaceListerExpansion allows custom methods to be added to
+// ConsoleNamespaceLister.
+type ConsoleNamespaceListerExpansion interface{}
+
+// ConsoleAuthorisationListerExpansion allows custom methods to be added to
+// ConsoleAuthorisationLister.
+type ConsoleAuthorisationListerExpansion interface{}
+
+// ConsoleAuthorisationNamespaceListerExpansion allows custom methods to be added to
+// ConsoleAuthorisationNamespaceLister.
+type ConsoleAuthorisationNamespaceListerExpansion interface{}
+
+// ConsoleScheduleListerExpansion allows custom methods to be added to
+// ConsoleScheduleLister.
+type ConsoleScheduleListerExpansion interface{}
+
+// ConsoleScheduleNamespaceListerExpansion allows custom methods to be added to
+// ConsoleScheduleNamespaceLister.
+type ConsoleScheduleNamespaceListerExpansion interface{}
+
+// ConsoleTemplateListerExpansion allows custom methods to be added to
+// ConsoleTemplateLister.
+type ConsoleTemplateListerExpansion interface{}
+
+// ConsoleTemplateNamespaceListerExpansion allows custom methods to be added to
+// ConsoleTemplateNamespaceLister.
+type ConsoleTemplateNamespaceListerExpansion interface{}
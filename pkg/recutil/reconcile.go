@@ -8,11 +8,13 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
@@ -25,6 +27,7 @@ const (
 	EventNotFound     = "ReconcileNotFound"
 	EventStart        = "ReconcileStart"
 	EventSkipped      = "ReconcileSkipped"
+	EventFinalize     = "ReconcileFinalize"
 	EventRequeued     = "ReconcileRequeued"
 	EventError        = "ReconcileError"
 	EventComplete     = "ReconcileComplete"
@@ -49,10 +52,27 @@ func init() {
 // at the start of traditional reconciliation loops.
 type ObjectReconcileFunc func(logger logr.Logger, request reconcile.Request, obj runtime.Object) (reconcile.Result, error)
 
+// ObjectFinalizeFunc performs any clean up required for an object that is being
+// deleted. The object's finalizer is only removed, allowing the deletion to
+// complete, once this has succeeded.
+type ObjectFinalizeFunc func(logger logr.Logger, request reconcile.Request, obj runtime.Object) error
+
 // ResolveAndReconcile helps avoid boilerplate where you would normally attempt to fetch
 // your modified object at the start of a reconciliation loop, and instead calls an inner
 // reconciliation function with the already resolved object.
 func ResolveAndReconcile(ctx context.Context, logger logr.Logger, mgr manager.Manager, objType runtime.Object, inner ObjectReconcileFunc) reconcile.Reconciler {
+	return resolveAndReconcile(ctx, logger, mgr, objType, "", inner, nil)
+}
+
+// ResolveAndReconcileWithFinalizer behaves like ResolveAndReconcile, but also adds the
+// given finalizer to every object before it's reconciled. When an object with the
+// finalizer is deleted, the finalize function is called instead of the inner
+// reconciliation function, and the finalizer is removed once it succeeds.
+func ResolveAndReconcileWithFinalizer(ctx context.Context, logger logr.Logger, mgr manager.Manager, objType runtime.Object, finalizer string, inner ObjectReconcileFunc, finalize ObjectFinalizeFunc) reconcile.Reconciler {
+	return resolveAndReconcile(ctx, logger, mgr, objType, finalizer, inner, finalize)
+}
+
+func resolveAndReconcile(ctx context.Context, logger logr.Logger, mgr manager.Manager, objType runtime.Object, finalizer string, inner ObjectReconcileFunc, finalize ObjectFinalizeFunc) reconcile.Reconciler {
 	return reconcile.Func(func(request reconcile.Request) (res reconcile.Result, err error) {
 		logger := logger.WithValues("request", request)
 		logger.Info("Reconcile request start", "event", EventRequestStart)
@@ -98,19 +118,42 @@ func ResolveAndReconcile(ctx context.Context, logger logr.Logger, mgr manager.Ma
 		logger = logging.WithEventRecorder(logger, mgr.GetEventRecorderFor("theatre"), obj)
 		logger.Info("Starting reconciliation", "event", EventStart)
 
+		hasFinalizer := finalizer != "" && controllerutil.ContainsFinalizer(obj, finalizer)
+
 		// If the object is being deleted then don't attempt any further
 		// reconciliation, as this can lead to recreating child resources (which
 		// we'd expect to be eventually deleted via propagation) and getting stuck
 		// in an infinite loop, due to these resources now blocking the deletion of
 		// the parent.
-		// If we need to support finalizers in the future then this will need to be
-		// extended to call a function that performs the finalizer actions.
+		// The only exception is to finalize objects that have our finalizer, which
+		// are otherwise prevented from being deleted.
 		if !obj.GetDeletionTimestamp().IsZero() {
-			logger.Info("Skipping reconciliation due to deletion", "event", EventSkipped)
-			res = reconcile.Result{Requeue: false}
+			if !hasFinalizer {
+				logger.Info("Skipping reconciliation due to deletion", "event", EventSkipped)
+				res = reconcile.Result{Requeue: false}
+				return res, nil
+			}
+
+			logger.Info("Finalizing deleted object", "event", EventFinalize, "finalizer", finalizer)
+			if err := finalize(logger, request, obj); err != nil {
+				return res, errors.Wrap(err, "failed to finalize object")
+			}
+
+			controllerutil.RemoveFinalizer(obj, finalizer)
+			if err := mgr.GetClient().Update(ctx, obj); err != nil {
+				return res, errors.Wrap(err, "failed to remove finalizer")
+			}
+
 			return res, nil
 		}
 
+		if finalizer != "" && !hasFinalizer {
+			controllerutil.AddFinalizer(obj, finalizer)
+			if err := mgr.GetClient().Update(ctx, obj); err != nil {
+				return res, errors.Wrap(err, "failed to add finalizer")
+			}
+		}
+
 		return inner(logger, request, obj)
 	})
 }
@@ -212,3 +255,16 @@ func DirectoryRoleBindingDiff(expectedObj runtime.Object, existingObj runtime.Ob
 
 	return operation
 }
+
+// NetworkPolicyDiff is a DiffFunc for NetworkPolicies
+func NetworkPolicyDiff(expectedObj runtime.Object, existingObj runtime.Object) Outcome {
+	expected := expectedObj.(*networkingv1.NetworkPolicy)
+	existing := existingObj.(*networkingv1.NetworkPolicy)
+
+	if !reflect.DeepEqual(expected.Spec, existing.Spec) {
+		existing.Spec = expected.Spec
+		return Update
+	}
+
+	return None
+}
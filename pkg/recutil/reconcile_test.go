@@ -0,0 +1,81 @@
+package recutil
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var _ = Describe("NetworkPolicyDiff", func() {
+	var (
+		expected, existing *networkingv1.NetworkPolicy
+	)
+
+	newPolicy := func(ports ...int) *networkingv1.NetworkPolicy {
+		var egress []networkingv1.NetworkPolicyEgressRule
+		for _, port := range ports {
+			port, protocol := intstr.FromInt(port), corev1.ProtocolTCP
+			egress = append(egress, networkingv1.NetworkPolicyEgressRule{
+				Ports: []networkingv1.NetworkPolicyPort{{Port: &port, Protocol: &protocol}},
+			})
+		}
+
+		return &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "console", Namespace: "staging"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"console-name": "console"}},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+				Egress:      egress,
+			},
+		}
+	}
+
+	Context("When the policies match", func() {
+		BeforeEach(func() {
+			expected, existing = newPolicy(53), newPolicy(53)
+		})
+
+		It("Does nothing", func() {
+			Expect(NetworkPolicyDiff(expected, existing)).To(Equal(None))
+		})
+	})
+
+	Context("When only the metadata differs", func() {
+		BeforeEach(func() {
+			expected, existing = newPolicy(53), newPolicy(53)
+			existing.ResourceVersion = "42"
+			existing.Labels = map[string]string{"user": "alice"}
+		})
+
+		It("Does nothing", func() {
+			Expect(NetworkPolicyDiff(expected, existing)).To(Equal(None))
+		})
+	})
+
+	Context("When the egress rules differ", func() {
+		BeforeEach(func() {
+			expected, existing = newPolicy(53, 5432), newPolicy(53)
+			existing.ResourceVersion = "42"
+		})
+
+		It("Updates the spec of the existing policy", func() {
+			Expect(NetworkPolicyDiff(expected, existing)).To(Equal(Update))
+			Expect(existing.Spec).To(Equal(expected.Spec))
+			Expect(existing.ResourceVersion).To(Equal("42"))
+		})
+	})
+
+	Context("When the expected policy denies all egress", func() {
+		BeforeEach(func() {
+			expected, existing = newPolicy(), newPolicy(53)
+		})
+
+		It("Removes the existing egress rules", func() {
+			Expect(NetworkPolicyDiff(expected, existing)).To(Equal(Update))
+			Expect(existing.Spec.Egress).To(BeEmpty())
+		})
+	})
+})
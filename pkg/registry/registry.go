@@ -0,0 +1,461 @@
+// Package registry reads the configuration of container images from registries that
+// implement the Docker Registry HTTP API V2, such as Docker Hub, GCR and ECR. Only the
+// metadata of images is read, never their layers.
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	dockerHubRegistry = "registry-1.docker.io"
+
+	mediaTypeManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeOCIManifest  = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex     = "application/vnd.oci.image.index.v1+json"
+
+	// maxResponseSize limits how much of a response we read, as manifests and image
+	// configuration are small, and anything larger is unlikely to be either
+	maxResponseSize = 10 << 20
+)
+
+// ImageConfig is the part of an image's configuration that decides what containers
+// of it run, when they don't set a command
+type ImageConfig struct {
+	Entrypoint []string `json:"Entrypoint"`
+	Cmd        []string `json:"Cmd"`
+}
+
+// Client reads image configuration from registries
+type Client struct {
+	// HTTPClient makes requests to registries, which defaults to http.DefaultClient
+	HTTPClient *http.Client
+	// CredentialsFile is a Docker config file, whose auths are used to authenticate with
+	// registries. Registries without credentials are accessed anonymously.
+	CredentialsFile string
+	// CacheTTL is how long the configuration of an image is remembered for, keyed by its
+	// reference and the credentials used to read it. Nothing is cached if it's zero.
+	CacheTTL time.Duration
+	// OS and Architecture of the image to use from multi-platform images, defaulting to
+	// linux and amd64
+	OS           string
+	Architecture string
+
+	cacheMu sync.Mutex
+	cache   map[cacheKey]cacheEntry
+}
+
+type cacheKey struct {
+	ref         reference
+	credentials string
+}
+
+type cacheEntry struct {
+	config    ImageConfig
+	expiresAt time.Time
+}
+
+// ImageConfig returns the configuration of an image, given as it would be in a pod
+// spec, e.g. 'eu.gcr.io/project/app:v1' or 'alpine@sha256:...'. Registries are
+// authenticated with using the first of dockerConfigs, such as those of a pod's image
+// pull secrets, that has credentials for them, falling back to CredentialsFile.
+func (c *Client) ImageConfig(ctx context.Context, image string, dockerConfigs ...[]byte) (ImageConfig, error) {
+	ref, err := parseReference(image)
+	if err != nil {
+		return ImageConfig{}, err
+	}
+
+	credentials, err := c.credentials(ref.registry, dockerConfigs)
+	if err != nil {
+		return ImageConfig{}, err
+	}
+
+	key := cacheKey{ref: ref}
+	if credentials != nil {
+		key.credentials = credentials.String()
+	}
+
+	if config, ok := c.cached(key); ok {
+		return config, nil
+	}
+
+	config, err := c.fetchImageConfig(ctx, image, ref, credentials)
+	if err != nil {
+		return ImageConfig{}, err
+	}
+
+	c.store(key, config)
+	return config, nil
+}
+
+func (c *Client) cached(key cacheKey) (ImageConfig, bool) {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+
+	entry, ok := c.cache[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return ImageConfig{}, false
+	}
+
+	return entry.config, true
+}
+
+func (c *Client) store(key cacheKey, config ImageConfig) {
+	if c.CacheTTL <= 0 {
+		return
+	}
+
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+
+	now := time.Now()
+	if c.cache == nil {
+		c.cache = map[cacheKey]cacheEntry{}
+	}
+
+	// Drop expired entries as we go, so images that are no longer used don't
+	// accumulate
+	for existing, entry := range c.cache {
+		if now.After(entry.expiresAt) {
+			delete(c.cache, existing)
+		}
+	}
+
+	c.cache[key] = cacheEntry{config: config, expiresAt: now.Add(c.CacheTTL)}
+}
+
+func (c *Client) fetchImageConfig(ctx context.Context, image string, ref reference, credentials *url.Userinfo) (ImageConfig, error) {
+	s := &session{client: c, ctx: ctx, ref: ref, credentials: credentials}
+
+	var manifest struct {
+		Config struct {
+			Digest string `json:"digest"`
+		} `json:"config"`
+		Manifests []struct {
+			Digest   string `json:"digest"`
+			Platform struct {
+				OS           string `json:"os"`
+				Architecture string `json:"architecture"`
+			} `json:"platform"`
+		} `json:"manifests"`
+	}
+
+	accept := []string{mediaTypeManifest, mediaTypeManifestList, mediaTypeOCIManifest, mediaTypeOCIIndex}
+	if err := s.getJSON("manifests/"+ref.reference, accept, &manifest); err != nil {
+		return ImageConfig{}, err
+	}
+
+	// Multi-platform images list a manifest for each platform, which we choose between
+	if len(manifest.Manifests) > 0 {
+		digest := ""
+		for _, platformManifest := range manifest.Manifests {
+			if platformManifest.Platform.OS == c.os() && platformManifest.Platform.Architecture == c.architecture() {
+				digest = platformManifest.Digest
+				break
+			}
+		}
+
+		if digest == "" {
+			return ImageConfig{}, fmt.Errorf("image %s has no manifest for %s/%s", image, c.os(), c.architecture())
+		}
+
+		if err := s.getJSON("manifests/"+digest, accept, &manifest); err != nil {
+			return ImageConfig{}, err
+		}
+	}
+
+	if manifest.Config.Digest == "" {
+		return ImageConfig{}, fmt.Errorf("image %s has an unsupported manifest, with no config", image)
+	}
+
+	var config struct {
+		Config ImageConfig `json:"config"`
+	}
+	if err := s.getJSON("blobs/"+manifest.Config.Digest, nil, &config); err != nil {
+		return ImageConfig{}, err
+	}
+
+	return config.Config, nil
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+
+	return c.HTTPClient
+}
+
+func (c *Client) os() string {
+	if c.OS == "" {
+		return "linux"
+	}
+
+	return c.OS
+}
+
+func (c *Client) architecture() string {
+	if c.Architecture == "" {
+		return "amd64"
+	}
+
+	return c.Architecture
+}
+
+// reference is a parsed image name, of a repository within a registry, along with the
+// tag or digest of the image within that repository
+type reference struct {
+	registry   string
+	repository string
+	reference  string
+}
+
+func parseReference(image string) (reference, error) {
+	name, ref := image, "latest"
+	if idx := strings.Index(name, "@"); idx >= 0 {
+		name, ref = name[:idx], name[idx+1:]
+	}
+
+	// Tags follow the last colon, as long as it's after the registry, whose port would
+	// otherwise be mistaken for one. Images pinned to a digest ignore their tag.
+	if idx := strings.LastIndex(name, ":"); idx > strings.LastIndex(name, "/") {
+		if !strings.Contains(image, "@") {
+			ref = name[idx+1:]
+		}
+		name = name[:idx]
+	}
+
+	result := reference{registry: dockerHubRegistry, repository: name, reference: ref}
+	if parts := strings.SplitN(name, "/", 2); len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		result.registry, result.repository = parts[0], parts[1]
+	}
+
+	if result.registry == "docker.io" || result.registry == "index.docker.io" {
+		result.registry = dockerHubRegistry
+	}
+
+	// Official images on Docker Hub live in the library namespace
+	if result.registry == dockerHubRegistry && !strings.Contains(result.repository, "/") {
+		result.repository = "library/" + result.repository
+	}
+
+	if result.repository == "" || ref == "" {
+		return result, fmt.Errorf("invalid image reference %q", image)
+	}
+
+	return result, nil
+}
+
+// credentials returns the username and password for a registry from the given Docker
+// configs or our Docker config file, or nil if there are none
+func (c *Client) credentials(registry string, dockerConfigs [][]byte) (*url.Userinfo, error) {
+	// Configs that can't be parsed are skipped, as the kubelet would skip them when
+	// pulling the image
+	for _, dockerConfig := range dockerConfigs {
+		if credentials, err := credentialsFromConfig(dockerConfig, registry); err == nil && credentials != nil {
+			return credentials, nil
+		}
+	}
+
+	if c.CredentialsFile == "" {
+		return nil, nil
+	}
+
+	content, err := ioutil.ReadFile(c.CredentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry credentials: %w", err)
+	}
+
+	return credentialsFromConfig(content, registry)
+}
+
+// credentialsFromConfig returns the credentials for a registry from the auths of a
+// Docker config file, or nil if it has none
+func credentialsFromConfig(content []byte, registry string) (*url.Userinfo, error) {
+	var config struct {
+		Auths map[string]struct {
+			Auth     string `json:"auth"`
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse registry credentials: %w", err)
+	}
+
+	for server, auth := range config.Auths {
+		if normaliseRegistry(server) != registry {
+			continue
+		}
+
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("invalid auth for registry %s: %w", server, err)
+			}
+
+			split := strings.SplitN(string(decoded), ":", 2)
+			if len(split) != 2 {
+				return nil, fmt.Errorf("invalid auth for registry %s, which must be 'username:password'", server)
+			}
+
+			return url.UserPassword(split[0], split[1]), nil
+		}
+
+		return url.UserPassword(auth.Username, auth.Password), nil
+	}
+
+	return nil, nil
+}
+
+// normaliseRegistry turns the server of a Docker config auth, which may be a URL such
+// as https://index.docker.io/v1/, into a registry host
+func normaliseRegistry(server string) string {
+	server = strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+	server = strings.SplitN(server, "/", 2)[0]
+	if server == "docker.io" || server == "index.docker.io" {
+		return dockerHubRegistry
+	}
+
+	return server
+}
+
+// session makes requests for an image, reusing any token obtained along the way
+type session struct {
+	client        *Client
+	ctx           context.Context
+	ref           reference
+	credentials   *url.Userinfo
+	authorization string
+}
+
+func (s *session) getJSON(path string, accept []string, v interface{}) error {
+	requestURL := fmt.Sprintf("https://%s/v2/%s/%s", s.ref.registry, s.ref.repository, path)
+
+	// Registries tell us how to authenticate by rejecting our first request, so we
+	// retry once having done what they ask
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(s.ctx, http.MethodGet, requestURL, nil)
+		if err != nil {
+			return err
+		}
+
+		for _, mediaType := range accept {
+			req.Header.Add("Accept", mediaType)
+		}
+
+		if s.authorization != "" {
+			req.Header.Set("Authorization", s.authorization)
+		}
+
+		resp, err := s.client.httpClient().Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			s.authorization, err = s.authorize(resp.Header.Get("WWW-Authenticate"))
+			if err != nil {
+				return fmt.Errorf("failed to authenticate with registry %s: %w", s.ref.registry, err)
+			}
+
+			continue
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("registry responded to GET %s with %s", requestURL, resp.Status)
+		}
+
+		if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v); err != nil {
+			return fmt.Errorf("failed to decode response to GET %s: %w", requestURL, err)
+		}
+
+		return nil
+	}
+}
+
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// authorize returns the Authorization header that satisfies a WWW-Authenticate
+// challenge, obtaining a token to pull the repository if the registry asks for one
+func (s *session) authorize(challenge string) (string, error) {
+	split := strings.SplitN(challenge, " ", 2)
+	params := map[string]string{}
+	if len(split) == 2 {
+		for _, match := range challengeParam.FindAllStringSubmatch(split[1], -1) {
+			params[match[1]] = match[2]
+		}
+	}
+
+	switch strings.ToLower(split[0]) {
+	case "basic":
+		if s.credentials == nil {
+			return "", fmt.Errorf("registry requires credentials, but none were given")
+		}
+
+		password, _ := s.credentials.Password()
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(s.credentials.Username()+":"+password)), nil
+
+	case "bearer":
+		realm, err := url.Parse(params["realm"])
+		if err != nil || params["realm"] == "" {
+			return "", fmt.Errorf("invalid token realm %q", params["realm"])
+		}
+
+		query := realm.Query()
+		query.Set("scope", fmt.Sprintf("repository:%s:pull", s.ref.repository))
+		if service, ok := params["service"]; ok {
+			query.Set("service", service)
+		}
+		realm.RawQuery = query.Encode()
+
+		req, err := http.NewRequestWithContext(s.ctx, http.MethodGet, realm.String(), nil)
+		if err != nil {
+			return "", err
+		}
+
+		if s.credentials != nil {
+			password, _ := s.credentials.Password()
+			req.SetBasicAuth(s.credentials.Username(), password)
+		}
+
+		resp, err := s.client.httpClient().Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("token server responded with %s", resp.Status)
+		}
+
+		var token struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&token); err != nil {
+			return "", fmt.Errorf("failed to decode token: %w", err)
+		}
+
+		if token.Token == "" {
+			token.Token = token.AccessToken
+		}
+
+		return "Bearer " + token.Token, nil
+	}
+
+	return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+}
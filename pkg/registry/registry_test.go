@@ -0,0 +1,265 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeRegistry serves the manifests and config of app images, requiring a token that
+// is obtained from its token endpoint with the credentials admin:hunter2
+type fakeRegistry struct {
+	url       string
+	manifests map[string]interface{}
+	blobs     map[string]interface{}
+	scopes    []string
+	requests  int
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests++
+	if r.URL.Path == "/token" {
+		if username, password, ok := r.BasicAuth(); !ok || username != "admin" || password != "hunter2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		f.scopes = append(f.scopes, r.URL.Query().Get("scope"))
+		json.NewEncoder(w).Encode(map[string]string{"token": "pull-token"})
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer pull-token" {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake"`, f.url))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var body interface{}
+	switch path := strings.TrimPrefix(r.URL.Path, "/v2/team/app/"); {
+	case strings.HasPrefix(path, "manifests/"):
+		body = f.manifests[strings.TrimPrefix(path, "manifests/")]
+	case strings.HasPrefix(path, "blobs/"):
+		body = f.blobs[strings.TrimPrefix(path, "blobs/")]
+	}
+
+	if body == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(body)
+}
+
+var _ = Describe("Client", func() {
+	var (
+		registry *fakeRegistry
+		server   *httptest.Server
+		client   *Client
+		dir      string
+		host     string
+		config   ImageConfig
+		err      error
+		image    string
+
+		dockerConfigs [][]byte
+	)
+
+	BeforeEach(func() {
+		registry = &fakeRegistry{
+			manifests: map[string]interface{}{
+				"v1": map[string]interface{}{
+					"mediaType": mediaTypeManifest,
+					"config":    map[string]interface{}{"digest": "sha256:config"},
+				},
+				"multi": map[string]interface{}{
+					"mediaType": mediaTypeManifestList,
+					"manifests": []interface{}{
+						map[string]interface{}{
+							"digest":   "sha256:arm64",
+							"platform": map[string]interface{}{"os": "linux", "architecture": "arm64"},
+						},
+						map[string]interface{}{
+							"digest":   "sha256:amd64",
+							"platform": map[string]interface{}{"os": "linux", "architecture": "amd64"},
+						},
+					},
+				},
+				"sha256:amd64": map[string]interface{}{
+					"mediaType": mediaTypeManifest,
+					"config":    map[string]interface{}{"digest": "sha256:amd64-config"},
+				},
+			},
+			blobs: map[string]interface{}{
+				"sha256:config": map[string]interface{}{
+					"config": map[string]interface{}{"Entrypoint": []string{"/app"}, "Cmd": []string{"serve"}},
+				},
+				"sha256:amd64-config": map[string]interface{}{
+					"config": map[string]interface{}{"Entrypoint": []string{"/app-amd64"}},
+				},
+			},
+		}
+		server = httptest.NewTLSServer(registry)
+		registry.url = server.URL
+		host = strings.TrimPrefix(server.URL, "https://")
+
+		dir, err = ioutil.TempDir("", "registry-")
+		Expect(err).NotTo(HaveOccurred())
+
+		credentials := filepath.Join(dir, "config.json")
+		Expect(ioutil.WriteFile(credentials, []byte(fmt.Sprintf(
+			`{"auths":{"https://%s":{"auth":"YWRtaW46aHVudGVyMg=="}}}`, host,
+		)), 0600)).To(Succeed())
+
+		client = &Client{HTTPClient: server.Client(), CredentialsFile: credentials}
+		dockerConfigs = nil
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	JustBeforeEach(func() {
+		config, err = client.ImageConfig(context.Background(), image, dockerConfigs...)
+	})
+
+	Context("With a single platform image", func() {
+		BeforeEach(func() {
+			image = host + "/team/app:v1"
+		})
+
+		It("Returns the image config, authenticating with a token", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config).To(Equal(ImageConfig{Entrypoint: []string{"/app"}, Cmd: []string{"serve"}}))
+			Expect(registry.scopes).To(Equal([]string{"repository:team/app:pull"}))
+		})
+	})
+
+	Context("With a multi-platform image", func() {
+		BeforeEach(func() {
+			image = host + "/team/app:multi"
+		})
+
+		It("Returns the config of the linux/amd64 image", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Entrypoint).To(Equal([]string{"/app-amd64"}))
+		})
+	})
+
+	Context("With an image that doesn't exist", func() {
+		BeforeEach(func() {
+			image = host + "/team/app:v2"
+		})
+
+		It("Returns an error", func() {
+			Expect(err).To(MatchError(ContainSubstring("404 Not Found")))
+		})
+	})
+
+	Context("Without credentials", func() {
+		BeforeEach(func() {
+			image = host + "/team/app:v1"
+			client.CredentialsFile = ""
+		})
+
+		It("Returns an error", func() {
+			Expect(err).To(MatchError(ContainSubstring("token server responded with 401 Unauthorized")))
+		})
+	})
+
+	Context("With credentials from a pull secret", func() {
+		BeforeEach(func() {
+			image = host + "/team/app:v1"
+			client.CredentialsFile = ""
+			dockerConfigs = [][]byte{
+				[]byte("not json"),
+				[]byte(`{"auths":{"other.example.com":{"auth":"b3RoZXI6b3RoZXI="}}}`),
+				[]byte(fmt.Sprintf(`{"auths":{"%s":{"username":"admin","password":"hunter2"}}}`, host)),
+			}
+		})
+
+		It("Uses the credentials for the registry", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Entrypoint).To(Equal([]string{"/app"}))
+		})
+	})
+
+	Context("With a cache", func() {
+		BeforeEach(func() {
+			image = host + "/team/app:v1"
+			client.CacheTTL = time.Minute
+		})
+
+		It("Doesn't contact the registry again for the same image", func() {
+			Expect(err).NotTo(HaveOccurred())
+			requests := registry.requests
+
+			again, err := client.ImageConfig(context.Background(), image)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(again).To(Equal(config))
+			Expect(registry.requests).To(Equal(requests))
+		})
+
+		It("Doesn't share images read with different credentials", func() {
+			Expect(err).NotTo(HaveOccurred())
+
+			client.CredentialsFile = ""
+			_, err := client.ImageConfig(context.Background(), image)
+			Expect(err).To(MatchError(ContainSubstring("token server responded with 401 Unauthorized")))
+		})
+
+		Context("Once entries have expired", func() {
+			BeforeEach(func() {
+				client.CacheTTL = time.Nanosecond
+			})
+
+			It("Contacts the registry again", func() {
+				Expect(err).NotTo(HaveOccurred())
+				requests := registry.requests
+
+				_, err := client.ImageConfig(context.Background(), image)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(registry.requests).To(Equal(requests * 2))
+			})
+		})
+	})
+})
+
+var _ = Describe("parseReference", func() {
+	expectReference := func(image string, expected reference) {
+		ref, err := parseReference(image)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ref).To(Equal(expected))
+	}
+
+	It("Parses official Docker Hub images", func() {
+		expectReference("alpine", reference{"registry-1.docker.io", "library/alpine", "latest"})
+	})
+
+	It("Parses Docker Hub images with a tag", func() {
+		expectReference("docker.io/gocardless/theatre:v2", reference{"registry-1.docker.io", "gocardless/theatre", "v2"})
+	})
+
+	It("Parses images in other registries", func() {
+		expectReference("eu.gcr.io/project/app:v1", reference{"eu.gcr.io", "project/app", "v1"})
+	})
+
+	It("Parses registries with ports and no tag", func() {
+		expectReference("localhost:5000/app", reference{"localhost:5000", "app", "latest"})
+	})
+
+	It("Prefers digests to tags", func() {
+		expectReference("eu.gcr.io/project/app:v1@sha256:abc", reference{"eu.gcr.io", "project/app", "sha256:abc"})
+	})
+})
@@ -0,0 +1,177 @@
+package vault
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+)
+
+const (
+	// AuthMethodKubernetes exchanges a Kubernetes service account token for a Vault
+	// token, using the kubernetes auth method
+	AuthMethodKubernetes = "kubernetes"
+	// AuthMethodJWT exchanges a JWT, such as a projected service account token, for
+	// a Vault token, using the jwt auth method
+	AuthMethodJWT = "jwt"
+	// AuthMethodAppRole exchanges a role ID and secret ID for a Vault token, using
+	// the approle auth method
+	AuthMethodAppRole = "approle"
+	// AuthMethodTokenFile reads a Vault token from a file, such as one written by
+	// Vault agent
+	AuthMethodTokenFile = "token-file"
+)
+
+// AuthMethods are the names of every supported way of authenticating with Vault
+var AuthMethods = []string{AuthMethodKubernetes, AuthMethodJWT, AuthMethodAppRole, AuthMethodTokenFile}
+
+// ValidateAuthMethod returns an error if the name isn't a supported auth method
+func ValidateAuthMethod(name string) error {
+	for _, method := range AuthMethods {
+		if name == method {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid auth method %q, must be one of %s", name, strings.Join(AuthMethods, ", "))
+}
+
+// AuthMethod is a way of obtaining a Vault token. Login is called again whenever
+// the token can no longer be renewed, so credentials should be read each time
+// rather than once.
+type AuthMethod interface {
+	// Login returns a new Vault token
+	Login(client *api.Client) (string, error)
+}
+
+// KubernetesAuth logs in with a Kubernetes service account token, which Vault
+// validates with the TokenReview API
+type KubernetesAuth struct {
+	// MountPath of the auth method, which defaults to kubernetes
+	MountPath string
+	// Role to be assigned the token
+	Role string
+	// ServiceAccountToken returns the token to exchange for a Vault token
+	ServiceAccountToken func() (string, error)
+}
+
+func (a KubernetesAuth) Login(client *api.Client) (string, error) {
+	jwt, err := a.ServiceAccountToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to read service account token: %w", err)
+	}
+
+	return login(client, a.MountPath, AuthMethodKubernetes, map[string]interface{}{
+		"jwt":  jwt,
+		"role": a.Role,
+	})
+}
+
+// JWTAuth logs in with a JWT read from a file, which Vault validates against the
+// keys or OIDC discovery URL of the auth method
+type JWTAuth struct {
+	// MountPath of the auth method, which defaults to jwt
+	MountPath string
+	// Role to be assigned the token
+	Role string
+	// TokenFile contains the JWT, which is read on every login so that it can be
+	// rotated
+	TokenFile string
+}
+
+func (a JWTAuth) Login(client *api.Client) (string, error) {
+	jwt, err := readCredential(a.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read JWT: %w", err)
+	}
+
+	return login(client, a.MountPath, AuthMethodJWT, map[string]interface{}{
+		"jwt":  jwt,
+		"role": a.Role,
+	})
+}
+
+// AppRoleAuth logs in with the role ID of an AppRole, along with a secret ID read
+// from a file
+type AppRoleAuth struct {
+	// MountPath of the auth method, which defaults to approle
+	MountPath string
+	// RoleID of the AppRole
+	RoleID string
+	// SecretIDFile contains the secret ID, which is read on every login. Roles
+	// that don't require secret IDs can leave this empty.
+	SecretIDFile string
+}
+
+func (a AppRoleAuth) Login(client *api.Client) (string, error) {
+	if a.RoleID == "" {
+		return "", fmt.Errorf("no AppRole role ID given")
+	}
+
+	data := map[string]interface{}{"role_id": a.RoleID}
+	if a.SecretIDFile != "" {
+		secretID, err := readCredential(a.SecretIDFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read AppRole secret ID: %w", err)
+		}
+
+		data["secret_id"] = secretID
+	}
+
+	return login(client, a.MountPath, AuthMethodAppRole, data)
+}
+
+// TokenFileAuth reads a token that something else has already obtained, such as
+// Vault agent, from a file. The file is read again whenever the token can no
+// longer be renewed, in the hope that it has been replaced.
+type TokenFileAuth struct {
+	Path string
+}
+
+func (a TokenFileAuth) Login(_ *api.Client) (string, error) {
+	token, err := readCredential(a.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token: %w", err)
+	}
+
+	return token, nil
+}
+
+// login writes credentials to the login endpoint of an auth method, returning the
+// token it issues
+func login(client *api.Client, mountPath, defaultMountPath string, data map[string]interface{}) (string, error) {
+	if mountPath == "" {
+		mountPath = defaultMountPath
+	}
+
+	secret, err := client.Logical().Write(fmt.Sprintf("auth/%s/login", strings.Trim(mountPath, "/")), data)
+	if err != nil {
+		return "", err
+	}
+
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return "", fmt.Errorf("no token in response from auth/%s/login", mountPath)
+	}
+
+	return secret.Auth.ClientToken, nil
+}
+
+// readCredential reads a file, ignoring surrounding whitespace
+func readCredential(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("no file given")
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	credential := strings.TrimSpace(string(content))
+	if credential == "" {
+		return "", fmt.Errorf("%s is empty", path)
+	}
+
+	return credential, nil
+}
@@ -0,0 +1,140 @@
+package vault
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/vault/api"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AuthMethod", func() {
+	var (
+		vault  *fakeVault
+		server *httptest.Server
+		client *api.Client
+		dir    string
+		method AuthMethod
+		token  string
+		err    error
+	)
+
+	BeforeEach(func() {
+		vault = &fakeVault{reads: map[string]int{}}
+		server = httptest.NewServer(vault)
+
+		cfg := api.DefaultConfig()
+		cfg.Address = server.URL
+		client, err = api.NewClient(cfg)
+		Expect(err).NotTo(HaveOccurred())
+
+		dir, err = ioutil.TempDir("", "vault-")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	JustBeforeEach(func() {
+		token, err = method.Login(client)
+	})
+
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		Expect(ioutil.WriteFile(path, []byte(content), 0600)).To(Succeed())
+		return path
+	}
+
+	Context("KubernetesAuth", func() {
+		BeforeEach(func() {
+			method = KubernetesAuth{
+				Role:                "default",
+				ServiceAccountToken: func() (string, error) { return "service-account-token", nil },
+			}
+		})
+
+		It("logs in at the default mount path", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token).To(Equal("kubernetes-token"))
+			Expect(vault.logins["kubernetes"]).To(Equal(map[string]interface{}{
+				"jwt":  "service-account-token",
+				"role": "default",
+			}))
+		})
+	})
+
+	Context("JWTAuth", func() {
+		BeforeEach(func() {
+			method = JWTAuth{
+				MountPath: "jwt/cluster",
+				Role:      "app",
+				TokenFile: writeFile("jwt", "header.payload.signature\n"),
+			}
+		})
+
+		It("logs in with the JWT from the file", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token).To(Equal("jwt/cluster-token"))
+			Expect(vault.logins["jwt/cluster"]).To(Equal(map[string]interface{}{
+				"jwt":  "header.payload.signature",
+				"role": "app",
+			}))
+		})
+	})
+
+	Context("AppRoleAuth", func() {
+		BeforeEach(func() {
+			method = AppRoleAuth{
+				RoleID:       "role-id",
+				SecretIDFile: writeFile("secret-id", "secret-id"),
+			}
+		})
+
+		It("logs in with the role and secret IDs", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token).To(Equal("approle-token"))
+			Expect(vault.logins["approle"]).To(Equal(map[string]interface{}{
+				"role_id":   "role-id",
+				"secret_id": "secret-id",
+			}))
+		})
+
+		Context("without a role ID", func() {
+			BeforeEach(func() {
+				method = AppRoleAuth{}
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError("no AppRole role ID given"))
+			})
+		})
+	})
+
+	Context("TokenFileAuth", func() {
+		BeforeEach(func() {
+			method = TokenFileAuth{Path: writeFile("token", "s.token\n")}
+		})
+
+		It("returns the token from the file without logging in", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token).To(Equal("s.token"))
+			Expect(vault.logins).To(BeEmpty())
+		})
+
+		Context("when the file is empty", func() {
+			BeforeEach(func() {
+				method = TokenFileAuth{Path: writeFile("token", "")}
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("is empty")))
+			})
+		})
+	})
+})
@@ -0,0 +1,46 @@
+package vault
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// WriteOutput writes resolved environment variables to a directory, for processes
+// whose environment we can't set, such as containers whose secrets are resolved by
+// an init container. Each variable is written to a file named after it in the vars
+// directory, and all of them to an env file of shell assignments, which can be
+// loaded with 'set -a; . dir/env; set +a'.
+func WriteOutput(dir string, env map[string]string) error {
+	names := []string{}
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var envFile strings.Builder
+	for _, name := range names {
+		if name == "" || strings.ContainsAny(name, "/=") || name == "." || name == ".." {
+			return fmt.Errorf("can't write variable %q to a file", name)
+		}
+
+		if _, err := writeFileAtomic(filepath.Join(dir, "vars", name), []byte(env[name]), 0600, -1, -1); err != nil {
+			return fmt.Errorf("failed to write variable %s: %w", name, err)
+		}
+
+		fmt.Fprintf(&envFile, "%s=%s\n", name, shellQuote(env[name]))
+	}
+
+	if _, err := writeFileAtomic(filepath.Join(dir, "env"), []byte(envFile.String()), 0600, -1, -1); err != nil {
+		return fmt.Errorf("failed to write env file: %w", err)
+	}
+
+	return nil
+}
+
+// shellQuote wraps a value in single quotes, which leave everything but single quotes
+// uninterpreted
+func shellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
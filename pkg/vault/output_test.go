@@ -0,0 +1,63 @@
+package vault
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WriteOutput", func() {
+	var (
+		dir string
+		env map[string]string
+		err error
+	)
+
+	BeforeEach(func() {
+		dir, err = ioutil.TempDir("", "vault-")
+		Expect(err).NotTo(HaveOccurred())
+
+		env = map[string]string{
+			"PASSWORD": "it's $HOME",
+			"USERNAME": "admin",
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	JustBeforeEach(func() {
+		err = WriteOutput(dir, env)
+	})
+
+	It("writes each variable to a file", func() {
+		Expect(err).NotTo(HaveOccurred())
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, "vars", "PASSWORD"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal("it's $HOME"))
+	})
+
+	It("writes an env file that can be sourced by a shell", func() {
+		Expect(err).NotTo(HaveOccurred())
+
+		output, err := exec.Command("sh", "-c", `set -a; . "$1"; printf '%s %s' "$USERNAME" "$PASSWORD"`, "sh", filepath.Join(dir, "env")).Output()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(output)).To(Equal("admin it's $HOME"))
+	})
+
+	Context("with a variable that can't be a file name", func() {
+		BeforeEach(func() {
+			env["../PASSWORD"] = "hunter2"
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(MatchError(`can't write variable "../PASSWORD" to a file`))
+		})
+	})
+})
@@ -0,0 +1,148 @@
+package vault
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// SecretPrefix marks an environment variable whose value should be replaced
+	// by a Vault secret, e.g. 'vault:database/password'. Secrets with several keys
+	// must have the key given after a '#', e.g. 'vault:database#password', and
+	// secrets in KV v2 can be pinned to a version with '@', e.g.
+	// 'vault:database@3#password'
+	SecretPrefix = "vault:"
+	// SecretFilePrefix marks an environment variable whose Vault secret should be
+	// written to a file, with the variable set to the path of that file. The file
+	// path is optional, and a temporary file is used when it isn't given, e.g.
+	// 'vault-file:tls-key/2021010100' or
+	// 'vault-file:ssh-key/2021010100:/home/user/.ssh/id_rsa'
+	SecretFilePrefix = "vault-file:"
+	// DynamicSecretPrefix marks an environment variable whose value should be
+	// taken from a secret generated by a secrets engine role, such as database
+	// credentials, e.g. 'vault-dynamic:database/creds/app#password'. Unlike other
+	// references, the path is not relative to the path prefix. Each secret is
+	// generated once, so that several variables can take different fields of it,
+	// and its lease is held for as long as the process runs.
+	DynamicSecretPrefix = "vault-dynamic:"
+)
+
+// Reference is a pointer to a Vault secret, parsed from the value of an
+// environment variable
+type Reference struct {
+	// Path of the secret, relative to any path prefix
+	Path string
+	// Key of the secret's data to use, which can be empty if the secret has only
+	// one key
+	Key string
+	// Version of the secret to read, or zero for the latest version. Only secrets
+	// in KV v2 are versioned.
+	Version int
+	// Dynamic is true if the secret is generated by a secrets engine role
+	Dynamic bool
+	// File is true if the secret should be written to a file
+	File bool
+	// FilePath is where the file should be written, or empty if it should be
+	// written to a temporary file
+	FilePath string
+}
+
+// ParseReference parses an environment variable value, returning nil if it
+// doesn't refer to Vault
+func ParseReference(value string) (*Reference, error) {
+	switch {
+	case strings.HasPrefix(value, SecretFilePrefix):
+		trimmed := strings.TrimSpace(strings.TrimPrefix(value, SecretFilePrefix))
+		if trimmed == "" {
+			return nil, fmt.Errorf("empty vault-file reference: %v", value)
+		}
+
+		// The file path is everything after the first colon, so paths can't contain
+		// colons but file paths can
+		ref := &Reference{File: true}
+		split := strings.SplitN(trimmed, ":", 2)
+		if len(split) == 2 {
+			ref.FilePath = split[1]
+		}
+
+		if err := ref.parsePath(split[0]); err != nil {
+			return nil, fmt.Errorf("invalid vault-file reference %v: %w", value, err)
+		}
+
+		return ref, nil
+
+	case strings.HasPrefix(value, DynamicSecretPrefix):
+		trimmed := strings.TrimSpace(strings.TrimPrefix(value, DynamicSecretPrefix))
+		if trimmed == "" {
+			return nil, fmt.Errorf("empty vault-dynamic reference: %v", value)
+		}
+
+		ref := &Reference{Dynamic: true}
+		if err := ref.parsePath(trimmed); err != nil {
+			return nil, fmt.Errorf("invalid vault-dynamic reference %v: %w", value, err)
+		}
+
+		if ref.Version != 0 {
+			return nil, fmt.Errorf("invalid vault-dynamic reference %v: dynamic secrets have no versions", value)
+		}
+
+		return ref, nil
+
+	case strings.HasPrefix(value, SecretPrefix):
+		trimmed := strings.TrimSpace(strings.TrimPrefix(value, SecretPrefix))
+		if trimmed == "" {
+			return nil, fmt.Errorf("empty vault reference: %v", value)
+		}
+
+		ref := &Reference{}
+		if err := ref.parsePath(trimmed); err != nil {
+			return nil, fmt.Errorf("invalid vault reference %v: %w", value, err)
+		}
+
+		return ref, nil
+	}
+
+	return nil, nil
+}
+
+// parsePath sets the path of the reference, along with the version that follows
+// an '@' and the key that follows a '#', if they're given
+func (r *Reference) parsePath(pathKey string) error {
+	split := strings.SplitN(pathKey, "#", 2)
+	r.Path = split[0]
+
+	if len(split) == 2 {
+		r.Key = split[1]
+		if r.Key == "" {
+			return fmt.Errorf("empty secret key")
+		}
+	}
+
+	if idx := strings.LastIndex(r.Path, "@"); idx >= 0 {
+		version, err := strconv.Atoi(r.Path[idx+1:])
+		if err != nil || version < 1 {
+			return fmt.Errorf("secret version must be a positive integer, not %q", r.Path[idx+1:])
+		}
+
+		r.Path, r.Version = r.Path[:idx], version
+	}
+
+	if r.Path == "" {
+		return fmt.Errorf("no secret path")
+	}
+
+	return nil
+}
+
+// HasDynamicReferences returns true if any variable of the environment refers to
+// a dynamic secret
+func HasDynamicReferences(env map[string]string) bool {
+	for _, value := range env {
+		if ref, err := ParseReference(value); err == nil && ref != nil && ref.Dynamic {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,466 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/vault/api"
+)
+
+// Resolver reads secrets from Vault, replacing references to them in an
+// environment. Secrets can be stored in either version of the KV secrets
+// engine.
+type Resolver struct {
+	Client *api.Client
+	// PathPrefix is prepended to the path of every reference
+	PathPrefix string
+	Logger     logr.Logger
+	// TempDir is where files are written for vault-file references without a path,
+	// which defaults to the system temporary directory
+	TempDir string
+
+	// Mounts that have been looked up, so that each is only looked up once
+	mounts []*kvMount
+	// Temporary files that secrets have been written to, by variable name
+	tempFiles map[string]string
+	// Dynamic secrets that have been generated, by path, which are reused until
+	// they expire
+	dynamic map[string]*api.Secret
+}
+
+// kvMount describes the KV secrets engine mounted at a path
+type kvMount struct {
+	// Path of the mount, with a trailing slash
+	Path    string
+	Version int
+}
+
+// Read returns the data of the secret at a path, relative to the path prefix. A
+// version of zero reads the latest version of the secret.
+//
+// The version of the KV secrets engine is taken from the metadata of its mount.
+// Paths in KV v2 mounts have 'data/' inserted after the mount path if they don't
+// include it already. If the mount can't be looked up, which requires
+// permission to read sys/internal/ui/mounts, secrets from KV v2 are recognised
+// by the shape of their response instead.
+func (r *Resolver) Read(secretPath string, version int) (map[string]interface{}, error) {
+	fullPath := path.Join(r.PathPrefix, secretPath)
+
+	mount, err := r.mount(fullPath)
+	if err != nil {
+		r.Logger.Info(
+			"failed to look up secrets engine mount, assuming version from response",
+			"event", "mount.lookup_failed",
+			"path", fullPath,
+			"error", err.Error(),
+		)
+	}
+
+	if mount != nil && mount.Version == 2 {
+		dataPrefix := mount.Path + "data/"
+		if !strings.HasPrefix(fullPath, dataPrefix) {
+			fullPath = dataPrefix + strings.TrimPrefix(fullPath, mount.Path)
+		}
+	}
+
+	var params map[string][]string
+	if version > 0 {
+		if mount != nil && mount.Version != 2 {
+			return nil, fmt.Errorf("secret %s can't be read at version %d, as it isn't in a KV v2 mount", fullPath, version)
+		}
+
+		params = map[string][]string{"version": {strconv.Itoa(version)}}
+	}
+
+	secret, err := r.Client.Logical().ReadWithData(fullPath, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret %s: %w", fullPath, err)
+	}
+
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("secret %s not found", fullPath)
+	}
+
+	if mount != nil && mount.Version == 1 {
+		return secret.Data, nil
+	}
+
+	metadata, ok := secret.Data["metadata"].(map[string]interface{})
+	if !ok {
+		if mount != nil {
+			return nil, fmt.Errorf("secret %s has no metadata, but is in a KV v2 mount", fullPath)
+		}
+
+		return secret.Data, nil
+	}
+
+	inner, ok := secret.Data["data"].(map[string]interface{})
+	if !ok {
+		return nil, deletedVersionError(fullPath, metadata)
+	}
+
+	return inner, nil
+}
+
+// deletedVersionError explains why a version of a KV v2 secret has no data,
+// which is only the case when it has been deleted or destroyed
+func deletedVersionError(secretPath string, metadata map[string]interface{}) error {
+	version := fmt.Sprintf("%v", metadata["version"])
+	if destroyed, _ := metadata["destroyed"].(bool); destroyed {
+		return fmt.Errorf("version %s of secret %s has been destroyed", version, secretPath)
+	}
+
+	if deletionTime, _ := metadata["deletion_time"].(string); deletionTime != "" {
+		return fmt.Errorf(
+			"version %s of secret %s was deleted at %s, and must be undeleted or a different version used",
+			version, secretPath, deletionTime,
+		)
+	}
+
+	return fmt.Errorf("version %s of secret %s has no data", version, secretPath)
+}
+
+// mount returns the KV secrets engine mount that contains a path, looking it up
+// using the same endpoint as the Vault CLI if it hasn't been seen already
+func (r *Resolver) mount(fullPath string) (*kvMount, error) {
+	for _, mount := range r.mounts {
+		if strings.HasPrefix(fullPath, mount.Path) {
+			return mount, nil
+		}
+	}
+
+	secret, err := r.Client.Logical().Read(path.Join("sys/internal/ui/mounts", fullPath))
+	if err != nil {
+		return nil, err
+	}
+
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("no mount found for %s", fullPath)
+	}
+
+	mountPath, _ := secret.Data["path"].(string)
+	if mountPath == "" {
+		return nil, fmt.Errorf("no mount found for %s", fullPath)
+	}
+
+	if mountType, _ := secret.Data["type"].(string); mountType != "kv" && mountType != "generic" {
+		return nil, fmt.Errorf("mount %s for %s is a %s secrets engine, not kv", mountPath, fullPath, mountType)
+	}
+
+	mount := &kvMount{Path: strings.TrimSuffix(mountPath, "/") + "/", Version: 1}
+	if options, ok := secret.Data["options"].(map[string]interface{}); ok {
+		if options["version"] == "2" {
+			mount.Version = 2
+		}
+	}
+
+	r.mounts = append(r.mounts, mount)
+
+	return mount, nil
+}
+
+// Value returns the value of a key in a secret's data. The key can be empty if
+// the secret has exactly one key. Values that aren't strings are JSON encoded.
+func Value(secretPath string, data map[string]interface{}, key string) (string, error) {
+	if key != "" {
+		value, ok := data[key]
+		if !ok {
+			return "", fmt.Errorf("secret %s has no key %s, only %v", secretPath, key, keys(data))
+		}
+
+		return formatValue(value)
+	}
+
+	if len(data) != 1 {
+		return "", fmt.Errorf(
+			"secret %s has %d keys %v, so the key to use must be given as '%s#<key>'",
+			secretPath, len(data), keys(data), secretPath,
+		)
+	}
+
+	for _, value := range data {
+		return formatValue(value)
+	}
+
+	return "", nil
+}
+
+func keys(data map[string]interface{}) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func formatValue(value interface{}) (string, error) {
+	if str, ok := value.(string); ok {
+		return str, nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode secret value: %w", err)
+	}
+
+	return string(encoded), nil
+}
+
+// readDynamic returns the data of a dynamic secret, generating it from the role
+// at the path if it hasn't been already
+func (r *Resolver) readDynamic(rolePath string) (map[string]interface{}, error) {
+	if secret, ok := r.dynamic[rolePath]; ok {
+		return secret.Data, nil
+	}
+
+	secret, err := r.Client.Logical().Read(rolePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate dynamic secret %s: %w", rolePath, err)
+	}
+
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("dynamic secret role %s not found", rolePath)
+	}
+
+	r.Logger.Info(
+		"generated dynamic secret",
+		"event", "dynamic_secret.generate",
+		"path", rolePath,
+		"lease_id", secret.LeaseID,
+		"lease_duration", secret.LeaseDuration,
+	)
+
+	if r.dynamic == nil {
+		r.dynamic = map[string]*api.Secret{}
+	}
+	r.dynamic[rolePath] = secret
+
+	return secret.Data, nil
+}
+
+// Leases returns the dynamic secrets that have been generated, by path, which
+// must be renewed for as long as they're used
+func (r *Resolver) Leases() map[string]*api.Secret {
+	leases := map[string]*api.Secret{}
+	for rolePath, secret := range r.dynamic {
+		if secret.LeaseID != "" {
+			leases[rolePath] = secret
+		}
+	}
+
+	return leases
+}
+
+// Expire forgets a dynamic secret, so that it's generated again the next time
+// it's resolved. The lease of the old secret is left to expire, as the process
+// may still be using it.
+func (r *Resolver) Expire(rolePath string) {
+	delete(r.dynamic, rolePath)
+}
+
+// RevokeLeases revokes the leases of every dynamic secret that has been
+// generated, which is done once they're no longer used to limit their exposure
+func (r *Resolver) RevokeLeases() error {
+	var errs []string
+	for rolePath, secret := range r.Leases() {
+		r.Logger.Info("revoking lease", "event", "lease.revoke", "path", rolePath, "lease_id", secret.LeaseID)
+		if err := r.Client.Sys().Revoke(secret.LeaseID); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", secret.LeaseID, err))
+			continue
+		}
+
+		delete(r.dynamic, rolePath)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to revoke leases: %s", strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// Secret is the value of a secret referenced by an environment variable
+type Secret struct {
+	Reference
+	Value string
+}
+
+// ResolveEnvironment returns the variables of an environment that refer to
+// Vault, with their values replaced by secrets. Secrets referenced with
+// 'vault-file:' are written to files, and their variables set to the paths of
+// those files. Variables that don't refer to Vault are not returned.
+func (r *Resolver) ResolveEnvironment(env map[string]string) (map[string]string, error) {
+	secrets, err := r.Resolve(env)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Environment(secrets)
+}
+
+// Resolve reads the secrets referenced by the variables of an environment,
+// keyed by the name of the variable. Several variables can take different keys
+// from the same secret.
+func (r *Resolver) Resolve(env map[string]string) (map[string]Secret, error) {
+	resolved := map[string]Secret{}
+	secrets := map[string]map[string]interface{}{}
+
+	for key, value := range env {
+		ref, err := ParseReference(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reference in %s: %w", key, err)
+		}
+
+		if ref == nil {
+			continue
+		}
+
+		var data map[string]interface{}
+		if ref.Dynamic {
+			data, err = r.readDynamic(ref.Path)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			// Several variables can refer to the same version of a secret, which only
+			// needs reading once
+			cacheKey := fmt.Sprintf("%s@%d", ref.Path, ref.Version)
+			if _, ok := secrets[cacheKey]; !ok {
+				secrets[cacheKey], err = r.Read(ref.Path, ref.Version)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			data = secrets[cacheKey]
+		}
+
+		value, err := Value(ref.Path, data, ref.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", key, err)
+		}
+
+		resolved[key] = Secret{Reference: *ref, Value: value}
+	}
+
+	return resolved, nil
+}
+
+// Environment returns the value of each variable that refers to a secret. Secrets
+// that should be written to files are written, and their variables are set to the
+// paths of those files.
+func (r *Resolver) Environment(secrets map[string]Secret) (map[string]string, error) {
+	env := map[string]string{}
+	for key, secret := range secrets {
+		if !secret.File {
+			env[key] = secret.Value
+			continue
+		}
+
+		filePath, err := r.writeFile(key, secret.FilePath, secret.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		env[key] = filePath
+	}
+
+	return env, nil
+}
+
+// writeFile writes a secret value to a file, and returns the path of the file.
+// If no path is given, a temporary file is created the first time the variable's
+// secret is written, and reused each time after.
+func (r *Resolver) writeFile(key, filePath, value string) (string, error) {
+	if filePath == "" {
+		filePath = r.tempFiles[key]
+	}
+
+	if filePath == "" {
+		tempFile, err := ioutil.TempFile(r.TempDir, fmt.Sprintf("%s-*", key))
+		if err != nil {
+			return "", fmt.Errorf("failed to create temporary file for %s: %w", key, err)
+		}
+		tempFile.Close()
+
+		filePath = tempFile.Name()
+		if r.tempFiles == nil {
+			r.tempFiles = map[string]string{}
+		}
+		r.tempFiles[key] = filePath
+	}
+
+	changed, err := writeFileAtomic(filePath, []byte(value), 0600, -1, -1)
+	if err != nil {
+		return "", fmt.Errorf("failed to write file for %s to path %s: %w", key, filePath, err)
+	}
+
+	if changed {
+		r.Logger.Info(
+			"wrote vault secret file",
+			"event", "secret_file.write",
+			"key", key,
+			"path", filePath,
+		)
+	}
+
+	return filePath, nil
+}
+
+// writeFileAtomic replaces the content of a file by renaming a temporary file
+// over it, so that processes reading it never see it partially written. Files
+// are left untouched if their content hasn't changed, and the returned bool is
+// true only if the file was written. The owner is changed unless uid and gid are
+// both -1.
+func writeFileAtomic(filePath string, content []byte, mode os.FileMode, uid, gid int) (bool, error) {
+	if existing, err := ioutil.ReadFile(filePath); err == nil && bytes.Equal(existing, content) {
+		return false, nil
+	}
+
+	dir := filepath.Dir(filePath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return false, err
+	}
+
+	tempFile, err := ioutil.TempFile(dir, fmt.Sprintf(".%s-*", filepath.Base(filePath)))
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	if _, err := tempFile.Write(content); err != nil {
+		return false, err
+	}
+
+	if err := tempFile.Close(); err != nil {
+		return false, err
+	}
+
+	if err := os.Chmod(tempFile.Name(), mode); err != nil {
+		return false, err
+	}
+
+	if uid != -1 || gid != -1 {
+		if err := os.Chown(tempFile.Name(), uid, gid); err != nil {
+			return false, err
+		}
+	}
+
+	if err := os.Rename(tempFile.Name(), filePath); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,580 @@
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/vault/api"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeVault serves secrets over the Vault HTTP API, from a KV v1 mount at kv/ and
+// a KV v2 mount at secret/
+type fakeVault struct {
+	sync.Mutex
+	// Secrets in the KV v1 mount, by their full path
+	kv1 map[string]map[string]interface{}
+	// Versions of secrets in the KV v2 mount, by their path within the mount
+	kv2 map[string][]fakeVersion
+	// Whether mounts can be looked up, which requires permission in Vault
+	mountsForbidden bool
+	// TTL of the token, in seconds, which is not renewable
+	tokenTTL int
+	// Roles that generate dynamic secrets, by path, along with the duration and
+	// renewability of their leases
+	dynamicRoles   map[string]bool
+	leaseDuration  int
+	leaseRenewable bool
+	generated      int
+	renewed        []string
+	revoked        []string
+	// Credentials given to the login endpoints of auth methods, by mount path
+	logins map[string]map[string]interface{}
+	reads  map[string]int
+	// Number of upcoming requests to each path that fail
+	failures map[string]int
+}
+
+type fakeVersion struct {
+	data      map[string]interface{}
+	deleted   bool
+	destroyed bool
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	secretPath := strings.TrimPrefix(r.URL.Path, "/v1/")
+	f.reads[secretPath]++
+
+	if f.failures[secretPath] > 0 {
+		f.failures[secretPath]--
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"errors":["internal error"]}`))
+		return
+	}
+
+	respond := func(status int, data interface{}) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}
+
+	if f.dynamicRoles[secretPath] {
+		f.generated++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"lease_id":       fmt.Sprintf("%s/%d", secretPath, f.generated),
+			"lease_duration": f.leaseDuration,
+			"renewable":      f.leaseRenewable,
+			"data": map[string]interface{}{
+				"username": fmt.Sprintf("user-%d", f.generated),
+				"password": fmt.Sprintf("password-%d", f.generated),
+			},
+		})
+		return
+	}
+
+	if secretPath == "sys/leases/renew" {
+		var body struct {
+			LeaseID string `json:"lease_id"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.renewed = append(f.renewed, body.LeaseID)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"lease_id":       body.LeaseID,
+			"lease_duration": f.leaseDuration,
+			"renewable":      true,
+		})
+		return
+	}
+
+	if leaseID := strings.TrimPrefix(secretPath, "sys/leases/revoke/"); leaseID != secretPath {
+		f.revoked = append(f.revoked, leaseID)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if strings.HasPrefix(secretPath, "auth/") && strings.HasSuffix(secretPath, "/login") {
+		mountPath := strings.TrimSuffix(strings.TrimPrefix(secretPath, "auth/"), "/login")
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if f.logins == nil {
+			f.logins = map[string]map[string]interface{}{}
+		}
+		f.logins[mountPath] = body
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": fmt.Sprintf("%s-token", mountPath)},
+		})
+		return
+	}
+
+	if secretPath == "auth/token/lookup-self" {
+		respond(http.StatusOK, map[string]interface{}{"ttl": f.tokenTTL, "renewable": false})
+		return
+	}
+
+	if mountPath := strings.TrimPrefix(secretPath, "sys/internal/ui/mounts/"); mountPath != secretPath {
+		switch {
+		case f.mountsForbidden:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+		case strings.HasPrefix(mountPath, "kv/"):
+			respond(http.StatusOK, map[string]interface{}{"path": "kv/", "type": "kv", "options": nil})
+		case strings.HasPrefix(mountPath, "secret/"):
+			respond(http.StatusOK, map[string]interface{}{"path": "secret/", "type": "kv", "options": map[string]interface{}{"version": "2"}})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":["no mount"]}`))
+		}
+		return
+	}
+
+	if data, ok := f.kv1[secretPath]; ok {
+		respond(http.StatusOK, data)
+		return
+	}
+
+	versions := f.kv2[strings.TrimPrefix(secretPath, "secret/data/")]
+	if !strings.HasPrefix(secretPath, "secret/data/") || len(versions) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errors":[]}`))
+		return
+	}
+
+	number := len(versions)
+	if requested := r.URL.Query().Get("version"); requested != "" {
+		number, _ = strconv.Atoi(requested)
+	}
+
+	version := versions[number-1]
+	metadata := map[string]interface{}{
+		"version":       number,
+		"deletion_time": "",
+		"destroyed":     version.destroyed,
+	}
+	if version.deleted {
+		metadata["deletion_time"] = "2020-01-01T00:00:00Z"
+	}
+
+	// Vault responds with a 404, but still includes the metadata, for versions
+	// that have no data
+	if version.deleted || version.destroyed {
+		respond(http.StatusNotFound, map[string]interface{}{"data": nil, "metadata": metadata})
+		return
+	}
+
+	respond(http.StatusOK, map[string]interface{}{"data": version.data, "metadata": metadata})
+}
+
+var _ = Describe("Resolver", func() {
+	var (
+		vault    *fakeVault
+		server   *httptest.Server
+		resolver *Resolver
+		dir      string
+	)
+
+	BeforeEach(func() {
+		vault = &fakeVault{
+			kv1: map[string]map[string]interface{}{
+				"kv/app/password": {"password": "hunter2"},
+				"kv/app/ports":    {"ports": []interface{}{80, 443}},
+				"kv/app/multiple": {"username": "admin", "password": "hunter2"},
+			},
+			kv2: map[string][]fakeVersion{
+				"app/password": {
+					{data: map[string]interface{}{"data": "battery-staple"}},
+					{data: map[string]interface{}{"data": "deleted"}, deleted: true},
+					{data: map[string]interface{}{"data": "destroyed"}, destroyed: true},
+					{data: map[string]interface{}{"data": "correct-horse"}},
+				},
+			},
+			reads: map[string]int{},
+		}
+		server = httptest.NewServer(vault)
+
+		cfg := api.DefaultConfig()
+		cfg.Address = server.URL
+		client, err := api.NewClient(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		client.SetToken("token")
+
+		resolver = &Resolver{
+			Client: client,
+			Logger: zap.LoggerTo(GinkgoWriter, true),
+		}
+
+		dir, err = ioutil.TempDir("", "vault-")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	Describe("ResolveEnvironment", func() {
+		var (
+			env      map[string]string
+			resolved map[string]string
+			err      error
+		)
+
+		JustBeforeEach(func() {
+			resolved, err = resolver.ResolveEnvironment(env)
+		})
+
+		Context("with a KV v1 secret", func() {
+			BeforeEach(func() {
+				env = map[string]string{
+					"PASSWORD": "vault:kv/app/password",
+					"PLAIN":    "value",
+				}
+			})
+
+			It("resolves only the vault references", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resolved).To(Equal(map[string]string{"PASSWORD": "hunter2"}))
+			})
+		})
+
+		Context("with a KV v2 secret", func() {
+			BeforeEach(func() {
+				env = map[string]string{"PASSWORD": "vault:secret/data/app/password"}
+			})
+
+			It("unwraps the latest version of the secret data", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resolved).To(Equal(map[string]string{"PASSWORD": "correct-horse"}))
+			})
+
+			Context("when the mount can't be looked up", func() {
+				BeforeEach(func() {
+					vault.mountsForbidden = true
+				})
+
+				It("recognises the secret from the response", func() {
+					Expect(err).NotTo(HaveOccurred())
+					Expect(resolved).To(Equal(map[string]string{"PASSWORD": "correct-horse"}))
+				})
+			})
+		})
+
+		Context("with a KV v2 secret path without data/", func() {
+			BeforeEach(func() {
+				env = map[string]string{
+					"PASSWORD":       "vault:secret/app/password",
+					"OTHER_PASSWORD": "vault:secret/data/app/password",
+				}
+			})
+
+			It("reads the secret from the data path, looking up the mount once", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resolved).To(HaveKeyWithValue("PASSWORD", "correct-horse"))
+
+				lookups := 0
+				for readPath, count := range vault.reads {
+					if strings.HasPrefix(readPath, "sys/internal/ui/mounts/") {
+						lookups += count
+					}
+				}
+				Expect(lookups).To(Equal(1))
+			})
+		})
+
+		Context("with a version of a KV v2 secret", func() {
+			BeforeEach(func() {
+				env = map[string]string{
+					"PASSWORD":          "vault:secret/app/password@1",
+					"PASSWORD_WITH_KEY": "vault:secret/app/password@1#data",
+					"LATEST_PASSWORD":   "vault:secret/app/password",
+				}
+			})
+
+			It("reads that version", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resolved).To(Equal(map[string]string{
+					"PASSWORD":          "battery-staple",
+					"PASSWORD_WITH_KEY": "battery-staple",
+					"LATEST_PASSWORD":   "correct-horse",
+				}))
+			})
+		})
+
+		Context("with a deleted version of a KV v2 secret", func() {
+			BeforeEach(func() {
+				env = map[string]string{"PASSWORD": "vault:secret/app/password@2"}
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring(
+					"version 2 of secret secret/data/app/password was deleted at 2020-01-01T00:00:00Z",
+				)))
+			})
+		})
+
+		Context("with a destroyed version of a KV v2 secret", func() {
+			BeforeEach(func() {
+				env = map[string]string{"PASSWORD": "vault:secret/app/password@3"}
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring(
+					"version 3 of secret secret/data/app/password has been destroyed",
+				)))
+			})
+		})
+
+		Context("with a version of a KV v1 secret", func() {
+			BeforeEach(func() {
+				env = map[string]string{"PASSWORD": "vault:kv/app/password@1"}
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("isn't in a KV v2 mount")))
+			})
+		})
+
+		Context("with a path prefix", func() {
+			BeforeEach(func() {
+				resolver.PathPrefix = "kv/app"
+				env = map[string]string{"PASSWORD": "vault:password"}
+			})
+
+			It("reads secrets relative to the prefix", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resolved).To(Equal(map[string]string{"PASSWORD": "hunter2"}))
+			})
+		})
+
+		Context("with a secret that isn't a string", func() {
+			BeforeEach(func() {
+				env = map[string]string{"PORTS": "vault:kv/app/ports"}
+			})
+
+			It("encodes the value as JSON", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resolved).To(Equal(map[string]string{"PORTS": "[80,443]"}))
+			})
+		})
+
+		Context("with several references to the same secret", func() {
+			BeforeEach(func() {
+				env = map[string]string{
+					"PASSWORD":       "vault:kv/app/password",
+					"OTHER_PASSWORD": "vault:kv/app/password",
+				}
+			})
+
+			It("reads the secret once", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resolved).To(HaveKeyWithValue("OTHER_PASSWORD", "hunter2"))
+				Expect(vault.reads["kv/app/password"]).To(Equal(1))
+			})
+		})
+
+		Context("with a secret that has several keys", func() {
+			BeforeEach(func() {
+				env = map[string]string{"CREDENTIALS": "vault:kv/app/multiple"}
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("the key to use must be given as 'kv/app/multiple#<key>'")))
+			})
+		})
+
+		Context("with keys of a secret that has several keys", func() {
+			BeforeEach(func() {
+				env = map[string]string{
+					"USERNAME":      "vault:kv/app/multiple#username",
+					"PASSWORD_FILE": "vault-file:kv/app/multiple#password:" + filepath.Join(dir, "password"),
+				}
+			})
+
+			It("resolves each key, reading the secret once", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resolved).To(Equal(map[string]string{
+					"USERNAME":      "admin",
+					"PASSWORD_FILE": filepath.Join(dir, "password"),
+				}))
+				Expect(vault.reads["kv/app/multiple"]).To(Equal(1))
+
+				content, err := ioutil.ReadFile(filepath.Join(dir, "password"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).To(Equal("hunter2"))
+			})
+		})
+
+		Context("with a key that the secret doesn't have", func() {
+			BeforeEach(func() {
+				env = map[string]string{"TOKEN": "vault:kv/app/multiple#token"}
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("secret kv/app/multiple has no key token")))
+			})
+		})
+
+		Context("with a secret that doesn't exist", func() {
+			BeforeEach(func() {
+				env = map[string]string{"MISSING": "vault:kv/app/missing"}
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("secret kv/app/missing not found")))
+			})
+		})
+
+		Context("with dynamic secret references", func() {
+			BeforeEach(func() {
+				vault.dynamicRoles = map[string]bool{"database/creds/app": true}
+				vault.leaseDuration = 60
+				resolver.PathPrefix = "kv/app"
+				env = map[string]string{
+					"DATABASE_USERNAME": "vault-dynamic:database/creds/app#username",
+					"DATABASE_PASSWORD": "vault-dynamic:database/creds/app#password",
+				}
+			})
+
+			It("generates one secret, without the path prefix", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resolved).To(Equal(map[string]string{
+					"DATABASE_USERNAME": "user-1",
+					"DATABASE_PASSWORD": "password-1",
+				}))
+				Expect(vault.generated).To(Equal(1))
+			})
+
+			It("reuses the secret until it expires", func() {
+				_, err := resolver.ResolveEnvironment(env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vault.generated).To(Equal(1))
+
+				resolver.Expire("database/creds/app")
+				resolved, err := resolver.ResolveEnvironment(env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resolved).To(HaveKeyWithValue("DATABASE_USERNAME", "user-2"))
+			})
+
+			It("revokes its lease", func() {
+				Expect(resolver.Leases()).To(HaveKey("database/creds/app"))
+				Expect(resolver.RevokeLeases()).To(Succeed())
+				Expect(vault.revoked).To(Equal([]string{"database/creds/app/1"}))
+				Expect(resolver.Leases()).To(BeEmpty())
+			})
+		})
+
+		Context("with a file reference", func() {
+			BeforeEach(func() {
+				env = map[string]string{
+					"PASSWORD_FILE": "vault-file:kv/app/password:" + filepath.Join(dir, "nested", "password"),
+				}
+			})
+
+			It("writes the secret to the file", func() {
+				Expect(err).NotTo(HaveOccurred())
+
+				filePath := filepath.Join(dir, "nested", "password")
+				Expect(resolved).To(Equal(map[string]string{"PASSWORD_FILE": filePath}))
+
+				content, err := ioutil.ReadFile(filePath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).To(Equal("hunter2"))
+			})
+		})
+
+		Context("with a file reference without a path", func() {
+			BeforeEach(func() {
+				env = map[string]string{"PASSWORD_FILE": "vault-file:kv/app/password"}
+			})
+
+			AfterEach(func() {
+				os.Remove(resolved["PASSWORD_FILE"])
+			})
+
+			It("writes the secret to a temporary file", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(filepath.Base(resolved["PASSWORD_FILE"])).To(HavePrefix("PASSWORD_FILE-"))
+
+				content, err := ioutil.ReadFile(resolved["PASSWORD_FILE"])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).To(Equal("hunter2"))
+			})
+		})
+	})
+})
+
+var _ = Describe("ParseReference", func() {
+	It("ignores values that aren't references", func() {
+		Expect(ParseReference("value")).To(BeNil())
+	})
+
+	It("parses secret references", func() {
+		Expect(ParseReference("vault:app/password")).To(Equal(&Reference{Path: "app/password"}))
+	})
+
+	It("parses file references", func() {
+		Expect(ParseReference("vault-file:app/key")).To(Equal(&Reference{Path: "app/key", File: true}))
+	})
+
+	It("parses file references with a path", func() {
+		Expect(ParseReference("vault-file:app/key:/etc/key")).To(
+			Equal(&Reference{Path: "app/key", File: true, FilePath: "/etc/key"}),
+		)
+	})
+
+	It("parses references with a key", func() {
+		Expect(ParseReference("vault:app/database#password")).To(
+			Equal(&Reference{Path: "app/database", Key: "password"}),
+		)
+	})
+
+	It("parses file references with a key and a path", func() {
+		Expect(ParseReference("vault-file:app/tls#key:/etc/key")).To(
+			Equal(&Reference{Path: "app/tls", Key: "key", File: true, FilePath: "/etc/key"}),
+		)
+	})
+
+	It("parses references with a version and a key", func() {
+		Expect(ParseReference("vault:app/database@3#password")).To(
+			Equal(&Reference{Path: "app/database", Version: 3, Key: "password"}),
+		)
+	})
+
+	It("rejects references with an invalid version", func() {
+		_, err := ParseReference("vault:app/database@latest")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("parses dynamic references", func() {
+		Expect(ParseReference("vault-dynamic:database/creds/app#password")).To(
+			Equal(&Reference{Path: "database/creds/app", Key: "password", Dynamic: true}),
+		)
+	})
+
+	It("rejects dynamic references with a version", func() {
+		_, err := ParseReference("vault-dynamic:database/creds/app@1")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects references with an empty key", func() {
+		_, err := ParseReference("vault:app/database#")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects empty references", func() {
+		_, err := ParseReference("vault-file: ")
+		Expect(err).To(HaveOccurred())
+	})
+})
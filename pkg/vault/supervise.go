@@ -0,0 +1,672 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"os"
+	execpkg "os/exec"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/vault/api"
+)
+
+// SuperviseRestart is the action that restarts the supervised process when its
+// secrets change, rather than signalling it
+const SuperviseRestart = "restart"
+
+// forwardedSignals are passed on to the supervised process, so that it can be
+// stopped or reloaded as if it were running without a supervisor
+var forwardedSignals = []os.Signal{
+	syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM,
+	syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGWINCH,
+}
+
+// SuperviseAction is what the supervisor does when the secrets of its process
+// change
+type SuperviseAction struct {
+	Restart bool
+	Signal  syscall.Signal
+}
+
+// ParseSuperviseAction parses either 'restart' or the name of a signal, with or
+// without its SIG prefix, e.g. 'SIGHUP' or 'USR1'
+func ParseSuperviseAction(action string) (SuperviseAction, error) {
+	if action == SuperviseRestart {
+		return SuperviseAction{Restart: true}, nil
+	}
+
+	name := strings.TrimPrefix(strings.ToUpper(action), "SIG")
+	for _, sig := range forwardedSignals {
+		if strings.TrimPrefix(signalName(sig), "SIG") == name {
+			return SuperviseAction{Signal: sig.(syscall.Signal)}, nil
+		}
+	}
+
+	return SuperviseAction{}, fmt.Errorf(
+		"invalid supervise action %q, must be %s or one of %v", action, SuperviseRestart, signalNames(),
+	)
+}
+
+func (a SuperviseAction) String() string {
+	if a.Restart {
+		return SuperviseRestart
+	}
+
+	return signalName(a.Signal)
+}
+
+// Supervisor runs a process with secrets resolved into its environment, keeping
+// them up to date for as long as it runs. It remains the parent of the process,
+// renewing its Vault token and the leases of dynamic secrets, and reading its
+// secrets again on an interval or when dynamic secrets expire. When they change,
+// files and templates are rewritten and the process is either signalled, so that
+// it can read them again, or restarted. Changes to secrets held in environment variables
+// always restart the process, as they can't be seen otherwise.
+type Supervisor struct {
+	Resolver *Resolver
+	Logger   logr.Logger
+
+	// Env is the environment of the process, including references to secrets
+	Env map[string]string
+	// Command is the process to run, whose binary is found in the PATH
+	Command []string
+	// Templates are rendered before the process starts, and again whenever
+	// secrets are read
+	Templates []Template
+	// OnChange is what to do when secrets change
+	OnChange SuperviseAction
+	// Interval between reading secrets. If zero, secrets are only read again when
+	// dynamic secrets expire.
+	Interval time.Duration
+	// RetryInterval is how long to wait before retrying failed requests to Vault,
+	// which doubles for each consecutive failure to read secrets. Defaults to the
+	// Interval, or 30 seconds without one.
+	RetryInterval time.Duration
+	// RestartTimeout is how long the process has to exit when being restarted,
+	// after which it is killed
+	RestartTimeout time.Duration
+	// Login optionally provides a new Vault token when the current one can no
+	// longer be renewed
+	Login func() (string, error)
+	// Reap collects the exit status of every child process, rather than only the
+	// process it runs. This should be set when running as PID 1, where orphaned
+	// processes are re-parented to the supervisor and would otherwise remain as
+	// zombies.
+	Reap bool
+
+	reaper *reaper
+}
+
+// Run starts the process and supervises it until it exits, returning its exit
+// code. Signals are forwarded to the process, so callers shouldn't handle them.
+// Cancelling the context stops the process. The leases of any dynamic secrets
+// are revoked before returning.
+func (s *Supervisor) Run(ctx context.Context) (int, error) {
+	defer func() {
+		if err := s.Resolver.RevokeLeases(); err != nil {
+			s.Logger.Error(err, "failed to revoke leases")
+		}
+	}()
+
+	secrets, err := s.Resolver.Resolve(s.Env)
+	if err != nil {
+		return 0, err
+	}
+
+	env, err := s.Resolver.Environment(secrets)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := s.Resolver.RenderTemplates(s.Templates); err != nil {
+		return 0, err
+	}
+
+	// The reaper outlives the context, as the process is still waited for when
+	// stopping it
+	if s.Reap {
+		s.reaper = newReaper()
+		stop := make(chan struct{})
+		defer close(stop)
+		go s.reaper.run(stop)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	process, err := s.start(env)
+	if err != nil {
+		return 0, err
+	}
+
+	signals := make(chan os.Signal, len(forwardedSignals))
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+
+	relogin := make(chan struct{}, 1)
+	go s.renewToken(ctx, relogin)
+
+	expired := make(chan *expiredLease, 1)
+	renewing := map[string]bool{}
+	s.renewLeases(ctx, renewing, expired)
+
+	// Without an interval, secrets are only read again when dynamic secrets expire
+	var tick <-chan time.Time
+	if s.Interval > 0 {
+		ticker := time.NewTicker(s.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	// Failures to read secrets are retried until they succeed, as nothing else may
+	// cause them to be read again, such as when an expired dynamic secret couldn't
+	// be generated again
+	var (
+		retry      <-chan time.Time
+		retryDelay time.Duration
+	)
+
+	for {
+		select {
+		case sig := <-signals:
+			s.Logger.Info("forwarding signal", "event", "supervisor.signal", "signal", signalName(sig))
+			process.Signal(sig)
+			continue
+
+		case err := <-process.exited:
+			code := exitCode(err)
+			s.Logger.Info("process exited", "event", "supervisor.exit", "code", code)
+			return code, nil
+
+		case <-ctx.Done():
+			s.Logger.Info("stopping process", "event", "supervisor.stop")
+			return exitCode(process.Stop(s.RestartTimeout)), nil
+
+		case lease := <-expired:
+			// Leases may have been replaced since they started expiring
+			if current, ok := s.Resolver.Leases()[lease.path]; !ok || current.LeaseID != lease.id {
+				continue
+			}
+
+			s.Logger.Info("dynamic secret expiring, generating it again", "event", "supervisor.lease_expiring", "path", lease.path)
+			s.Resolver.Expire(lease.path)
+
+		// Dynamic secrets are revoked along with the token that generated them
+		case <-relogin:
+			for rolePath := range s.Resolver.Leases() {
+				s.Resolver.Expire(rolePath)
+			}
+
+		case <-retry:
+			retry = nil
+
+		case <-tick:
+		}
+
+		updated, err := s.Resolver.Resolve(s.Env)
+		if err != nil {
+			retry, retryDelay = s.backoff(retryDelay)
+			s.Logger.Error(err, "failed to refresh secrets, keeping current values", "retry_after", retryDelay)
+			continue
+		}
+		s.renewLeases(ctx, renewing, expired)
+
+		rendered, err := s.Resolver.RenderTemplates(s.Templates)
+		if err != nil {
+			retry, retryDelay = s.backoff(retryDelay)
+			s.Logger.Error(err, "failed to render templates, keeping current files", "retry_after", retryDelay)
+			continue
+		}
+
+		if reflect.DeepEqual(secrets, updated) && !rendered {
+			retry, retryDelay = nil, 0
+			continue
+		}
+
+		updatedEnv, err := s.Resolver.Environment(updated)
+		if err != nil {
+			retry, retryDelay = s.backoff(retryDelay)
+			s.Logger.Error(err, "failed to update secrets, keeping current values", "retry_after", retryDelay)
+			continue
+		}
+		retry, retryDelay = nil, 0
+
+		restart := s.OnChange.Restart || !reflect.DeepEqual(env, updatedEnv)
+		secrets, env = updated, updatedEnv
+
+		if !restart {
+			s.Logger.Info(
+				"secrets changed, signalling process",
+				"event", "supervisor.reload", "signal", signalName(s.OnChange.Signal),
+			)
+			process.Signal(s.OnChange.Signal)
+			continue
+		}
+
+		s.Logger.Info("secrets changed, restarting process", "event", "supervisor.restart")
+		process.Stop(s.RestartTimeout)
+		process, err = s.start(env)
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// expiredLease identifies the lease of a dynamic secret that can no longer be
+// renewed
+type expiredLease struct {
+	path string
+	id   string
+}
+
+// renewLeases starts renewing the lease of each dynamic secret that isn't being
+// renewed already. Once a lease can no longer be renewed, it is sent to the
+// expired channel. Leases that aren't renewable are sent once most of their
+// duration has passed.
+func (s *Supervisor) renewLeases(ctx context.Context, renewing map[string]bool, expired chan<- *expiredLease) {
+	for rolePath, secret := range s.Resolver.Leases() {
+		if renewing[secret.LeaseID] {
+			continue
+		}
+
+		renewing[secret.LeaseID] = true
+		go func(rolePath string, secret *api.Secret) {
+			if err := s.renewLease(ctx, rolePath, secret); ctx.Err() == nil {
+				s.Logger.Info(
+					"lease can no longer be renewed",
+					"event", "lease.expiring", "path", rolePath, "reason", fmt.Sprintf("%v", err),
+				)
+			}
+
+			select {
+			case expired <- &expiredLease{path: rolePath, id: secret.LeaseID}:
+			case <-ctx.Done():
+			}
+		}(rolePath, secret)
+	}
+}
+
+// renewLease renews the lease of a secret until it can no longer be renewed
+func (s *Supervisor) renewLease(ctx context.Context, rolePath string, secret *api.Secret) error {
+	if !secret.Renewable {
+		sleep(ctx, time.Duration(secret.LeaseDuration)*time.Second*2/3)
+		return fmt.Errorf("lease is not renewable")
+	}
+
+	renewer, err := s.Resolver.Client.NewRenewer(&api.RenewerInput{Secret: secret})
+	if err != nil {
+		sleep(ctx, time.Duration(secret.LeaseDuration)*time.Second*2/3)
+		return err
+	}
+
+	go renewer.Renew()
+	defer renewer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-renewer.DoneCh():
+			return err
+		case renewal := <-renewer.RenewCh():
+			s.Logger.Info(
+				"renewed lease",
+				"event", "lease.renewed", "path", rolePath, "ttl", renewal.Secret.LeaseDuration,
+			)
+		}
+	}
+}
+
+// renewToken keeps the Vault token alive for as long as the context, logging in
+// again when it can't be renewed, if a login function was provided. Each login
+// is notified on the relogin channel.
+func (s *Supervisor) renewToken(ctx context.Context, relogin chan<- struct{}) {
+	for {
+		err := s.keepTokenAlive(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.Logger.Info(
+			"vault token can no longer be renewed",
+			"event", "supervisor.token_expiring", "reason", fmt.Sprintf("%v", err),
+		)
+		if s.Login == nil {
+			return
+		}
+
+		token, err := s.Login()
+		if err != nil {
+			s.Logger.Error(err, "failed to login to vault, retrying")
+			if !sleep(ctx, s.retryInterval()) {
+				return
+			}
+			continue
+		}
+
+		s.Resolver.Client.SetToken(token)
+		select {
+		case relogin <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// keepTokenAlive renews the Vault token until it can no longer be renewed. Tokens
+// that aren't renewable are kept until most of their TTL has passed.
+func (s *Supervisor) keepTokenAlive(ctx context.Context) error {
+	client := s.Resolver.Client
+
+	self, err := client.Auth().Token().LookupSelf()
+	if err != nil {
+		sleep(ctx, s.retryInterval())
+		return fmt.Errorf("failed to look up vault token: %w", err)
+	}
+
+	renewable, err := self.TokenIsRenewable()
+	if err != nil {
+		return err
+	}
+
+	ttl, err := self.TokenTTL()
+	if err != nil {
+		return err
+	}
+
+	// Tokens without a TTL never expire
+	if ttl == 0 {
+		<-ctx.Done()
+		return nil
+	}
+
+	if !renewable {
+		sleep(ctx, ttl*2/3)
+		return fmt.Errorf("vault token is not renewable")
+	}
+
+	renewer, err := client.NewRenewer(&api.RenewerInput{
+		Secret: &api.Secret{
+			Auth: &api.SecretAuth{
+				ClientToken:   client.Token(),
+				Renewable:     renewable,
+				LeaseDuration: int(ttl / time.Second),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	go renewer.Renew()
+	defer renewer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-renewer.DoneCh():
+			if err == nil {
+				err = fmt.Errorf("vault token reached its maximum TTL")
+			}
+			return err
+		case renewal := <-renewer.RenewCh():
+			s.Logger.Info(
+				"renewed vault token",
+				"event", "vault.token_renewed",
+				"ttl", renewal.Secret.Auth.LeaseDuration,
+			)
+		}
+	}
+}
+
+// retryInterval is how long to wait before retrying failed requests to Vault
+func (s *Supervisor) retryInterval() time.Duration {
+	if s.RetryInterval > 0 {
+		return s.RetryInterval
+	}
+
+	if s.Interval > 0 {
+		return s.Interval
+	}
+
+	return 30 * time.Second
+}
+
+// maxRetryInterval limits how far retries back off, unless the retry interval is
+// longer
+const maxRetryInterval = 5 * time.Minute
+
+// backoff returns a timer for retrying a failed attempt to read secrets, after
+// twice the delay of the previous attempt, or the retry interval for the first
+func (s *Supervisor) backoff(previous time.Duration) (<-chan time.Time, time.Duration) {
+	delay := s.retryInterval()
+	if previous > 0 {
+		delay = previous * 2
+	}
+
+	limit := maxRetryInterval
+	if s.retryInterval() > limit {
+		limit = s.retryInterval()
+	}
+	if delay > limit {
+		delay = limit
+	}
+
+	return time.After(delay), delay
+}
+
+// sleep waits for the duration, returning false if the context was cancelled
+// first
+func sleep(ctx context.Context, duration time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(duration):
+		return true
+	}
+}
+
+// supervisedProcess is a running process, whose exit is sent to a channel
+type supervisedProcess struct {
+	*execpkg.Cmd
+	exited chan error
+}
+
+func (s *Supervisor) start(secretEnv map[string]string) (*supervisedProcess, error) {
+	cmd := execpkg.Command(s.Command[0], s.Command[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	for key, value := range s.Env {
+		if secretValue, ok := secretEnv[key]; ok {
+			value = secretValue
+		}
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, value))
+	}
+
+	s.Logger.Info("starting process", "event", "supervisor.start", "binary", cmd.Path)
+	process := &supervisedProcess{Cmd: cmd, exited: make(chan error, 1)}
+
+	// When reaping, the process is collected along with every other child, so it
+	// mustn't also be waited for
+	if s.reaper != nil {
+		if err := s.reaper.start(process); err != nil {
+			return nil, fmt.Errorf("failed to start %s: %w", s.Command[0], err)
+		}
+
+		return process, nil
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", s.Command[0], err)
+	}
+
+	go func() {
+		process.exited <- cmd.Wait()
+	}()
+
+	return process, nil
+}
+
+func (p *supervisedProcess) Signal(sig os.Signal) {
+	p.Process.Signal(sig)
+}
+
+// Stop terminates the process, killing it if it doesn't exit within the timeout,
+// and returns the result of waiting for it
+func (p *supervisedProcess) Stop(timeout time.Duration) error {
+	p.Process.Signal(syscall.SIGTERM)
+	select {
+	case err := <-p.exited:
+		return err
+	case <-time.After(timeout):
+		p.Process.Kill()
+		return <-p.exited
+	}
+}
+
+// reaper waits for every child of the supervisor, including orphaned processes
+// that were re-parented to it, sending the exit of each process it started to
+// the process' exited channel
+type reaper struct {
+	sync.Mutex
+	processes map[int]*supervisedProcess
+}
+
+func newReaper() *reaper {
+	return &reaper{processes: map[int]*supervisedProcess{}}
+}
+
+// start starts the process, holding the lock so that it can't be reaped before
+// it has been recorded
+func (r *reaper) start(process *supervisedProcess) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if err := process.Start(); err != nil {
+		return err
+	}
+
+	r.processes[process.Process.Pid] = process
+	return nil
+}
+
+// run reaps children whenever one exits, until stopped
+func (r *reaper) run(stop <-chan struct{}) {
+	exits := make(chan os.Signal, 1)
+	signal.Notify(exits, syscall.SIGCHLD)
+	defer signal.Stop(exits)
+
+	for {
+		// Children that exited before being notified are reaped first
+		r.reap()
+
+		select {
+		case <-stop:
+			return
+		case <-exits:
+		}
+	}
+}
+
+// reap collects every child that has exited, without blocking on those that
+// haven't
+func (r *reaper) reap() {
+	r.Lock()
+	defer r.Unlock()
+
+	for {
+		var status syscall.WaitStatus
+		pid, err := syscall.Wait4(-1, &status, syscall.WNOHANG, nil)
+		if err == syscall.EINTR {
+			continue
+		}
+
+		if err != nil || pid <= 0 {
+			return
+		}
+
+		if process, ok := r.processes[pid]; ok {
+			delete(r.processes, pid)
+			process.exited <- waitStatusError(status)
+		}
+	}
+}
+
+// waitStatusError is the exit of a reaped process, which unlike the result of
+// execpkg.Cmd.Wait isn't nil when the process succeeds
+type waitStatusError syscall.WaitStatus
+
+func (e waitStatusError) Error() string {
+	status := syscall.WaitStatus(e)
+	if status.Signaled() {
+		return fmt.Sprintf("signal: %s", status.Signal())
+	}
+
+	return fmt.Sprintf("exit status %d", status.ExitStatus())
+}
+
+// exitCode returns the code that a process exited with, following the shell
+// convention of 128 plus the signal number for processes killed by signals
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	if status, ok := err.(waitStatusError); ok {
+		return statusCode(syscall.WaitStatus(status))
+	}
+
+	if exitErr, ok := err.(*execpkg.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			return statusCode(status)
+		}
+
+		return exitErr.ExitCode()
+	}
+
+	return 1
+}
+
+func statusCode(status syscall.WaitStatus) int {
+	if status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+
+	return status.ExitStatus()
+}
+
+func signalName(sig os.Signal) string {
+	switch sig {
+	case syscall.SIGHUP:
+		return "SIGHUP"
+	case syscall.SIGINT:
+		return "SIGINT"
+	case syscall.SIGQUIT:
+		return "SIGQUIT"
+	case syscall.SIGTERM:
+		return "SIGTERM"
+	case syscall.SIGUSR1:
+		return "SIGUSR1"
+	case syscall.SIGUSR2:
+		return "SIGUSR2"
+	case syscall.SIGWINCH:
+		return "SIGWINCH"
+	default:
+		return sig.String()
+	}
+}
+
+func signalNames() []string {
+	names := []string{}
+	for _, sig := range forwardedSignals {
+		names = append(names, signalName(sig))
+	}
+
+	return names
+}
@@ -0,0 +1,285 @@
+package vault
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Supervisor", func() {
+	var (
+		vault      *fakeVault
+		server     *httptest.Server
+		supervisor *Supervisor
+		dir        string
+		output     string
+		ctx        context.Context
+		cancel     func()
+		exited     chan int
+	)
+
+	BeforeEach(func() {
+		vault = &fakeVault{
+			kv1: map[string]map[string]interface{}{
+				"kv/app/password": {"password": "hunter2"},
+			},
+			reads: map[string]int{},
+		}
+		server = httptest.NewServer(vault)
+
+		cfg := api.DefaultConfig()
+		cfg.Address = server.URL
+		client, err := api.NewClient(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		client.SetToken("token")
+
+		dir, err = ioutil.TempDir("", "vault-")
+		Expect(err).NotTo(HaveOccurred())
+		output = filepath.Join(dir, "output")
+
+		logger := zap.LoggerTo(GinkgoWriter, true)
+		supervisor = &Supervisor{
+			Resolver: &Resolver{Client: client, Logger: logger},
+			Logger:   logger,
+			Env: map[string]string{
+				"PATH":   os.Getenv("PATH"),
+				"OUTPUT": output,
+			},
+			OnChange:       SuperviseAction{Signal: syscall.SIGHUP},
+			Interval:       50 * time.Millisecond,
+			RestartTimeout: time.Second,
+		}
+
+		ctx, cancel = context.WithCancel(context.Background())
+		exited = make(chan int, 1)
+	})
+
+	JustBeforeEach(func() {
+		go func() {
+			defer GinkgoRecover()
+			code, err := supervisor.Run(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			exited <- code
+		}()
+	})
+
+	AfterEach(func() {
+		cancel()
+		Eventually(exited, 5*time.Second).Should(Receive())
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	readOutput := func() string {
+		content, _ := ioutil.ReadFile(output)
+		return string(content)
+	}
+
+	setPassword := func(password string) {
+		vault.Lock()
+		defer vault.Unlock()
+		vault.kv1["kv/app/password"] = map[string]interface{}{"password": password}
+	}
+
+	Context("with a secret in an environment variable", func() {
+		BeforeEach(func() {
+			supervisor.Env["PASSWORD"] = "vault:kv/app/password"
+			supervisor.Command = []string{"sh", "-c", `echo "$PASSWORD" >> "$OUTPUT"; exec sleep 60`}
+		})
+
+		It("restarts the process when the secret changes", func() {
+			Eventually(readOutput).Should(Equal("hunter2\n"))
+			setPassword("correct-horse")
+			Eventually(readOutput).Should(Equal("hunter2\ncorrect-horse\n"))
+		})
+	})
+
+	Context("with a secret in a file", func() {
+		BeforeEach(func() {
+			supervisor.Env["PASSWORD_FILE"] = "vault-file:kv/app/password:" + filepath.Join(dir, "password")
+			supervisor.Command = []string{"sh", "-c", `
+				trap 'cat "$PASSWORD_FILE" >> "$OUTPUT"; echo >> "$OUTPUT"' HUP
+				echo started >> "$OUTPUT"
+				while true; do sleep 0.05; done
+			`}
+		})
+
+		It("rewrites the file and signals the process when the secret changes", func() {
+			Eventually(readOutput).Should(Equal("started\n"))
+			setPassword("correct-horse")
+			Eventually(readOutput).Should(Equal("started\ncorrect-horse\n"))
+		})
+
+		Context("when restarting on changes", func() {
+			BeforeEach(func() {
+				supervisor.OnChange = SuperviseAction{Restart: true}
+			})
+
+			It("restarts the process when the secret changes", func() {
+				Eventually(readOutput).Should(Equal("started\n"))
+				setPassword("correct-horse")
+				Eventually(readOutput).Should(Equal("started\nstarted\n"))
+
+				content, err := ioutil.ReadFile(filepath.Join(dir, "password"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).To(Equal("correct-horse"))
+			})
+		})
+	})
+
+	Context("with a dynamic secret", func() {
+		BeforeEach(func() {
+			vault.dynamicRoles = map[string]bool{"database/creds/app": true}
+			supervisor.Env["DATABASE_USERNAME"] = "vault-dynamic:database/creds/app#username"
+			supervisor.Command = []string{"sh", "-c", `echo "$DATABASE_USERNAME" >> "$OUTPUT"; exec sleep 60`}
+			supervisor.Interval = 0
+		})
+
+		Context("with a renewable lease", func() {
+			BeforeEach(func() {
+				vault.leaseDuration = 60
+				vault.leaseRenewable = true
+			})
+
+			It("renews the lease, and revokes it on exit", func() {
+				Eventually(readOutput).Should(Equal("user-1\n"))
+				Eventually(func() []string {
+					vault.Lock()
+					defer vault.Unlock()
+					return vault.renewed
+				}).Should(ContainElement("database/creds/app/1"))
+
+				cancel()
+				Eventually(exited, 5*time.Second).Should(Receive())
+				exited <- 0 // for AfterEach
+
+				Expect(vault.revoked).To(Equal([]string{"database/creds/app/1"}))
+			})
+		})
+
+		Context("with a lease that isn't renewable", func() {
+			BeforeEach(func() {
+				vault.leaseDuration = 1
+			})
+
+			It("generates the secret again before it expires, restarting the process", func() {
+				Eventually(readOutput).Should(Equal("user-1\n"))
+				Eventually(readOutput, 2*time.Second).Should(Equal("user-1\nuser-2\n"))
+			})
+
+			Context("when generating it again fails", func() {
+				BeforeEach(func() {
+					supervisor.RetryInterval = 50 * time.Millisecond
+					// Leave retries to the supervisor, rather than the client
+					supervisor.Resolver.Client.SetMaxRetries(0)
+				})
+
+				It("retries until it succeeds", func() {
+					Eventually(readOutput).Should(Equal("user-1\n"))
+
+					vault.Lock()
+					vault.failures = map[string]int{"database/creds/app": 2}
+					vault.Unlock()
+
+					Eventually(readOutput, 3*time.Second).Should(Equal("user-1\nuser-2\n"))
+					Expect(vault.reads["database/creds/app"]).To(Equal(4))
+				})
+			})
+		})
+	})
+
+	Context("when the process exits", func() {
+		BeforeEach(func() {
+			supervisor.Command = []string{"sh", "-c", "exit 3"}
+		})
+
+		It("returns its exit code", func() {
+			Eventually(exited).Should(Receive(Equal(3)))
+			exited <- 3 // for AfterEach
+		})
+	})
+
+	Context("when reaping", func() {
+		BeforeEach(func() {
+			supervisor.Reap = true
+			supervisor.Command = []string{"sh", "-c", `echo started >> "$OUTPUT"; exec sleep 60`}
+		})
+
+		It("reaps children it didn't start", func() {
+			Eventually(readOutput).Should(Equal("started\n"))
+
+			// Orphans re-parented to PID 1 are indistinguishable from other children
+			orphan := exec.Command("true")
+			Expect(orphan.Start()).To(Succeed())
+
+			Eventually(func() error {
+				return syscall.Kill(orphan.Process.Pid, 0)
+			}).Should(Equal(syscall.ESRCH))
+		})
+
+		It("returns the exit code of the process once stopped", func() {
+			Eventually(readOutput).Should(Equal("started\n"))
+			cancel()
+			Eventually(exited, 5*time.Second).Should(Receive(Equal(128 + int(syscall.SIGTERM))))
+			exited <- 0 // for AfterEach
+		})
+
+		Context("when the process exits", func() {
+			BeforeEach(func() {
+				supervisor.Command = []string{"sh", "-c", "exit 3"}
+			})
+
+			It("returns its exit code", func() {
+				Eventually(exited).Should(Receive(Equal(3)))
+				exited <- 3 // for AfterEach
+			})
+		})
+	})
+
+	Context("with a token that isn't renewable", func() {
+		var logins int32
+
+		BeforeEach(func() {
+			logins = 0
+			vault.tokenTTL = 1
+			supervisor.Command = []string{"sleep", "60"}
+			supervisor.Login = func() (string, error) {
+				atomic.AddInt32(&logins, 1)
+				return "new-token", nil
+			}
+		})
+
+		It("logs in again before the token expires", func() {
+			Eventually(func() int32 { return atomic.LoadInt32(&logins) }, 2*time.Second).Should(BeNumerically(">=", 1))
+			Expect(supervisor.Resolver.Client.Token()).To(Equal("new-token"))
+		})
+	})
+})
+
+var _ = Describe("ParseSuperviseAction", func() {
+	It("parses restart", func() {
+		Expect(ParseSuperviseAction("restart")).To(Equal(SuperviseAction{Restart: true}))
+	})
+
+	It("parses signals with or without their prefix", func() {
+		Expect(ParseSuperviseAction("SIGHUP")).To(Equal(SuperviseAction{Signal: syscall.SIGHUP}))
+		Expect(ParseSuperviseAction("usr1")).To(Equal(SuperviseAction{Signal: syscall.SIGUSR1}))
+	})
+
+	It("rejects unknown actions", func() {
+		_, err := ParseSuperviseAction("SIGKILL")
+		Expect(err).To(MatchError(ContainSubstring("invalid supervise action")))
+	})
+})
@@ -0,0 +1,173 @@
+package vault
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"strconv"
+	"text/template"
+)
+
+// Template is a Go text/template file that is rendered with secrets from Vault,
+// using the secret function, e.g. {{ secret "database#password" }}. The
+// argument is a path relative to the path prefix, with an optional version and
+// key as in 'vault:' references.
+type Template struct {
+	// Source is the path of the template
+	Source string `yaml:"source"`
+	// Destination is the path the rendered template is written to
+	Destination string `yaml:"destination"`
+	// Mode of the rendered file in octal, which defaults to 0600
+	Mode string `yaml:"mode"`
+	// User and Group that own the rendered file, by name or ID, which default to
+	// those of the current process
+	User  string `yaml:"user"`
+	Group string `yaml:"group"`
+}
+
+// RenderTemplates renders each template and writes it to its destination,
+// returning true if any of their content changed
+func (r *Resolver) RenderTemplates(templates []Template) (bool, error) {
+	changed := false
+	for _, tpl := range templates {
+		written, err := r.RenderTemplate(tpl)
+		if err != nil {
+			return false, err
+		}
+
+		changed = changed || written
+	}
+
+	return changed, nil
+}
+
+// RenderTemplate renders a template and writes it atomically to its destination,
+// returning true if its content changed
+func (r *Resolver) RenderTemplate(tpl Template) (bool, error) {
+	mode, uid, gid, err := tpl.fileOptions()
+	if err != nil {
+		return false, fmt.Errorf("invalid template %s: %w", tpl.Source, err)
+	}
+
+	source, err := ioutil.ReadFile(tpl.Source)
+	if err != nil {
+		return false, fmt.Errorf("failed to read template: %w", err)
+	}
+
+	// Secrets are read once per render, however many times they're used
+	secrets := map[string]map[string]interface{}{}
+	funcs := template.FuncMap{
+		"secret": func(pathKey string) (string, error) {
+			ref := &Reference{}
+			if err := ref.parsePath(pathKey); err != nil {
+				return "", fmt.Errorf("invalid secret %q: %w", pathKey, err)
+			}
+
+			cacheKey := fmt.Sprintf("%s@%d", ref.Path, ref.Version)
+			if _, ok := secrets[cacheKey]; !ok {
+				secrets[cacheKey], err = r.Read(ref.Path, ref.Version)
+				if err != nil {
+					return "", err
+				}
+			}
+
+			return Value(ref.Path, secrets[cacheKey], ref.Key)
+		},
+	}
+
+	parsed, err := template.New(tpl.Source).Funcs(funcs).Parse(string(source))
+	if err != nil {
+		return false, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var rendered bytes.Buffer
+	if err := parsed.Execute(&rendered, nil); err != nil {
+		return false, fmt.Errorf("failed to render template: %w", err)
+	}
+
+	changed, err := writeFileAtomic(tpl.Destination, rendered.Bytes(), mode, uid, gid)
+	if err != nil {
+		return false, fmt.Errorf("failed to write template %s to %s: %w", tpl.Source, tpl.Destination, err)
+	}
+
+	if changed {
+		r.Logger.Info(
+			"rendered template",
+			"event", "template.render",
+			"source", tpl.Source,
+			"destination", tpl.Destination,
+		)
+	}
+
+	return changed, nil
+}
+
+// fileOptions parses the mode and owner of the rendered file, with IDs of -1
+// leaving the owner unchanged
+func (tpl Template) fileOptions() (os.FileMode, int, int, error) {
+	if tpl.Source == "" || tpl.Destination == "" {
+		return 0, 0, 0, fmt.Errorf("templates must have a source and destination")
+	}
+
+	mode := os.FileMode(0600)
+	if tpl.Mode != "" {
+		parsed, err := strconv.ParseUint(tpl.Mode, 8, 32)
+		if err != nil || parsed > 0777 {
+			return 0, 0, 0, fmt.Errorf("mode must be octal permissions, e.g. 0640, not %q", tpl.Mode)
+		}
+
+		mode = os.FileMode(parsed)
+	}
+
+	uid, gid := -1, -1
+	if tpl.User != "" {
+		id, err := lookupID(tpl.User, func(name string) (string, error) {
+			u, err := user.Lookup(name)
+			if err != nil {
+				return "", err
+			}
+
+			return u.Uid, nil
+		})
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("unknown user %q: %w", tpl.User, err)
+		}
+
+		uid = id
+	}
+
+	if tpl.Group != "" {
+		id, err := lookupID(tpl.Group, func(name string) (string, error) {
+			g, err := user.LookupGroup(name)
+			if err != nil {
+				return "", err
+			}
+
+			return g.Gid, nil
+		})
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("unknown group %q: %w", tpl.Group, err)
+		}
+
+		gid = id
+	}
+
+	return mode, uid, gid, nil
+}
+
+// lookupID returns the numeric ID of a user or group, which is either given
+// directly or looked up by name
+func lookupID(nameOrID string, lookup func(string) (string, error)) (int, error) {
+	if id, err := strconv.Atoi(nameOrID); err == nil {
+		return id, nil
+	}
+
+	id, err := lookup(nameOrID)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(id)
+}
@@ -0,0 +1,142 @@
+package vault
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/vault/api"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RenderTemplate", func() {
+	var (
+		vault    *fakeVault
+		server   *httptest.Server
+		resolver *Resolver
+		dir      string
+		tpl      Template
+		source   string
+		changed  bool
+		err      error
+	)
+
+	BeforeEach(func() {
+		vault = &fakeVault{
+			kv1: map[string]map[string]interface{}{
+				"kv/app/multiple": {"username": "admin", "password": "hunter2"},
+			},
+			kv2: map[string][]fakeVersion{
+				"app/password": {
+					{data: map[string]interface{}{"data": "battery-staple"}},
+					{data: map[string]interface{}{"data": "correct-horse"}},
+				},
+			},
+			reads: map[string]int{},
+		}
+		server = httptest.NewServer(vault)
+
+		cfg := api.DefaultConfig()
+		cfg.Address = server.URL
+		client, err := api.NewClient(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		client.SetToken("token")
+
+		resolver = &Resolver{
+			Client: client,
+			Logger: zap.LoggerTo(GinkgoWriter, true),
+		}
+
+		dir, err = ioutil.TempDir("", "vault-")
+		Expect(err).NotTo(HaveOccurred())
+
+		tpl = Template{
+			Source:      filepath.Join(dir, "config.tpl"),
+			Destination: filepath.Join(dir, "config", "app.conf"),
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	JustBeforeEach(func() {
+		Expect(ioutil.WriteFile(tpl.Source, []byte(source), 0600)).To(Succeed())
+		changed, err = resolver.RenderTemplate(tpl)
+	})
+
+	readDestination := func() string {
+		content, err := ioutil.ReadFile(tpl.Destination)
+		Expect(err).NotTo(HaveOccurred())
+		return string(content)
+	}
+
+	Context("with secrets", func() {
+		BeforeEach(func() {
+			source = `user={{ secret "kv/app/multiple#username" }} ` +
+				`password={{ secret "kv/app/multiple#password" }} ` +
+				`old={{ secret "secret/app/password@1" }} ` +
+				`new={{ secret "secret/app/password" }}`
+		})
+
+		It("writes the rendered template with the default mode", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			Expect(readDestination()).To(Equal("user=admin password=hunter2 old=battery-staple new=correct-horse"))
+
+			info, err := os.Stat(tpl.Destination)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+		})
+
+		It("reads each secret once", func() {
+			Expect(vault.reads["kv/app/multiple"]).To(Equal(1))
+		})
+
+		It("reports no change when rendered again", func() {
+			Expect(resolver.RenderTemplate(tpl)).To(BeFalse())
+		})
+	})
+
+	Context("with a mode", func() {
+		BeforeEach(func() {
+			source = `{{ secret "kv/app/multiple#username" }}`
+			tpl.Mode = "0640"
+		})
+
+		It("sets the mode of the rendered file", func() {
+			Expect(err).NotTo(HaveOccurred())
+
+			info, err := os.Stat(tpl.Destination)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0640)))
+		})
+	})
+
+	Context("with an invalid mode", func() {
+		BeforeEach(func() {
+			source = "static"
+			tpl.Mode = "rw-r-----"
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(MatchError(ContainSubstring("mode must be octal permissions")))
+		})
+	})
+
+	Context("with a secret with several keys and no key", func() {
+		BeforeEach(func() {
+			source = `{{ secret "kv/app/multiple" }}`
+		})
+
+		It("returns an error without writing the file", func() {
+			Expect(err).To(MatchError(ContainSubstring("the key to use must be given")))
+			Expect(tpl.Destination).NotTo(BeAnExistingFile())
+		})
+	})
+})
@@ -0,0 +1,100 @@
+// Package archive retains the final state of consoles, and the resources created for
+// them, after they have been deleted. This provides evidence of what was run for
+// audit purposes, which would otherwise vanish along with the console.
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+)
+
+// Record is the final state of a console and the resources created for it
+type Record struct {
+	ArchivedAt    time.Time                               `json:"archivedAt"`
+	Console       *workloadsv1alpha1.Console              `json:"console"`
+	Authorisation *workloadsv1alpha1.ConsoleAuthorisation `json:"authorisation,omitempty"`
+	Job           *batchv1.Job                            `json:"job,omitempty"`
+	Pod           *corev1.Pod                             `json:"pod,omitempty"`
+	// Logs of each of the pod's containers, keyed by container name
+	Logs map[string]string `json:"logs,omitempty"`
+}
+
+// Sink stores archived records
+type Sink interface {
+	Write(ctx context.Context, record *Record) error
+}
+
+// NewSink returns the sink for a location, which can be a file:// URL or an
+// absolute path for a directory.
+func NewSink(location string) (Sink, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive location %q: %w", location, err)
+	}
+
+	switch u.Scheme {
+	case "", "file":
+		if !filepath.IsAbs(u.Path) {
+			return nil, fmt.Errorf("archive directory must be an absolute path: %q", location)
+		}
+
+		return &DirectorySink{Path: u.Path}, nil
+	default:
+		return nil, fmt.Errorf("unsupported archive location %q", location)
+	}
+}
+
+// DirectorySink writes each record as a JSON file to a directory, which may be a
+// mounted volume, at <namespace>/<console>-<uid>.json
+type DirectorySink struct {
+	Path string
+}
+
+var _ Sink = &DirectorySink{}
+
+func (s *DirectorySink) Write(ctx context.Context, record *Record) error {
+	dir := filepath.Join(s.Path, record.Console.Namespace)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode archive record: %w", err)
+	}
+
+	// Write to a temporary file first, so that a record is never left partially
+	// written if we're interrupted
+	tmp, err := ioutil.TempFile(dir, ".archive-")
+	if err != nil {
+		return fmt.Errorf("failed to create archive file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write archive file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write archive file: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.json", record.Console.Name, record.Console.UID)
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		return fmt.Errorf("failed to write archive file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,308 @@
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrLogsNotFound is returned by a LogStore when there are no archived logs for a
+// key
+var ErrLogsNotFound = errors.New("archived logs not found")
+
+// LogStore stores the archived logs of consoles, which are written as gzipped
+// objects by WriteLogs
+type LogStore interface {
+	Put(ctx context.Context, key string, body io.ReadSeeker, size int64) error
+	// Get returns the object stored at the key, or ErrLogsNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// List returns every object whose key starts with the prefix
+	List(ctx context.Context, prefix string) ([]LogObject, error)
+}
+
+// LogObject is an object in a LogStore
+type LogObject struct {
+	Key          string
+	LastModified time.Time
+}
+
+// LogKey is the key that the logs of a console are archived at. Keys include the
+// UID of the console, as names are reused once consoles are deleted, and are
+// grouped by name so that logs can still be found by FindLogs.
+func LogKey(namespace, name, uid string) string {
+	return path.Join(logPrefix(namespace, name), uid+".log.gz")
+}
+
+func logPrefix(namespace, name string) string {
+	return path.Join(namespace, name) + "/"
+}
+
+// FindLogs returns the key of the most recently archived logs of a console with
+// the name, or ErrLogsNotFound if there are none. This finds the logs of consoles
+// that have been deleted, whose UIDs are no longer known.
+func FindLogs(ctx context.Context, store LogStore, namespace, name string) (string, error) {
+	objects, err := store.List(ctx, logPrefix(namespace, name))
+	if err != nil {
+		return "", err
+	}
+
+	var latest *LogObject
+	for idx, object := range objects {
+		if !strings.HasSuffix(object.Key, ".log.gz") {
+			continue
+		}
+
+		if latest == nil || object.LastModified.After(latest.LastModified) {
+			latest = &objects[idx]
+		}
+	}
+
+	if latest == nil {
+		return "", ErrLogsNotFound
+	}
+
+	return latest.Key, nil
+}
+
+// NewLogStore returns the log store for a location, which can be a file:// URL
+// or an absolute path for a directory, or an s3:// URL for an S3-compatible
+// bucket. See NewS3LogStore for how S3 locations are configured.
+func NewLogStore(location string) (LogStore, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log archive location %q: %w", location, err)
+	}
+
+	switch u.Scheme {
+	case "", "file":
+		if !filepath.IsAbs(u.Path) {
+			return nil, fmt.Errorf("log archive directory must be an absolute path: %q", location)
+		}
+
+		return &DirectoryLogStore{Path: u.Path}, nil
+	case "s3":
+		return NewS3LogStore(u)
+	default:
+		return nil, fmt.Errorf("unsupported log archive location %q", location)
+	}
+}
+
+// DirectoryLogStore writes logs to a directory, which may be a mounted volume
+type DirectoryLogStore struct {
+	Path string
+}
+
+var _ LogStore = &DirectoryLogStore{}
+
+func (s *DirectoryLogStore) Put(ctx context.Context, key string, body io.ReadSeeker, size int64) error {
+	dst := filepath.Join(s.Path, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		return fmt.Errorf("failed to create log archive directory: %w", err)
+	}
+
+	// Write to a temporary file first, so that logs are never left partially
+	// written if we're interrupted
+	tmp, err := ioutil.TempFile(filepath.Dir(dst), ".logs-")
+	if err != nil {
+		return fmt.Errorf("failed to create log archive file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write log archive file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write log archive file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return fmt.Errorf("failed to write log archive file: %w", err)
+	}
+
+	return nil
+}
+
+func (s *DirectoryLogStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	file, err := os.Open(filepath.Join(s.Path, filepath.FromSlash(key)))
+	if os.IsNotExist(err) {
+		return nil, ErrLogsNotFound
+	}
+
+	return file, err
+}
+
+func (s *DirectoryLogStore) List(ctx context.Context, prefix string) ([]LogObject, error) {
+	// Only the directory holding the prefix can contain matching files
+	dir := filepath.Join(s.Path, filepath.FromSlash(path.Dir(prefix+"_")))
+
+	objects := []LogObject{}
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Temporary files are hidden until they're complete
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.Path, file)
+		if err != nil {
+			return err
+		}
+
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			objects = append(objects, LogObject{Key: key, LastModified: info.ModTime()})
+		}
+
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list log archive directory: %w", err)
+	}
+
+	return objects, nil
+}
+
+// LogMetadata describes the console that archived logs were produced by, and is
+// written as a header before the logs themselves
+type LogMetadata struct {
+	Console     string
+	Namespace   string
+	UID         string
+	User        string
+	Template    string
+	Command     []string
+	Reason      string
+	Pod         string
+	Container   string
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+	ArchivedAt  time.Time
+}
+
+// Header keys, in the order that they're written
+const (
+	headerConsole     = "Console"
+	headerNamespace   = "Namespace"
+	headerUID         = "Uid"
+	headerUser        = "User"
+	headerTemplate    = "Template"
+	headerCommand     = "Command"
+	headerReason      = "Reason"
+	headerPod         = "Pod"
+	headerContainer   = "Container"
+	headerCreatedAt   = "Created-At"
+	headerCompletedAt = "Completed-At"
+	headerArchivedAt  = "Archived-At"
+)
+
+// WriteLogs writes logs to w, gzipped, preceded by a header containing the
+// metadata. The header is a block of "Key: value" lines terminated by a blank
+// line, so that archives remain readable with zcat.
+func WriteLogs(w io.Writer, meta LogMetadata, logs io.Reader) error {
+	gz := gzip.NewWriter(w)
+	gz.Name = meta.Console + ".log"
+	gz.ModTime = meta.ArchivedAt
+
+	command, err := json.Marshal(meta.Command)
+	if err != nil {
+		return fmt.Errorf("failed to encode command: %w", err)
+	}
+
+	header := [][2]string{
+		{headerConsole, meta.Console},
+		{headerNamespace, meta.Namespace},
+		{headerUID, meta.UID},
+		{headerUser, meta.User},
+		{headerTemplate, meta.Template},
+		{headerCommand, string(command)},
+		// Reasons are free text, so may span several lines
+		{headerReason, strings.Join(strings.Fields(meta.Reason), " ")},
+		{headerPod, meta.Pod},
+		{headerContainer, meta.Container},
+		{headerCreatedAt, meta.CreatedAt.UTC().Format(time.RFC3339)},
+	}
+	if meta.CompletedAt != nil {
+		header = append(header, [2]string{headerCompletedAt, meta.CompletedAt.UTC().Format(time.RFC3339)})
+	}
+	header = append(header, [2]string{headerArchivedAt, meta.ArchivedAt.UTC().Format(time.RFC3339)})
+
+	for _, field := range header {
+		if _, err := fmt.Fprintf(gz, "%s: %s\n", field[0], field[1]); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprint(gz, "\n"); err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(gz, logs); err != nil {
+		return fmt.Errorf("failed to write logs: %w", err)
+	}
+
+	return gz.Close()
+}
+
+// ReadLogs reads logs written by WriteLogs, returning their metadata and a reader
+// for the logs that follow it
+func ReadLogs(r io.Reader) (*LogMetadata, io.Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decompress logs: %w", err)
+	}
+
+	buf := bufio.NewReader(gz)
+	header, err := textproto.NewReader(buf).ReadMIMEHeader()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read log header: %w", err)
+	}
+
+	meta := &LogMetadata{
+		Console:   header.Get(headerConsole),
+		Namespace: header.Get(headerNamespace),
+		UID:       header.Get(headerUID),
+		User:      header.Get(headerUser),
+		Template:  header.Get(headerTemplate),
+		Reason:    header.Get(headerReason),
+		Pod:       header.Get(headerPod),
+		Container: header.Get(headerContainer),
+	}
+
+	if err := json.Unmarshal([]byte(header.Get(headerCommand)), &meta.Command); err != nil {
+		return nil, nil, fmt.Errorf("failed to read log header: invalid command: %w", err)
+	}
+
+	for key, dst := range map[string]*time.Time{
+		headerCreatedAt:  &meta.CreatedAt,
+		headerArchivedAt: &meta.ArchivedAt,
+	} {
+		if *dst, err = time.Parse(time.RFC3339, header.Get(key)); err != nil {
+			return nil, nil, fmt.Errorf("failed to read log header: invalid %s: %w", key, err)
+		}
+	}
+
+	if value := header.Get(headerCompletedAt); value != "" {
+		completedAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read log header: invalid %s: %w", headerCompletedAt, err)
+		}
+		meta.CompletedAt = &completedAt
+	}
+
+	return meta, buf, nil
+}
@@ -0,0 +1,247 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Logs", func() {
+	var (
+		meta LogMetadata
+		data []byte
+	)
+
+	BeforeEach(func() {
+		completedAt := time.Date(2020, 1, 1, 12, 30, 0, 0, time.UTC)
+		meta = LogMetadata{
+			Console:     "console-abcde",
+			Namespace:   "default",
+			UID:         "0a1b2c3d",
+			User:        "alice@example.com",
+			Template:    "console-template",
+			Command:     []string{"bin/rails", "runner", "puts 'hello world'"},
+			Reason:      "Investigating\nan incident",
+			Pod:         "console-abcde-console-xyz",
+			Container:   "app",
+			CreatedAt:   time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC),
+			CompletedAt: &completedAt,
+			ArchivedAt:  time.Date(2020, 1, 1, 12, 31, 0, 0, time.UTC),
+		}
+
+		var buf bytes.Buffer
+		Expect(WriteLogs(&buf, meta, strings.NewReader("hello world\n"))).To(Succeed())
+		data = buf.Bytes()
+	})
+
+	It("reads the metadata and logs that were written", func() {
+		readMeta, logs, err := ReadLogs(bytes.NewReader(data))
+		Expect(err).NotTo(HaveOccurred())
+
+		meta.Reason = "Investigating an incident"
+		Expect(readMeta).To(Equal(&meta))
+
+		content, err := ioutil.ReadAll(logs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal("hello world\n"))
+	})
+
+	Context("with a directory store", func() {
+		var (
+			dir   string
+			store LogStore
+		)
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "log-archive-")
+			Expect(err).NotTo(HaveOccurred())
+
+			store, err = NewLogStore("file://" + dir)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("gets the logs that were put", func() {
+			key := LogKey("default", "console-abcde", "0a1b2c3d")
+			Expect(store.Put(context.TODO(), key, bytes.NewReader(data), int64(len(data)))).To(Succeed())
+
+			object, err := store.Get(context.TODO(), key)
+			Expect(err).NotTo(HaveOccurred())
+			defer object.Close()
+
+			Expect(ioutil.ReadAll(object)).To(Equal(data))
+		})
+
+		It("returns ErrLogsNotFound for missing logs", func() {
+			_, err := store.Get(context.TODO(), LogKey("default", "missing", "0a1b2c3d"))
+			Expect(err).To(Equal(ErrLogsNotFound))
+		})
+
+		It("finds the most recently archived logs of consoles with a name", func() {
+			for idx, uid := range []string{"0a1b2c3d", "4e5f6a7b"} {
+				key := LogKey("default", "console-abcde", uid)
+				Expect(store.Put(context.TODO(), key, bytes.NewReader(data), int64(len(data)))).To(Succeed())
+
+				modTime := time.Date(2020, 1, 1, 12, idx, 0, 0, time.UTC)
+				Expect(os.Chtimes(filepath.Join(dir, filepath.FromSlash(key)), modTime, modTime)).To(Succeed())
+			}
+
+			other := LogKey("default", "console-abcdef", "8c9d0e1f")
+			Expect(store.Put(context.TODO(), other, bytes.NewReader(data), int64(len(data)))).To(Succeed())
+
+			Expect(FindLogs(context.TODO(), store, "default", "console-abcde")).
+				To(Equal("default/console-abcde/4e5f6a7b.log.gz"))
+		})
+
+		It("returns ErrLogsNotFound when no consoles with a name were archived", func() {
+			_, err := FindLogs(context.TODO(), store, "default", "missing")
+			Expect(err).To(Equal(ErrLogsNotFound))
+		})
+	})
+
+	Context("with an S3 store", func() {
+		var (
+			server  *httptest.Server
+			store   LogStore
+			objects  map[string][]byte
+			modified map[string]time.Time
+			mu       sync.Mutex
+		)
+
+		// list responds to ListObjectsV2 requests with one object per page, so that
+		// continuation is exercised
+		list := func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal("/bucket"))
+
+			keys := []string{}
+			for objectPath := range objects {
+				key := strings.TrimPrefix(objectPath, "/bucket/")
+				if strings.HasPrefix(key, r.URL.Query().Get("prefix")) {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+
+			start := 0
+			if token := r.URL.Query().Get("continuation-token"); token != "" {
+				start, _ = strconv.Atoi(token)
+			}
+
+			result := listBucketResult{}
+			if start < len(keys) {
+				result.Contents = append(result.Contents, struct {
+					Key          string
+					LastModified time.Time
+				}{keys[start], modified["/bucket/"+keys[start]]})
+			}
+			if start+1 < len(keys) {
+				result.IsTruncated = true
+				result.NextContinuationToken = strconv.Itoa(start + 1)
+			}
+
+			Expect(xml.NewEncoder(w).Encode(result)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			objects = map[string][]byte{}
+			modified = map[string]time.Time{}
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+
+				Expect(r.Header.Get("Authorization")).To(HavePrefix("AWS4-HMAC-SHA256 Credential=access-key/"))
+				Expect(r.Header.Get("Authorization")).To(ContainSubstring("/eu-west-1/s3/aws4_request"))
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				switch r.Method {
+				case http.MethodPut:
+					body, err := ioutil.ReadAll(r.Body)
+					Expect(err).NotTo(HaveOccurred())
+
+					hash := sha256.Sum256(body)
+					Expect(r.Header.Get("X-Amz-Content-Sha256")).To(Equal(hex.EncodeToString(hash[:])))
+
+					objects[r.URL.Path] = body
+					modified[r.URL.Path] = time.Date(2020, 1, 1, 12, len(objects), 0, 0, time.UTC)
+				case http.MethodGet:
+					if r.URL.Query().Get("list-type") == "2" {
+						list(w, r)
+						return
+					}
+
+					body, ok := objects[r.URL.Path]
+					if !ok {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+
+					w.Write(body)
+				}
+			}))
+
+			os.Setenv("AWS_ACCESS_KEY_ID", "access-key")
+			os.Setenv("AWS_SECRET_ACCESS_KEY", "secret-key")
+
+			var err error
+			store, err = NewLogStore("s3://bucket/consoles?region=eu-west-1&endpoint=" + url.QueryEscape(server.URL))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+			os.Unsetenv("AWS_ACCESS_KEY_ID")
+			os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+		})
+
+		It("stores objects under the bucket and prefix", func() {
+			key := LogKey("default", "console-abcde", "0a1b2c3d")
+			Expect(store.Put(context.TODO(), key, bytes.NewReader(data), int64(len(data)))).To(Succeed())
+			Expect(objects).To(HaveKey("/bucket/consoles/default/console-abcde/0a1b2c3d.log.gz"))
+
+			object, err := store.Get(context.TODO(), key)
+			Expect(err).NotTo(HaveOccurred())
+			defer object.Close()
+
+			Expect(ioutil.ReadAll(object)).To(Equal(data))
+		})
+
+		It("returns ErrLogsNotFound for missing logs", func() {
+			_, err := store.Get(context.TODO(), LogKey("default", "missing", "0a1b2c3d"))
+			Expect(err).To(Equal(ErrLogsNotFound))
+		})
+
+		It("finds the most recently archived logs of consoles with a name", func() {
+			for _, key := range []string{
+				LogKey("default", "console-abcde", "4e5f6a7b"),
+				LogKey("default", "console-abcdef", "8c9d0e1f"),
+				LogKey("default", "console-abcde", "0a1b2c3d"),
+			} {
+				Expect(store.Put(context.TODO(), key, bytes.NewReader(data), int64(len(data)))).To(Succeed())
+			}
+
+			Expect(FindLogs(context.TODO(), store, "default", "console-abcde")).
+				To(Equal("default/console-abcde/0a1b2c3d.log.gz"))
+		})
+	})
+})
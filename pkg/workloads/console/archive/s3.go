@@ -0,0 +1,347 @@
+package archive
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3LogStore stores logs in a bucket of an S3-compatible API, such as AWS S3,
+// GCS in interoperability mode or MinIO. Objects are addressed path-style, i.e.
+// <endpoint>/<bucket>/<key>, as this is supported by every implementation, and
+// requests are signed with AWS Signature Version 4.
+type S3LogStore struct {
+	// Endpoint is the base URL of the API, e.g. https://s3.eu-west-1.amazonaws.com
+	Endpoint        string
+	Region          string
+	Bucket          string
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is only required for temporary credentials
+	SessionToken string
+
+	HTTPClient *http.Client
+}
+
+var _ LogStore = &S3LogStore{}
+
+// NewS3LogStore configures a store from a URL of the form
+// s3://<bucket>/<prefix>?region=<region>&endpoint=<url>. The region defaults to
+// AWS_REGION, or us-east-1 if that isn't set, and the endpoint defaults to AWS S3
+// in that region. Credentials are taken from the standard AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables.
+func NewS3LogStore(u *url.URL) (*S3LogStore, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("log archive location %q has no bucket", u.String())
+	}
+
+	query := u.Query()
+
+	region := query.Get("region")
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	endpoint := query.Get("endpoint")
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+
+	store := &S3LogStore{
+		Endpoint:        strings.TrimSuffix(endpoint, "/"),
+		Region:          region,
+		Bucket:          u.Host,
+		Prefix:          strings.Trim(u.Path, "/"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+
+	if store.AccessKeyID == "" || store.SecretAccessKey == "" {
+		return nil, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set to archive logs to S3")
+	}
+
+	return store, nil
+}
+
+func (s *S3LogStore) Put(ctx context.Context, key string, body io.ReadSeeker, size int64) error {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, body); err != nil {
+		return fmt.Errorf("failed to read logs: %w", err)
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read logs: %w", err)
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPut, s.objectKey(key), nil, body, hex.EncodeToString(hash.Sum(nil)))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/gzip")
+
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload logs: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to upload logs: %w", responseError(resp))
+	}
+
+	return nil
+}
+
+func (s *S3LogStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, s.objectKey(key), nil, nil, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download logs: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrLogsNotFound
+	}
+
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		return nil, fmt.Errorf("failed to download logs: %w", responseError(resp))
+	}
+
+	return resp.Body, nil
+}
+
+// listBucketResult is the response to ListObjectsV2, as described in
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListObjectsV2.html
+type listBucketResult struct {
+	Contents []struct {
+		Key          string
+		LastModified time.Time
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+func (s *S3LogStore) List(ctx context.Context, prefix string) ([]LogObject, error) {
+	objects := []LogObject{}
+	query := url.Values{"list-type": {"2"}, "prefix": {s.objectKey(prefix)}}
+
+	for {
+		req, err := s.newRequest(ctx, http.MethodGet, "", query, nil, emptyPayloadHash)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := s.list(req)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, object := range result.Contents {
+			objects = append(objects, LogObject{
+				Key:          strings.TrimPrefix(object.Key, s.objectKey("")),
+				LastModified: object.LastModified,
+			})
+		}
+
+		if !result.IsTruncated {
+			return objects, nil
+		}
+
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+func (s *S3LogStore) list(req *http.Request) (*listBucketResult, error) {
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list logs: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("failed to list logs: %w", responseError(resp))
+	}
+
+	result := &listBucketResult{}
+	if err := xml.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, fmt.Errorf("failed to list logs: %w", err)
+	}
+
+	return result, nil
+}
+
+// objectKey is the key of an object in the bucket, below the prefix
+func (s *S3LogStore) objectKey(key string) string {
+	if s.Prefix == "" {
+		return key
+	}
+
+	return s.Prefix + "/" + key
+}
+
+func (s *S3LogStore) httpClient() *http.Client {
+	if s.HTTPClient != nil {
+		return s.HTTPClient
+	}
+
+	return http.DefaultClient
+}
+
+// emptyPayloadHash is the hash of requests without a body
+var emptyPayloadHash = hex.EncodeToString(sha256.New().Sum(nil))
+
+// newRequest builds a request for an object, or the bucket when the key is empty,
+// signed as described in
+// https://docs.aws.amazon.com/general/latest/gr/sigv4_signing.html
+func (s *S3LogStore) newRequest(ctx context.Context, method, key string, query url.Values, body io.Reader, payloadHash string) (*http.Request, error) {
+	objectPath := "/" + path.Join(s.Bucket, key)
+
+	u, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint %q: %w", s.Endpoint, err)
+	}
+	u.Path = path.Join("/", u.Path, objectPath)
+	u.RawPath = uriEncode(u.Path)
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	headers := map[string]string{"host": u.Host}
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.RawPath,
+		u.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, s.Region, "s3", "aws4_request"}, "/")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	signingKey := []byte("AWS4" + s.SecretAccessKey)
+	for _, part := range []string{date, s.Region, "s3", "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, signedHeaders, signature,
+	))
+
+	return req, nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// uriEncode escapes every byte of a path other than unreserved characters and
+// slashes, as required for canonical requests
+func uriEncode(p string) string {
+	var encoded strings.Builder
+	for _, b := range []byte(p) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+			b == '-', b == '_', b == '.', b == '~', b == '/':
+			encoded.WriteByte(b)
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+
+	return encoded.String()
+}
+
+// canonicalQuery encodes query parameters sorted by name, escaping slashes too,
+// as required for canonical requests
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := []string{}
+	for _, name := range names {
+		for _, value := range query[name] {
+			params = append(params, queryEncode(name)+"="+queryEncode(value))
+		}
+	}
+
+	return strings.Join(params, "&")
+}
+
+func queryEncode(value string) string {
+	return strings.Replace(uriEncode(value), "/", "%2F", -1)
+}
+
+// responseError describes an unsuccessful response, including the start of the
+// body, which contains the error code and message
+func responseError(resp *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
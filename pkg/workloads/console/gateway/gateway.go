@@ -0,0 +1,462 @@
+// Package gateway provides an HTTP interface for reviewing and authorising consoles,
+// for approvers who don't have direct access to the Kubernetes API.
+//
+// The gateway expects to run behind an authenticating proxy, which identifies the
+// user by setting a request header. Every request to the Kubernetes API is made while
+// impersonating that user, so RBAC and the console authorisation webhook apply
+// exactly as they would if the user were using theatre-consoles directly. As anybody
+// who can set the header can act as any user, only requests from trusted proxies are
+// served.
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	"github.com/gocardless/theatre/v2/pkg/client/clientset/versioned"
+	"github.com/gocardless/theatre/v2/pkg/workloads/console/runner"
+)
+
+// DefaultUserHeader is set by common authenticating proxies, such as oauth2-proxy
+const DefaultUserHeader = "X-Forwarded-Email"
+
+// Options configures the gateway
+type Options struct {
+	// Header containing the identity of the authenticated user, as known to the
+	// Kubernetes API
+	UserHeader string
+	// Networks that requests must come from, as only the authenticating proxy can be
+	// trusted to set the user header. Defaults to loopback addresses, for a proxy
+	// running alongside the gateway in its pod.
+	TrustedProxies []*net.IPNet
+	// If set, the only users that the gateway will impersonate. These should match the
+	// resourceNames that the gateway is permitted to impersonate.
+	AllowedUsers []string
+}
+
+// WithDefaults sets any unset options to defaults
+func (opts Options) WithDefaults() Options {
+	if opts.UserHeader == "" {
+		opts.UserHeader = DefaultUserHeader
+	}
+
+	if len(opts.TrustedProxies) == 0 {
+		for _, cidr := range []string{"127.0.0.0/8", "::1/128"} {
+			_, network, _ := net.ParseCIDR(cidr)
+			opts.TrustedProxies = append(opts.TrustedProxies, network)
+		}
+	}
+
+	return opts
+}
+
+// Server serves the gateway, and implements http.Handler
+type Server struct {
+	config *rest.Config
+	logger logr.Logger
+	opts   Options
+}
+
+var _ http.Handler = &Server{}
+
+// New creates a gateway that will use the given configuration, impersonating the
+// requesting user, to talk to the Kubernetes API. The identity in the configuration
+// must be permitted to impersonate users.
+func New(config *rest.Config, logger logr.Logger, opts Options) *Server {
+	return &Server{
+		config: config,
+		logger: logger,
+		opts:   opts.WithDefaults(),
+	}
+}
+
+// Console summarises a console for review
+type Console struct {
+	Name           string                         `json:"name"`
+	Namespace      string                         `json:"namespace"`
+	User           string                         `json:"user"`
+	Reason         string                         `json:"reason"`
+	Command        []string                       `json:"command"`
+	Phase          workloadsv1alpha1.ConsolePhase `json:"phase"`
+	CreatedAt      time.Time                      `json:"createdAt"`
+	StartAfter     *time.Time                     `json:"startAfter,omitempty"`
+	Authorisations []string                       `json:"authorisations,omitempty"`
+	// Authorisers lists the subjects that may authorise the console, and
+	// AuthorisationsRequired how many of them must do so. These are only populated
+	// when viewing an individual console.
+	Authorisers            []string `json:"authorisers,omitempty"`
+	AuthorisationsRequired int      `json:"authorisationsRequired,omitempty"`
+}
+
+func newConsole(csl workloadsv1alpha1.Console) Console {
+	console := Console{
+		Name:      csl.Name,
+		Namespace: csl.Namespace,
+		User:      csl.Spec.User,
+		Reason:    csl.Spec.Reason,
+		Command:   csl.Spec.Command,
+		Phase:     csl.Status.Phase,
+		CreatedAt: csl.CreationTimestamp.Time,
+	}
+
+	if csl.Spec.StartAfter != nil {
+		console.StartAfter = &csl.Spec.StartAfter.Time
+	}
+
+	return console
+}
+
+// ServeHTTP routes requests:
+//
+//	GET  /                                      lists consoles pending authorisation
+//	GET  /consoles/<namespace>/<name>           shows a console
+//	POST /consoles/<namespace>/<name>/authorise authorises a console
+//	POST /consoles/<namespace>/<name>/reject    rejects a console, deleting it
+//
+// Responses are rendered as HTML, unless the request accepts application/json.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.fromTrustedProxy(r) {
+		s.logger.Info("request from untrusted address", "event", "request.untrusted", "remote_addr", r.RemoteAddr)
+		http.Error(w, "requests must be made through the authenticating proxy", http.StatusForbidden)
+		return
+	}
+
+	user := r.Header.Get(s.opts.UserHeader)
+	if user == "" {
+		http.Error(w, "no authenticated user", http.StatusUnauthorized)
+		return
+	}
+
+	if !s.mayImpersonate(user) {
+		http.Error(w, fmt.Sprintf("user %s may not use the gateway", user), http.StatusForbidden)
+		return
+	}
+
+	logger := s.logger.WithValues("user", user, "method", r.Method, "path", r.URL.Path)
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.URL.Path == "/" && r.Method == http.MethodGet:
+		s.list(w, r, logger, user)
+	case len(segments) == 3 && segments[0] == "consoles" && r.Method == http.MethodGet:
+		s.show(w, r, logger, user, segments[1], segments[2])
+	case len(segments) == 4 && segments[0] == "consoles" && r.Method == http.MethodPost:
+		if !sameOrigin(r) {
+			http.Error(w, "cross-origin requests are not permitted", http.StatusForbidden)
+			return
+		}
+
+		switch segments[3] {
+		case "authorise":
+			s.authorise(w, r, logger, user, segments[1], segments[2])
+		case "reject":
+			s.reject(w, r, logger, user, segments[1], segments[2])
+		default:
+			http.NotFound(w, r)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request, logger logr.Logger, user string) {
+	consoleRunner, _, err := s.clientsFor(user)
+	if err != nil {
+		s.error(w, logger, err)
+		return
+	}
+
+	csls, err := consoleRunner.ListConsolesByLabelsAndUser(r.Context(), r.URL.Query().Get("namespace"), "", "")
+	if err != nil {
+		s.error(w, logger, err)
+		return
+	}
+
+	consoles := []Console{}
+	for _, csl := range csls {
+		if csl.Status.Phase == workloadsv1alpha1.ConsolePendingAuthorisation {
+			consoles = append(consoles, newConsole(csl))
+		}
+	}
+
+	sort.Slice(consoles, func(i, j int) bool {
+		return consoles[i].CreatedAt.Before(consoles[j].CreatedAt)
+	})
+
+	s.render(w, r, logger, listTemplate, consoles)
+}
+
+func (s *Server) show(w http.ResponseWriter, r *http.Request, logger logr.Logger, user, namespace, name string) {
+	consoleRunner, theatreClient, err := s.clientsFor(user)
+	if err != nil {
+		s.error(w, logger, err)
+		return
+	}
+
+	csl, err := consoleRunner.Get(r.Context(), runner.GetOptions{Namespace: namespace, ConsoleName: name})
+	if err != nil {
+		s.error(w, logger, err)
+		return
+	}
+
+	console := newConsole(*csl)
+
+	auth, err := theatreClient.WorkloadsV1alpha1().ConsoleAuthorisations(namespace).Get(r.Context(), name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		s.error(w, logger, err)
+		return
+	}
+
+	if err == nil {
+		for _, subject := range auth.Spec.Authorisations {
+			console.Authorisations = append(console.Authorisations, subject.Name)
+		}
+	}
+
+	// Not every approver will be able to read the template, so we show what we can
+	tpl, err := theatreClient.WorkloadsV1alpha1().ConsoleTemplates(namespace).Get(r.Context(), csl.Spec.ConsoleTemplateRef.Name, metav1.GetOptions{})
+	if err == nil {
+		if rule, err := tpl.GetAuthorisationRule(csl.Spec.Command, csl.Spec.Resources); err == nil && rule != nil {
+			console.AuthorisationsRequired = rule.AuthorisationsRequired
+			for _, subject := range rule.Subjects {
+				console.Authorisers = append(console.Authorisers, subject.Kind+":"+subject.Name)
+			}
+		}
+	}
+
+	s.render(w, r, logger, showTemplate, console)
+}
+
+func (s *Server) authorise(w http.ResponseWriter, r *http.Request, logger logr.Logger, user, namespace, name string) {
+	consoleRunner, _, err := s.clientsFor(user)
+	if err != nil {
+		s.error(w, logger, err)
+		return
+	}
+
+	err = consoleRunner.Authorise(r.Context(), runner.AuthoriseOptions{
+		Namespace:   namespace,
+		ConsoleName: name,
+		Username:    user,
+	})
+	if err != nil {
+		s.error(w, logger, err)
+		return
+	}
+
+	logger.Info("authorised console", "event", "console.authorise", "console", name, "namespace", namespace)
+	s.redirect(w, r, consolePath(namespace, name))
+}
+
+func (s *Server) reject(w http.ResponseWriter, r *http.Request, logger logr.Logger, user, namespace, name string) {
+	_, theatreClient, err := s.clientsFor(user)
+	if err != nil {
+		s.error(w, logger, err)
+		return
+	}
+
+	err = theatreClient.WorkloadsV1alpha1().Consoles(namespace).Delete(r.Context(), name, metav1.DeleteOptions{})
+	if err != nil {
+		s.error(w, logger, err)
+		return
+	}
+
+	logger.Info("rejected console", "event", "console.reject", "console", name, "namespace", namespace)
+	s.redirect(w, r, "/")
+}
+
+// fromTrustedProxy returns true if the request came from one of our trusted proxies
+func (s *Server) fromTrustedProxy(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range s.opts.TrustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mayImpersonate returns true if the user is one the gateway may act as. System
+// users, including service accounts, are never impersonated, as they aren't people
+// who review consoles.
+func (s *Server) mayImpersonate(user string) bool {
+	if strings.HasPrefix(user, "system:") {
+		return false
+	}
+
+	if len(s.opts.AllowedUsers) == 0 {
+		return true
+	}
+
+	for _, allowed := range s.opts.AllowedUsers {
+		if user == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clientsFor builds clients that impersonate the given user
+func (s *Server) clientsFor(user string) (*runner.Runner, versioned.Interface, error) {
+	config := rest.CopyConfig(s.config)
+	config.Impersonate = rest.ImpersonationConfig{UserName: user}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	theatreClient, err := versioned.NewForConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return runner.NewFromClients(clientset, theatreClient), theatreClient, nil
+}
+
+// error responds with the status of errors returned by the Kubernetes API, including
+// any denial from the console authorisation webhook, so the user can see why their
+// request failed.
+func (s *Server) error(w http.ResponseWriter, logger logr.Logger, err error) {
+	code := http.StatusInternalServerError
+
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && status.Status().Code != 0 {
+		code = int(status.Status().Code)
+	}
+
+	logger.Info("request failed", "event", "request.error", "code", code, "error", err)
+	http.Error(w, err.Error(), code)
+}
+
+func (s *Server) render(w http.ResponseWriter, r *http.Request, logger logr.Logger, tpl *template.Template, data interface{}) {
+	if acceptsJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			logger.Error(err, "failed to encode response")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := tpl.Execute(w, data); err != nil {
+		logger.Error(err, "failed to render template")
+	}
+}
+
+func (s *Server) redirect(w http.ResponseWriter, r *http.Request, location string) {
+	if acceptsJSON(r) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	http.Redirect(w, r, location, http.StatusSeeOther)
+}
+
+func acceptsJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+// sameOrigin protects against cross-site request forgery: as the authenticating proxy
+// will usually rely on cookies, a form on another site could otherwise submit
+// authorisations on behalf of a user. Browsers send an Origin or Referer header, so
+// requests with neither are refused, and non-browser clients must set one.
+func sameOrigin(r *http.Request) bool {
+	source := r.Header.Get("Origin")
+	if source == "" {
+		source = r.Header.Get("Referer")
+	}
+	if source == "" {
+		return false
+	}
+
+	u, err := url.Parse(source)
+	if err != nil {
+		return false
+	}
+
+	return u.Host != "" && u.Host == r.Host
+}
+
+func consolePath(namespace, name string) string {
+	return fmt.Sprintf("/consoles/%s/%s", url.PathEscape(namespace), url.PathEscape(name))
+}
+
+var funcs = template.FuncMap{
+	"consolePath": consolePath,
+	"join":        strings.Join,
+}
+
+var listTemplate = template.Must(template.New("list").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html>
+<head><title>Consoles pending authorisation</title></head>
+<body>
+<h1>Consoles pending authorisation</h1>
+{{ if . }}
+<table>
+<tr><th>Namespace</th><th>Name</th><th>User</th><th>Reason</th><th>Command</th><th>Created</th></tr>
+{{ range . }}
+<tr>
+<td>{{ .Namespace }}</td>
+<td><a href="{{ consolePath .Namespace .Name }}">{{ .Name }}</a></td>
+<td>{{ .User }}</td>
+<td>{{ .Reason }}</td>
+<td><code>{{ join .Command " " }}</code></td>
+<td>{{ .CreatedAt }}</td>
+</tr>
+{{ end }}
+</table>
+{{ else }}
+<p>There are no consoles pending authorisation.</p>
+{{ end }}
+</body>
+</html>
+`))
+
+var showTemplate = template.Must(template.New("show").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html>
+<head><title>Console {{ .Namespace }}/{{ .Name }}</title></head>
+<body>
+<h1>Console {{ .Namespace }}/{{ .Name }}</h1>
+<dl>
+<dt>User</dt><dd>{{ .User }}</dd>
+<dt>Reason</dt><dd>{{ .Reason }}</dd>
+<dt>Command</dt><dd><code>{{ join .Command " " }}</code></dd>
+<dt>Phase</dt><dd>{{ .Phase }}</dd>
+<dt>Created</dt><dd>{{ .CreatedAt }}</dd>
+{{ if .StartAfter }}<dt>Scheduled to start</dt><dd>{{ .StartAfter }}</dd>{{ end }}
+{{ if .Authorisers }}<dt>Authorisers</dt><dd>{{ .AuthorisationsRequired }} required from {{ join .Authorisers ", " }}</dd>{{ end }}
+<dt>Authorised by</dt><dd>{{ if .Authorisations }}{{ join .Authorisations ", " }}{{ else }}nobody yet{{ end }}</dd>
+</dl>
+<form method="post" action="{{ consolePath .Namespace .Name }}/authorise"><button type="submit">Authorise</button></form>
+<form method="post" action="{{ consolePath .Namespace .Name }}/reject"><button type="submit">Reject</button></form>
+<p><a href="/">Back to pending consoles</a></p>
+</body>
+</html>
+`))
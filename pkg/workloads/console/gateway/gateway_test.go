@@ -0,0 +1,114 @@
+package gateway
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		opts Options
+		req  *http.Request
+		rec  *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		opts = Options{}
+		req = httptest.NewRequest(http.MethodPost, "/consoles/staging/console/authorise", nil)
+		req.RemoteAddr = "127.0.0.1:41234"
+		req.Header.Set(DefaultUserHeader, "authoriser@example.com")
+		req.Header.Set("Origin", "http://example.com")
+	})
+
+	JustBeforeEach(func() {
+		// Requests that pass our checks fail to reach this API server
+		server := New(&rest.Config{Host: "http://127.0.0.1:1"}, zap.LoggerTo(GinkgoWriter, true), opts)
+
+		rec = httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+	})
+
+	It("Serves requests from the proxy", func() {
+		Expect(rec.Code).To(Equal(http.StatusInternalServerError))
+	})
+
+	Context("From an untrusted address", func() {
+		BeforeEach(func() {
+			req.RemoteAddr = "10.0.0.1:41234"
+		})
+
+		It("Refuses the request", func() {
+			Expect(rec.Code).To(Equal(http.StatusForbidden))
+			Expect(rec.Body.String()).To(ContainSubstring("must be made through the authenticating proxy"))
+		})
+
+		Context("That is a trusted proxy", func() {
+			BeforeEach(func() {
+				_, network, _ := net.ParseCIDR("10.0.0.0/24")
+				opts.TrustedProxies = []*net.IPNet{network}
+			})
+
+			It("Serves the request", func() {
+				Expect(rec.Code).To(Equal(http.StatusInternalServerError))
+			})
+		})
+	})
+
+	Context("For a system user", func() {
+		BeforeEach(func() {
+			req.Header.Set(DefaultUserHeader, "system:serviceaccount:kube-system:default")
+		})
+
+		It("Refuses the request", func() {
+			Expect(rec.Code).To(Equal(http.StatusForbidden))
+		})
+	})
+
+	Context("With allowed users", func() {
+		BeforeEach(func() {
+			opts.AllowedUsers = []string{"other@example.com"}
+		})
+
+		It("Refuses other users", func() {
+			Expect(rec.Code).To(Equal(http.StatusForbidden))
+			Expect(rec.Body.String()).To(ContainSubstring("may not use the gateway"))
+		})
+	})
+
+	Context("Without an Origin or Referer", func() {
+		BeforeEach(func() {
+			req.Header.Del("Origin")
+		})
+
+		It("Refuses the request", func() {
+			Expect(rec.Code).To(Equal(http.StatusForbidden))
+			Expect(rec.Body.String()).To(ContainSubstring("cross-origin requests are not permitted"))
+		})
+
+		Context("With a Referer from the same origin", func() {
+			BeforeEach(func() {
+				req.Header.Set("Referer", "http://example.com/consoles/staging/console")
+			})
+
+			It("Serves the request", func() {
+				Expect(rec.Code).To(Equal(http.StatusInternalServerError))
+			})
+		})
+	})
+
+	Context("With an Origin of another site", func() {
+		BeforeEach(func() {
+			req.Header.Set("Origin", "https://attacker.example.com")
+		})
+
+		It("Refuses the request", func() {
+			Expect(rec.Code).To(Equal(http.StatusForbidden))
+		})
+	})
+})
@@ -0,0 +1,161 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	"github.com/gocardless/theatre/v2/pkg/workloads/console/gateway"
+)
+
+const (
+	requester  = "requester@example.com"
+	authoriser = "authoriser@example.com"
+)
+
+var _ = Describe("Gateway", func() {
+	var (
+		server    *gateway.Server
+		namespace string
+		csl       *workloadsv1alpha1.Console
+	)
+
+	request := func(method, path, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = "127.0.0.1:41234"
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Origin", "http://example.com")
+		if user != "" {
+			req.Header.Set(gateway.DefaultUserHeader, user)
+		}
+
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	BeforeEach(func() {
+		server = gateway.New(cfg, ctrl.Log.WithName("gateway"), gateway.Options{})
+		namespace = uuid.New().String()
+
+		By("Creating test namespace: " + namespace)
+		Expect(kubeClient.Create(context.TODO(), &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: namespace},
+		})).To(Succeed())
+
+		By("Creating console template")
+		Expect(kubeClient.Create(context.TODO(), &workloadsv1alpha1.ConsoleTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "template", Namespace: namespace},
+			Spec: workloadsv1alpha1.ConsoleTemplateSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Image: "alpine:latest", Name: "console-container-0"}},
+					},
+				},
+				DefaultAuthorisationRule: &workloadsv1alpha1.ConsoleAuthorisers{
+					AuthorisationsRequired: 1,
+					Subjects:               []rbacv1.Subject{{Kind: "User", Name: authoriser}},
+				},
+			},
+		})).To(Succeed())
+
+		By("Creating console pending authorisation")
+		csl = &workloadsv1alpha1.Console{
+			ObjectMeta: metav1.ObjectMeta{Name: "console", Namespace: namespace},
+			Spec: workloadsv1alpha1.ConsoleSpec{
+				ConsoleTemplateRef: corev1.LocalObjectReference{Name: "template"},
+				Command:            []string{"rails", "console"},
+				Reason:             "investigating an incident",
+			},
+		}
+		Expect(kubeClient.Create(context.TODO(), csl)).To(Succeed())
+
+		csl.Status.Phase = workloadsv1alpha1.ConsolePendingAuthorisation
+		Expect(kubeClient.Update(context.TODO(), csl)).To(Succeed())
+
+		Expect(kubeClient.Create(context.TODO(), &workloadsv1alpha1.ConsoleAuthorisation{
+			ObjectMeta: metav1.ObjectMeta{Name: "console", Namespace: namespace},
+			Spec: workloadsv1alpha1.ConsoleAuthorisationSpec{
+				ConsoleRef:     corev1.LocalObjectReference{Name: "console"},
+				Authorisations: []rbacv1.Subject{},
+			},
+		})).To(Succeed())
+	})
+
+	It("Rejects unauthenticated requests", func() {
+		Expect(request(http.MethodGet, "/", "").Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("Lists consoles pending authorisation", func() {
+		rec := request(http.MethodGet, "/?namespace="+namespace, authoriser)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+
+		var consoles []gateway.Console
+		Expect(json.Unmarshal(rec.Body.Bytes(), &consoles)).To(Succeed())
+		Expect(consoles).To(HaveLen(1))
+		Expect(consoles[0].Name).To(Equal("console"))
+		Expect(consoles[0].User).To(Equal(requester))
+		Expect(consoles[0].Reason).To(Equal("investigating an incident"))
+		Expect(consoles[0].Command).To(Equal([]string{"rails", "console"}))
+	})
+
+	It("Shows who may authorise a console", func() {
+		rec := request(http.MethodGet, "/consoles/"+namespace+"/console", authoriser)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+
+		var console gateway.Console
+		Expect(json.Unmarshal(rec.Body.Bytes(), &console)).To(Succeed())
+		Expect(console.AuthorisationsRequired).To(Equal(1))
+		Expect(console.Authorisers).To(ConsistOf("User:" + authoriser))
+	})
+
+	It("Authorises consoles as the requesting user", func() {
+		rec := request(http.MethodPost, "/consoles/"+namespace+"/console/authorise", authoriser)
+		Expect(rec.Code).To(Equal(http.StatusNoContent), rec.Body.String())
+
+		auth := &workloadsv1alpha1.ConsoleAuthorisation{}
+		Expect(kubeClient.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: "console"}, auth)).To(Succeed())
+		Expect(auth.Spec.Authorisations).To(ConsistOf(
+			rbacv1.Subject{Kind: rbacv1.UserKind, Namespace: namespace, Name: authoriser},
+		))
+	})
+
+	It("Surfaces denials from the authorisation webhook", func() {
+		rec := request(http.MethodPost, "/consoles/"+namespace+"/console/authorise", requester)
+		Expect(rec.Code).To(Equal(http.StatusForbidden))
+		Expect(rec.Body.String()).To(ContainSubstring("an authoriser cannot authorise their own console"))
+	})
+
+	It("Refuses cross-origin form submissions", func() {
+		req := httptest.NewRequest(http.MethodPost, "/consoles/"+namespace+"/console/authorise", nil)
+		req.RemoteAddr = "127.0.0.1:41234"
+		req.Header.Set(gateway.DefaultUserHeader, authoriser)
+		req.Header.Set("Origin", "https://attacker.example.com")
+
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+
+		Expect(rec.Code).To(Equal(http.StatusForbidden))
+	})
+
+	It("Rejects consoles by deleting them", func() {
+		rec := request(http.MethodPost, "/consoles/"+namespace+"/console/reject", authoriser)
+		Expect(rec.Code).To(Equal(http.StatusNoContent), rec.Body.String())
+
+		err := kubeClient.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: "console"}, &workloadsv1alpha1.Console{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue(), "console should have been deleted")
+	})
+})
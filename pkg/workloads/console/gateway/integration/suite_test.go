@@ -0,0 +1,139 @@
+package integration
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	rbacv1alpha1 "github.com/gocardless/theatre/v2/apis/rbac/v1alpha1"
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+)
+
+var (
+	cfg        *rest.Config
+	kubeClient client.Client
+	testEnv    *envtest.Environment
+
+	scheme   = runtime.NewScheme()
+	finished = make(chan struct{})
+)
+
+func TestSuite(t *testing.T) {
+	SetDefaultEventuallyTimeout(3 * time.Second)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "pkg/workloads/console/gateway/integration")
+}
+
+var _ = BeforeSuite(func(done Done) {
+	logf.SetLogger(zap.LoggerTo(GinkgoWriter, true))
+
+	By("bootstrapping test environment")
+	testEnv = &envtest.Environment{
+		CRDDirectoryPaths: []string{filepath.Join("..", "..", "..", "..", "..", "config", "base", "crds")},
+		WebhookInstallOptions: envtest.WebhookInstallOptions{
+			DirectoryPaths: []string{filepath.Join("..", "..", "..", "..", "..", "config", "base", "webhooks")},
+		},
+	}
+
+	var err error
+	cfg, err = testEnv.Start()
+	Expect(err).ToNot(HaveOccurred())
+	Expect(cfg).ToNot(BeNil())
+
+	err = clientgoscheme.AddToScheme(scheme)
+	Expect(err).NotTo(HaveOccurred())
+	err = rbacv1alpha1.AddToScheme(scheme)
+	Expect(err).NotTo(HaveOccurred())
+	err = workloadsv1alpha1.AddToScheme(scheme)
+	Expect(err).NotTo(HaveOccurred())
+
+	// Consoles are created by the requesting user, so the authenticator webhook sets
+	// them as the owner.
+	requesterCfg := rest.CopyConfig(cfg)
+	requesterCfg.Impersonate = rest.ImpersonationConfig{UserName: requester}
+
+	kubeClient, err = client.New(requesterCfg, client.Options{Scheme: scheme})
+	Expect(err).NotTo(HaveOccurred(), "could not create client")
+
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:             scheme,
+		MetricsBindAddress: "0",
+
+		Port:    testEnv.WebhookInstallOptions.LocalServingPort,
+		Host:    testEnv.WebhookInstallOptions.LocalServingHost,
+		CertDir: testEnv.WebhookInstallOptions.LocalServingCertDir,
+	})
+	Expect(err).ToNot(HaveOccurred())
+
+	mgr.GetWebhookServer().Register("/mutate-consoles", &admission.Webhook{
+		Handler: workloadsv1alpha1.NewConsoleAuthenticatorWebhook(
+			ctrl.Log.WithName("webhooks").WithName("console-authenticator"),
+			"system:unsecured",
+		),
+	})
+
+	mgr.GetWebhookServer().Register("/validate-consoleauthorisations", &admission.Webhook{
+		Handler: workloadsv1alpha1.NewConsoleAuthorisationWebhook(
+			mgr.GetClient(),
+			ctrl.Log.WithName("webhooks").WithName("console-authorisation"),
+			"system:unsecured",
+		),
+	})
+
+	mgr.GetWebhookServer().Register("/validate-consoles", &admission.Webhook{
+		Handler: workloadsv1alpha1.NewConsoleValidationWebhook(
+			mgr.GetClient(),
+			ctrl.Log.WithName("webhooks").WithName("console-validation"),
+		),
+	})
+
+	mgr.GetWebhookServer().Register("/validate-consoletemplates", &admission.Webhook{
+		Handler: workloadsv1alpha1.NewConsoleTemplateValidationWebhook(
+			mgr.GetAPIReader(),
+			ctrl.Log.WithName("webhooks").WithName("console-template"),
+			workloadsv1alpha1.ConsoleTemplatePolicyOptions{},
+		),
+	})
+
+	mgr.GetWebhookServer().Register("/validate-consoleschedules", &admission.Webhook{
+		Handler: workloadsv1alpha1.NewConsoleScheduleValidationWebhook(
+			ctrl.Log.WithName("webhooks").WithName("console-schedule"),
+		),
+	})
+
+	mgr.GetWebhookServer().Register("/mutate-pods", &admission.Webhook{
+		Handler: workloadsv1alpha1.NewPriorityInjector(
+			mgr.GetClient(),
+			ctrl.Log.WithName("webhooks").WithName("priority-injector"),
+		),
+	})
+
+	go func() {
+		defer GinkgoRecover()
+		err := mgr.Start(finished)
+		Expect(err).ToNot(HaveOccurred(), "failed to run manager")
+	}()
+
+	close(done)
+}, 60)
+
+var _ = AfterSuite(func() {
+	By("tearing down the test environment")
+	close(finished)
+	gexec.KillAndWait(5 * time.Second)
+	err := testEnv.Stop()
+	Expect(err).ToNot(HaveOccurred())
+})
@@ -0,0 +1,337 @@
+package runner
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+)
+
+// CopyDirection describes whether files are copied into or out of a console
+type CopyDirection string
+
+const (
+	CopyToConsole   CopyDirection = "upload"
+	CopyFromConsole CopyDirection = "download"
+)
+
+// CopiedFile describes a single file that has been copied to or from a console, for
+// the purpose of auditing
+type CopiedFile struct {
+	Direction CopyDirection
+	// Path of the file within the console container
+	RemotePath string
+	// Path of the file on the local machine
+	LocalPath string
+	Size      int64
+	SHA256    string
+}
+
+// CopyOptions encapsulates the arguments to copy files to or from a console
+type CopyOptions struct {
+	Namespace  string
+	KubeConfig *rest.Config
+	Name       string
+
+	Direction  CopyDirection
+	LocalPath  string
+	RemotePath string
+
+	// Lifecycle hook to notify of each file that is copied
+	Hook LifecycleHook
+}
+
+// WithDefaults sets any unset options to defaults
+func (opts CopyOptions) WithDefaults() CopyOptions {
+	if opts.Hook == nil {
+		opts.Hook = DefaultLifecycleHook{}
+	}
+
+	return opts
+}
+
+// Copy transfers files between the local machine and a running console, by streaming
+// a tar archive over exec, in the same fashion as `kubectl cp`. This requires `tar` to
+// be present in the console container. The console must have been created from a
+// template that allows file copying.
+//
+// Every file that is copied is reported to the lifecycle hook along with its size and
+// SHA-256 hash, so that data moved in and out of consoles can be audited.
+func (c *Runner) Copy(ctx context.Context, opts CopyOptions) error {
+	opts = opts.WithDefaults()
+
+	csl, err := c.FindConsoleByName(ctx, opts.Namespace, opts.Name)
+	if err != nil {
+		return err
+	}
+
+	tpl, err := c.getConsoleTemplate(ctx, csl)
+	if err != nil {
+		return err
+	}
+
+	if !tpl.Spec.AllowFileCopy {
+		return fmt.Errorf("console template %s does not allow copying files", tpl.Name)
+	}
+
+	if csl.Status.Phase != workloadsv1alpha1.ConsoleRunning {
+		return fmt.Errorf("console is not running, current phase: %s", csl.Status.Phase)
+	}
+
+	pod, containerName, err := c.GetAttachablePod(ctx, csl)
+	if err != nil {
+		return fmt.Errorf("could not find pod to copy files with: %w", err)
+	}
+
+	remotePath := path.Clean(opts.RemotePath)
+	if remotePath == "/" || remotePath == "." || path.Base(remotePath) == ".." {
+		return fmt.Errorf("invalid remote path: %s", opts.RemotePath)
+	}
+
+	audit := func(file CopiedFile) error {
+		file.Direction = opts.Direction
+		return opts.Hook.FileCopied(csl, file)
+	}
+
+	switch opts.Direction {
+	case CopyToConsole:
+		return c.copyToConsole(pod, containerName, opts.KubeConfig, opts.LocalPath, remotePath, audit)
+	case CopyFromConsole:
+		return c.copyFromConsole(pod, containerName, opts.KubeConfig, remotePath, opts.LocalPath, audit)
+	default:
+		return fmt.Errorf("unknown copy direction: %s", opts.Direction)
+	}
+}
+
+// copyToConsole archives the local path, naming entries relative to the parent of the
+// remote path, and extracts the archive in the container.
+func (c *Runner) copyToConsole(pod *corev1.Pod, containerName string, restconfig *rest.Config, localPath, remotePath string, audit func(CopiedFile) error) error {
+	if _, err := os.Stat(localPath); err != nil {
+		return err
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeArchive(writer, localPath, remotePath, audit))
+	}()
+
+	command := []string{"tar", "-xmf", "-", "-C", path.Dir(remotePath)}
+
+	return c.exec(pod, containerName, restconfig, command, reader, ioutil.Discard)
+}
+
+func writeArchive(w io.Writer, localPath, remotePath string, audit func(CopiedFile) error) error {
+	archive := tar.NewWriter(w)
+
+	err := filepath.Walk(localPath, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(localPath, file)
+		if err != nil {
+			return err
+		}
+
+		remoteFile := path.Join(remotePath, filepath.ToSlash(rel))
+
+		// Symlinks and other special files could point anywhere on the local machine,
+		// so we only ever copy the contents of regular files.
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(path.Base(remotePath), filepath.ToSlash(rel))
+
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		hash := sha256.New()
+		size, err := io.Copy(archive, io.TeeReader(f, hash))
+		if err != nil {
+			return err
+		}
+
+		return audit(CopiedFile{
+			RemotePath: remoteFile,
+			LocalPath:  file,
+			Size:       size,
+			SHA256:     hex.EncodeToString(hash.Sum(nil)),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+// copyFromConsole archives the remote path in the container and extracts it locally,
+// placing the contents of the remote path at the local path.
+func (c *Runner) copyFromConsole(pod *corev1.Pod, containerName string, restconfig *rest.Config, remotePath, localPath string, audit func(CopiedFile) error) error {
+	reader, writer := io.Pipe()
+
+	execErr := make(chan error, 1)
+	go func() {
+		command := []string{"tar", "-cf", "-", "-C", path.Dir(remotePath), path.Base(remotePath)}
+		err := c.exec(pod, containerName, restconfig, command, nil, writer)
+		writer.CloseWithError(err)
+		execErr <- err
+	}()
+
+	if err := readArchive(reader, remotePath, localPath, audit); err != nil {
+		// Unblock the exec if we stopped reading part way through the archive
+		reader.CloseWithError(err)
+		<-execErr
+		return err
+	}
+
+	// tar pads the archive beyond the end marker, which we need to consume for the
+	// exec to finish writing
+	io.Copy(ioutil.Discard, reader)
+
+	if err := <-execErr; err != nil {
+		return fmt.Errorf("failed to archive %s in console: %w", remotePath, err)
+	}
+
+	return nil
+}
+
+func readArchive(r io.Reader, remotePath, localPath string, audit func(CopiedFile) error) error {
+	archive := tar.NewReader(r)
+	prefix := path.Base(remotePath)
+
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Entries are named relative to the parent of the remote path, so should all
+		// start with its base name. Anything else could escape the local path, so is
+		// refused rather than written somewhere unexpected.
+		name := path.Clean(header.Name)
+		if name != prefix && !strings.HasPrefix(name, prefix+"/") {
+			return fmt.Errorf("refusing to extract unexpected archive entry: %s", header.Name)
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(name, prefix), "/")
+
+		file := filepath.Join(localPath, filepath.FromSlash(rel))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(file, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+				return err
+			}
+
+			size, sum, err := writeFile(file, os.FileMode(header.Mode).Perm(), archive)
+			if err != nil {
+				return err
+			}
+
+			err = audit(CopiedFile{
+				RemotePath: path.Join(path.Dir(remotePath), name),
+				LocalPath:  file,
+				Size:       size,
+				SHA256:     sum,
+			})
+			if err != nil {
+				return err
+			}
+		default:
+			// As when uploading, we skip anything that isn't a regular file or directory
+			continue
+		}
+	}
+}
+
+func writeFile(file string, mode os.FileMode, r io.Reader) (int64, string, error) {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, hash), r)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return size, hex.EncodeToString(hash.Sum(nil)), f.Close()
+}
+
+// exec runs a command in the console container, streaming the given stdin and stdout
+func (c *Runner) exec(pod *corev1.Pod, containerName string, restconfig *rest.Config, command []string, stdin io.Reader, stdout io.Writer) error {
+	req := c.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.GetNamespace()).
+		Name(pod.GetName()).
+		SubResource("exec")
+
+	req.VersionedParams(
+		&corev1.PodExecOptions{
+			Container: containerName,
+			Command:   command,
+			Stdin:     stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+		},
+		scheme.ParameterCodec,
+	)
+
+	remoteExecutor, err := remotecommand.NewSPDYExecutor(restconfig, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("failed to create SPDY executor: %w", err)
+	}
+
+	var stderr bytes.Buffer
+	err = remoteExecutor.Stream(remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: &stderr,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
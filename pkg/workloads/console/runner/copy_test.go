@@ -0,0 +1,214 @@
+package runner
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+// tarEntry is an entry of an archive built by buildArchive
+type tarEntry struct {
+	name     string
+	typeflag byte
+	content  string
+	linkname string
+}
+
+func buildArchive(entries ...tarEntry) *bytes.Buffer {
+	var buffer bytes.Buffer
+	archive := tar.NewWriter(&buffer)
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:     entry.name,
+			Typeflag: entry.typeflag,
+			Mode:     0644,
+			Size:     int64(len(entry.content)),
+			Linkname: entry.linkname,
+		}
+		if entry.typeflag != tar.TypeReg {
+			header.Size = 0
+		}
+
+		Expect(archive.WriteHeader(header)).To(Succeed())
+		if entry.typeflag == tar.TypeReg {
+			_, err := archive.Write([]byte(entry.content))
+			Expect(err).NotTo(HaveOccurred())
+		}
+	}
+	Expect(archive.Close()).To(Succeed())
+
+	return &buffer
+}
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+var _ = Describe("Copying archives", func() {
+	var (
+		dir    string
+		copied []CopiedFile
+		audit  func(CopiedFile) error
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "theatre-copy-")
+		Expect(err).NotTo(HaveOccurred())
+
+		copied = nil
+		audit = func(file CopiedFile) error {
+			copied = append(copied, file)
+			return nil
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("writeArchive and readArchive", func() {
+		var source, destination string
+
+		BeforeEach(func() {
+			source = filepath.Join(dir, "source")
+			destination = filepath.Join(dir, "destination")
+
+			Expect(os.MkdirAll(filepath.Join(source, "nested"), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(source, "top.txt"), []byte("top"), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(source, "nested", "script.sh"), []byte("echo hi"), 0755)).To(Succeed())
+
+			Expect(ioutil.WriteFile(filepath.Join(dir, "secret"), []byte("secret"), 0600)).To(Succeed())
+			Expect(os.Symlink(filepath.Join(dir, "secret"), filepath.Join(source, "link"))).To(Succeed())
+		})
+
+		It("Round trips regular files and directories, skipping symlinks", func() {
+			var buffer bytes.Buffer
+			Expect(writeArchive(&buffer, source, "/tmp/app", audit)).To(Succeed())
+
+			Expect(copied).To(ConsistOf(
+				CopiedFile{RemotePath: "/tmp/app/nested/script.sh", LocalPath: filepath.Join(source, "nested", "script.sh"), Size: 7, SHA256: sha256Hex("echo hi")},
+				CopiedFile{RemotePath: "/tmp/app/top.txt", LocalPath: filepath.Join(source, "top.txt"), Size: 3, SHA256: sha256Hex("top")},
+			))
+
+			copied = nil
+			Expect(readArchive(&buffer, "/tmp/app", destination, audit)).To(Succeed())
+
+			Expect(copied).To(ConsistOf(
+				CopiedFile{RemotePath: "/tmp/app/nested/script.sh", LocalPath: filepath.Join(destination, "nested", "script.sh"), Size: 7, SHA256: sha256Hex("echo hi")},
+				CopiedFile{RemotePath: "/tmp/app/top.txt", LocalPath: filepath.Join(destination, "top.txt"), Size: 3, SHA256: sha256Hex("top")},
+			))
+
+			content, err := ioutil.ReadFile(filepath.Join(destination, "nested", "script.sh"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("echo hi"))
+
+			info, err := os.Stat(filepath.Join(destination, "nested", "script.sh"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0755)))
+
+			_, err = os.Lstat(filepath.Join(destination, "link"))
+			Expect(os.IsNotExist(err)).To(BeTrue(), "symlink should not have been copied")
+		})
+
+		It("Names entries after the base of the remote path", func() {
+			var buffer bytes.Buffer
+			Expect(writeArchive(&buffer, source, "/tmp/app", audit)).To(Succeed())
+
+			var names []string
+			archive := tar.NewReader(&buffer)
+			for header, err := archive.Next(); err == nil; header, err = archive.Next() {
+				names = append(names, header.Name)
+			}
+
+			Expect(names).To(ConsistOf("app", "app/nested", "app/nested/script.sh", "app/top.txt"))
+		})
+
+		It("Copies a single file", func() {
+			var buffer bytes.Buffer
+			Expect(writeArchive(&buffer, filepath.Join(source, "top.txt"), "/tmp/renamed.txt", audit)).To(Succeed())
+			Expect(readArchive(&buffer, "/tmp/renamed.txt", filepath.Join(dir, "copy.txt"), audit)).To(Succeed())
+
+			content, err := ioutil.ReadFile(filepath.Join(dir, "copy.txt"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("top"))
+		})
+	})
+
+	Describe("readArchive", func() {
+		var destination string
+
+		BeforeEach(func() {
+			destination = filepath.Join(dir, "nested", "destination")
+		})
+
+		// Nothing should ever be written outside of the destination
+		expectNothingEscaped := func() {
+			entries, err := ioutil.ReadDir(dir)
+			Expect(err).NotTo(HaveOccurred())
+			for _, entry := range entries {
+				Expect(entry.Name()).To(Equal("nested"))
+			}
+		}
+
+		DescribeTable("Refuses entries outside of the remote path",
+			func(name string) {
+				archive := buildArchive(tarEntry{name: name, typeflag: tar.TypeReg, content: "evil"})
+
+				err := readArchive(archive, "/tmp/app", destination, audit)
+				Expect(err).To(MatchError("refusing to extract unexpected archive entry: " + name))
+				Expect(copied).To(BeEmpty())
+				expectNothingEscaped()
+			},
+			Entry("parent directories", "../evil"),
+			Entry("parent directories within the remote path", "app/../../evil"),
+			Entry("absolute paths", "/etc/evil"),
+			Entry("absolute paths within the remote path", "/app/evil"),
+			Entry("siblings of the remote path", "other/evil"),
+			Entry("names prefixed with the remote path", "application/evil"),
+		)
+
+		It("Skips symlinks", func() {
+			archive := buildArchive(
+				tarEntry{name: "app", typeflag: tar.TypeDir},
+				tarEntry{name: "app/link", typeflag: tar.TypeSymlink, linkname: "../../../evil"},
+				tarEntry{name: "app/hardlink", typeflag: tar.TypeLink, linkname: "/etc/passwd"},
+				tarEntry{name: "app/file", typeflag: tar.TypeReg, content: "ok"},
+			)
+
+			Expect(readArchive(archive, "/tmp/app", destination, audit)).To(Succeed())
+			Expect(copied).To(HaveLen(1))
+
+			for _, name := range []string{"link", "hardlink"} {
+				_, err := os.Lstat(filepath.Join(destination, name))
+				Expect(os.IsNotExist(err)).To(BeTrue(), "%s should not have been extracted", name)
+			}
+
+			content, err := ioutil.ReadFile(filepath.Join(destination, "file"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("ok"))
+			expectNothingEscaped()
+		})
+
+		It("Cleans entries that stay within the remote path", func() {
+			archive := buildArchive(tarEntry{name: "app/./nested/../file", typeflag: tar.TypeReg, content: "ok"})
+
+			Expect(readArchive(archive, "/tmp/app", destination, audit)).To(Succeed())
+			Expect(copied).To(ConsistOf(CopiedFile{
+				RemotePath: "/tmp/app/file",
+				LocalPath:  filepath.Join(destination, "file"),
+				Size:       2,
+				SHA256:     sha256Hex("ok"),
+			}))
+		})
+	})
+})
@@ -161,7 +161,7 @@ var _ = Describe("Runner", func() {
 
 		JustBeforeEach(func() {
 			var csl *workloadsv1alpha1.Console
-			csl, err = consoleRunner.CreateResource(namespace.Name, consoleTemplate, createOptions)
+			csl, err = consoleRunner.CreateResource(context.TODO(), namespace.Name, consoleTemplate, createOptions)
 			console = *csl
 		})
 
@@ -219,7 +219,7 @@ var _ = Describe("Runner", func() {
 
 		Context("Successfully finds template", func() {
 			It("Finds a template in the namespace", func() {
-				foundTmpl, err := consoleRunner.FindTemplateBySelector(namespace.Name, "release=test")
+				foundTmpl, err := consoleRunner.FindTemplateBySelector(context.TODO(), namespace.Name, "release=test")
 				Expect(err).NotTo(HaveOccurred(), "unable to find template")
 				Expect(foundTmpl.Name).To(Equal(consoleTemplate.Name))
 			})
@@ -233,13 +233,13 @@ var _ = Describe("Runner", func() {
 
 			It("Fails to find non-existent template", func() {
 				By("Returning an error when not found")
-				foundTmpl, err := consoleRunner.FindTemplateBySelector(namespace.Name, "release=not-here")
+				foundTmpl, err := consoleRunner.FindTemplateBySelector(context.TODO(), namespace.Name, "release=not-here")
 				Expect(err).To(HaveOccurred(), "should be unable to find template")
 				Expect(foundTmpl).To(BeNil(), "result template should be nil")
 			})
 
 			It("Fails when targeting all namespaces", func() {
-				_, err := consoleRunner.FindTemplateBySelector(metav1.NamespaceAll, "release=test")
+				_, err := consoleRunner.FindTemplateBySelector(context.TODO(), metav1.NamespaceAll, "release=test")
 				Expect(err).To(HaveOccurred(), "expected template collision error")
 				Expect(err.Error()).To(
 					ContainSubstring("expected to discover 1 console template"),
@@ -0,0 +1,85 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+)
+
+// PortForwardOptions encapsulates the arguments to port-forward to a console
+type PortForwardOptions struct {
+	Namespace  string
+	KubeConfig *rest.Config
+	Name       string
+
+	// Ports to forward, each in the form accepted by `kubectl port-forward`, e.g.
+	// "8080:80", ":80" or "80"
+	Ports []string
+
+	IO IOStreams
+}
+
+// PortForward forwards local ports to the pod of a running console, until the context
+// is cancelled or the connection to the pod is lost. The console must have been
+// created from a template that allows port-forwarding.
+func (c *Runner) PortForward(ctx context.Context, opts PortForwardOptions) error {
+	csl, err := c.FindConsoleByName(ctx, opts.Namespace, opts.Name)
+	if err != nil {
+		return err
+	}
+
+	tpl, err := c.getConsoleTemplate(ctx, csl)
+	if err != nil {
+		return err
+	}
+
+	if !tpl.Spec.AllowPortForward {
+		return fmt.Errorf("console template %s does not allow port-forwarding", tpl.Name)
+	}
+
+	if csl.Status.Phase != workloadsv1alpha1.ConsoleRunning {
+		return fmt.Errorf("console is not running, current phase: %s", csl.Status.Phase)
+	}
+
+	pod, err := c.clientset.CoreV1().Pods(csl.Namespace).Get(ctx, csl.Status.PodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("could not find pod to port-forward to: %w", err)
+	}
+
+	req := c.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.GetNamespace()).
+		Name(pod.GetName()).
+		SubResource("portforward")
+
+	transport, upgrader, err := spdy.RoundTripperFor(opts.KubeConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create SPDY round tripper: %w", err)
+	}
+
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", req.URL())
+
+	stopChan, done := make(chan struct{}), make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			close(stopChan)
+		case <-done:
+		}
+	}()
+
+	forwarder, err := portforward.New(dialer, opts.Ports, stopChan, nil, opts.IO.Out, opts.IO.ErrOut)
+	if err != nil {
+		return fmt.Errorf("failed to create port forwarder: %w", err)
+	}
+
+	return forwarder.ForwardPorts()
+}
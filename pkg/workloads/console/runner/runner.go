@@ -8,6 +8,8 @@ import (
 	"io"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"text/tabwriter"
 	"time"
 
@@ -16,33 +18,32 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
-	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
-	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/remotecommand"
 	"k8s.io/kubectl/pkg/cmd/get"
 	"k8s.io/kubectl/pkg/util/term"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	rbacv1alpha1 "github.com/gocardless/theatre/v2/apis/rbac/v1alpha1"
 	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	"github.com/gocardless/theatre/v2/pkg/client/clientset/versioned"
+	"github.com/gocardless/theatre/v2/pkg/workloads/console/archive"
 )
 
+// ErrConsoleNotFound is returned when no console matches the given name
+var ErrConsoleNotFound = errors.New("no consoles found")
+
 // Alias genericclioptions.IOStreams to avoid additional imports
 type IOStreams genericclioptions.IOStreams
 
 // Runner is responsible for managing the lifecycle of a console
 type Runner struct {
 	clientset     kubernetes.Interface
-	consoleClient dynamic.NamespaceableResourceInterface
-	kubeClient    client.Client
+	theatreClient versioned.Interface
 }
 
 // Options defines the parameters that can be set upon a new console
@@ -54,9 +55,14 @@ type Options struct {
 	// should be set to false but some execution environments, eg
 	// Tekton, do not like attaching to TTY-enabled pods.
 	Noninteractive bool
+	// Compute resources for the console container, overriding those in the
+	// template
+	Resources *workloadsv1alpha1.ConsoleResources
+	// Time before which the console won't be started
+	StartAfter *time.Time
 }
 
-// New builds a runner
+// New builds a runner from a Kubernetes client configuration
 func New(cfg *rest.Config) (*Runner, error) {
 	// create a client that can be used to attach to consoles pod
 	clientset, err := kubernetes.NewForConfig(cfg)
@@ -64,35 +70,33 @@ func New(cfg *rest.Config) (*Runner, error) {
 		return nil, err
 	}
 
-	// create a client that can be used to watch a console CRD
-	dynClient, err := dynamic.NewForConfig(cfg)
+	// create a client for the theatre custom resources
+	theatreClient, err := versioned.NewForConfig(cfg)
 	if err != nil {
 		return nil, err
 	}
-	consoleClient := dynClient.Resource(workloadsv1alpha1.GroupVersion.WithResource("consoles"))
 
-	// create a client that can be used for everything else
-	scheme := runtime.NewScheme()
-	_ = clientgoscheme.AddToScheme(scheme)
-	_ = workloadsv1alpha1.AddToScheme(scheme)
-	_ = rbacv1alpha1.AddToScheme(scheme)
-	kubeClient, err := client.New(cfg, client.Options{Scheme: scheme})
-	if err != nil {
-		return nil, err
-	}
+	return NewFromClients(clientset, theatreClient), nil
+}
 
+// NewFromClients builds a runner from existing clients, allowing the caller to
+// control how they are configured, or to provide fakes in tests
+func NewFromClients(clientset kubernetes.Interface, theatreClient versioned.Interface) *Runner {
 	return &Runner{
 		clientset:     clientset,
-		consoleClient: consoleClient,
-		kubeClient:    kubeClient,
-	}, nil
+		theatreClient: theatreClient,
+	}
 }
 
 // LifecycleHook provides a communication to react to console lifecycle changes
 type LifecycleHook interface {
 	AttachingToConsole(*workloadsv1alpha1.Console) error
+	ReconnectingToConsole(*workloadsv1alpha1.Console, error) error
+	DetachedFromConsole(*workloadsv1alpha1.Console) error
+	FileCopied(*workloadsv1alpha1.Console, CopiedFile) error
 	ConsoleCreated(*workloadsv1alpha1.Console) error
 	ConsoleRequiresAuthorisation(*workloadsv1alpha1.Console, *workloadsv1alpha1.ConsoleAuthorisationRule) error
+	ConsoleScheduled(*workloadsv1alpha1.Console) error
 	ConsoleReady(*workloadsv1alpha1.Console) error
 	TemplateFound(*workloadsv1alpha1.ConsoleTemplate) error
 }
@@ -101,8 +105,12 @@ var _ LifecycleHook = DefaultLifecycleHook{}
 
 type DefaultLifecycleHook struct {
 	AttachingToPodFunc               func(*workloadsv1alpha1.Console) error
+	ReconnectingToConsoleFunc        func(*workloadsv1alpha1.Console, error) error
+	DetachedFromConsoleFunc          func(*workloadsv1alpha1.Console) error
+	FileCopiedFunc                   func(*workloadsv1alpha1.Console, CopiedFile) error
 	ConsoleCreatedFunc               func(*workloadsv1alpha1.Console) error
 	ConsoleRequiresAuthorisationFunc func(*workloadsv1alpha1.Console, *workloadsv1alpha1.ConsoleAuthorisationRule) error
+	ConsoleScheduledFunc             func(*workloadsv1alpha1.Console) error
 	ConsoleReadyFunc                 func(*workloadsv1alpha1.Console) error
 	TemplateFoundFunc                func(*workloadsv1alpha1.ConsoleTemplate) error
 }
@@ -114,6 +122,27 @@ func (d DefaultLifecycleHook) AttachingToConsole(c *workloadsv1alpha1.Console) e
 	return nil
 }
 
+func (d DefaultLifecycleHook) ReconnectingToConsole(c *workloadsv1alpha1.Console, err error) error {
+	if d.ReconnectingToConsoleFunc != nil {
+		return d.ReconnectingToConsoleFunc(c, err)
+	}
+	return nil
+}
+
+func (d DefaultLifecycleHook) DetachedFromConsole(c *workloadsv1alpha1.Console) error {
+	if d.DetachedFromConsoleFunc != nil {
+		return d.DetachedFromConsoleFunc(c)
+	}
+	return nil
+}
+
+func (d DefaultLifecycleHook) FileCopied(c *workloadsv1alpha1.Console, f CopiedFile) error {
+	if d.FileCopiedFunc != nil {
+		return d.FileCopiedFunc(c, f)
+	}
+	return nil
+}
+
 func (d DefaultLifecycleHook) ConsoleCreated(c *workloadsv1alpha1.Console) error {
 	if d.ConsoleCreatedFunc != nil {
 		return d.ConsoleCreatedFunc(c)
@@ -128,6 +157,13 @@ func (d DefaultLifecycleHook) ConsoleRequiresAuthorisation(c *workloadsv1alpha1.
 	return nil
 }
 
+func (d DefaultLifecycleHook) ConsoleScheduled(c *workloadsv1alpha1.Console) error {
+	if d.ConsoleScheduledFunc != nil {
+		return d.ConsoleScheduledFunc(c)
+	}
+	return nil
+}
+
 func (d DefaultLifecycleHook) ConsoleReady(c *workloadsv1alpha1.Console) error {
 	if d.ConsoleReadyFunc != nil {
 		return d.ConsoleReadyFunc(c)
@@ -151,10 +187,16 @@ type CreateOptions struct {
 	Command        []string
 	Attach         bool
 	Noninteractive bool
+	Resources      *workloadsv1alpha1.ConsoleResources
+	// Time before which the console won't be started. Create returns once the
+	// console has been requested, rather than waiting for it to be ready, and
+	// cannot be used with Attach.
+	StartAfter *time.Time
 
 	// Options only used when Attach is true
-	KubeConfig *rest.Config
-	IO         IOStreams
+	KubeConfig     *rest.Config
+	IO             IOStreams
+	EscapeSequence string
 
 	// Lifecycle hook to notify when the state of the console changes
 	Hook LifecycleHook
@@ -174,8 +216,12 @@ func (c *Runner) Create(ctx context.Context, opts CreateOptions) (*workloadsv1al
 	// Get options with any unset values defaulted
 	opts = opts.WithDefaults()
 
+	if opts.StartAfter != nil && opts.Attach {
+		return nil, errors.New("cannot attach to a console that is scheduled to start later")
+	}
+
 	// Create and attach to the console
-	tpl, err := c.FindTemplateBySelector(opts.Namespace, opts.Selector)
+	tpl, err := c.FindTemplateBySelector(ctx, opts.Namespace, opts.Selector)
 	if err != nil {
 		return nil, err
 	}
@@ -185,8 +231,8 @@ func (c *Runner) Create(ctx context.Context, opts CreateOptions) (*workloadsv1al
 		return nil, err
 	}
 
-	opt := Options{Cmd: opts.Command, Timeout: int(opts.Timeout.Seconds()), Reason: opts.Reason, Noninteractive: opts.Noninteractive}
-	csl, err := c.CreateResource(tpl.Namespace, *tpl, opt)
+	opt := Options{Cmd: opts.Command, Timeout: int(opts.Timeout.Seconds()), Reason: opts.Reason, Noninteractive: opts.Noninteractive, Resources: opts.Resources, StartAfter: opts.StartAfter}
+	csl, err := c.CreateResource(ctx, tpl.Namespace, *tpl, opt)
 	if err != nil {
 		return nil, err
 	}
@@ -199,15 +245,23 @@ func (c *Runner) Create(ctx context.Context, opts CreateOptions) (*workloadsv1al
 	// Wait for authorisation step or until ready
 	_, err = c.WaitUntilReady(ctx, *csl, false)
 	if err == consolePendingAuthorisationError {
-		rule, err := tpl.GetAuthorisationRuleForCommand(opts.Command)
+		rule, err := tpl.GetAuthorisationRule(opts.Command, opts.Resources)
 		if err != nil {
 			return csl, fmt.Errorf("failed to get authorisation rule %w", err)
 		}
-		opts.Hook.ConsoleRequiresAuthorisation(csl, &rule)
-	} else if err != nil {
+		if rule != nil {
+			opts.Hook.ConsoleRequiresAuthorisation(csl, rule)
+		}
+	} else if err != nil && err != consoleScheduledError {
 		return nil, err
 	}
 
+	// Scheduled consoles won't be ready until their start time, which is usually
+	// long after we'd want to wait for
+	if opts.StartAfter != nil {
+		return csl, opts.Hook.ConsoleScheduled(csl)
+	}
+
 	// Wait for the console to enter a ready state
 	csl, err = c.WaitUntilReady(ctx, *csl, true)
 	if err != nil {
@@ -223,11 +277,12 @@ func (c *Runner) Create(ctx context.Context, opts CreateOptions) (*workloadsv1al
 		return csl, c.Attach(
 			ctx,
 			AttachOptions{
-				Namespace:  csl.GetNamespace(),
-				KubeConfig: opts.KubeConfig,
-				Name:       csl.GetName(),
-				IO:         opts.IO,
-				Hook:       opts.Hook,
+				Namespace:      csl.GetNamespace(),
+				KubeConfig:     opts.KubeConfig,
+				Name:           csl.GetName(),
+				IO:             opts.IO,
+				Hook:           opts.Hook,
+				EscapeSequence: opts.EscapeSequence,
 			},
 		)
 	}
@@ -294,7 +349,7 @@ func (c *Runner) waitForSuccess(ctx context.Context, csl *workloadsv1alpha1.Cons
 			// We can receive *metav1.Status events in the situation where there's an error, in
 			// which case we should exit early.
 			if status, ok := event.Object.(*metav1.Status); ok {
-				return fmt.Errorf("received failure from Kubernetes: %w", status.Reason)
+				return fmt.Errorf("received failure from Kubernetes: %s", status.Reason)
 			}
 
 			// We should be safe now, as a watcher should return either Status or the type we
@@ -325,20 +380,17 @@ type GetOptions struct {
 
 // Get provides a standardised method to get a console
 func (c *Runner) Get(ctx context.Context, opts GetOptions) (*workloadsv1alpha1.Console, error) {
-	var csl workloadsv1alpha1.Console
-	err := c.kubeClient.Get(
-		ctx,
-		client.ObjectKey{
-			Name:      opts.ConsoleName,
-			Namespace: opts.Namespace,
-		},
-		&csl,
-	)
-	if err != nil {
-		return nil, err
-	}
+	return c.theatreClient.WorkloadsV1alpha1().Consoles(opts.Namespace).Get(ctx, opts.ConsoleName, metav1.GetOptions{})
+}
 
-	return &csl, nil
+// DefaultReconnectBackoff is used to reconnect to a console when the attached stream
+// drops while the console container is still running, e.g. due to a flaky network.
+var DefaultReconnectBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    5,
+	Cap:      30 * time.Second,
 }
 
 // AttachOptions encapsulates the arguments to attach to a console
@@ -351,6 +403,14 @@ type AttachOptions struct {
 
 	// Lifecycle hook to notify when the state of the console changes
 	Hook LifecycleHook
+
+	// ReconnectBackoff controls the retries made when the stream to a running console
+	// is lost. Setting Steps to 0 disables reconnection.
+	ReconnectBackoff *wait.Backoff
+
+	// EscapeSequence, when typed at the start of a line, detaches from an interactive
+	// console and leaves it running. Leaving this empty disables detaching.
+	EscapeSequence string
 }
 
 // WithDefaults sets any unset options to defaults
@@ -359,6 +419,11 @@ func (opts AttachOptions) WithDefaults() AttachOptions {
 		opts.Hook = DefaultLifecycleHook{}
 	}
 
+	if opts.ReconnectBackoff == nil {
+		backoff := DefaultReconnectBackoff
+		opts.ReconnectBackoff = &backoff
+	}
+
 	return opts
 }
 
@@ -367,7 +432,7 @@ func (c *Runner) Attach(ctx context.Context, opts AttachOptions) error {
 	// Get options with any unset values defaulted
 	opts = opts.WithDefaults()
 
-	csl, err := c.FindConsoleByName(opts.Namespace, opts.Name)
+	csl, err := c.FindConsoleByName(ctx, opts.Namespace, opts.Name)
 	if err != nil {
 		return err
 	}
@@ -382,30 +447,119 @@ func (c *Runner) Attach(ctx context.Context, opts AttachOptions) error {
 		return err
 	}
 
+	tracker := &connectionTracker{}
 	var attacher Attacher
 	if !csl.Spec.Noninteractive {
-		attacher = newInteractiveAttacher(c.clientset, opts.KubeConfig)
+		attacher = newInteractiveAttacher(c.clientset, opts.KubeConfig, opts.EscapeSequence, tracker.Connected)
 	} else {
-		attacher = newNoninteractiveAttacher(c.clientset, opts.KubeConfig)
+		attacher = newNoninteractiveAttacher(c.clientset, opts.KubeConfig, tracker.Connected)
 	}
 
-	err = attacher.Attach(ctx, pod, containerName, opts.IO)
+	err = c.attachUntilFinished(ctx, pod, containerName, attacher, tracker, csl, opts)
+	if errors.Is(err, ErrDetached) {
+		return opts.Hook.DetachedFromConsole(csl)
+	}
 	if err != nil {
+		return err
+	}
+
+	return c.waitForSuccess(ctx, csl)
+}
+
+// attachUntilFinished attaches to the console container, reconnecting whenever the
+// stream drops while the container is still running. It returns nil once the container
+// has finished, leaving the caller to determine how it exited.
+func (c *Runner) attachUntilFinished(ctx context.Context, pod *corev1.Pod, containerName string, attacher Attacher, tracker *connectionTracker, csl *workloadsv1alpha1.Console, opts AttachOptions) error {
+	// Take a copy, as stepping through the backoff mutates it
+	backoff := *opts.ReconnectBackoff
+
+	for {
+		err := attacher.Attach(ctx, pod, containerName, opts.IO)
+		if err == nil || errors.Is(err, ErrDetached) {
+			return err
+		}
+
 		// If this is true, it is likely that the pod has already terminated for whatever
 		// reason - very often because a command has run so quickly that by the time waitForConsole
 		// is done the script has run to completion. We don't necessarily want to error out
 		// (only if the pod exited unsuccessfully).
 		if strings.Contains(err.Error(), fmt.Sprintf("container %s not found in pod %s", containerName, pod.Name)) {
-			return c.extractLogs(ctx, csl, pod, containerName, opts.IO)
+			return c.extractLogs(ctx, pod, containerName, opts.IO)
+		}
+
+		if ctx.Err() != nil {
+			return fmt.Errorf("failed to attach to console: %w", err)
 		}
 
-		return fmt.Errorf("failed to attach to console: %w", err)
+		// A stream that ends because the console container has finished is not
+		// something we can recover from by reconnecting: fall through to check how
+		// the pod exited.
+		if c.containerFinished(ctx, pod, containerName) {
+			return nil
+		}
+
+		// Each time a stream is established, we're entitled to the full set of retries
+		// again should it drop, rather than those left over from previous drops.
+		if tracker.Reset() {
+			backoff = *opts.ReconnectBackoff
+		}
+
+		if backoff.Steps < 1 {
+			return fmt.Errorf("failed to attach to console: %w", err)
+		}
+
+		if err := opts.Hook.ReconnectingToConsole(csl, err); err != nil {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff.Step()):
+		case <-ctx.Done():
+			return fmt.Errorf("failed to reconnect to console: %w", ctx.Err())
+		}
 	}
+}
 
-	return c.waitForSuccess(ctx, csl)
+// connectionTracker records whether an attacher has established a stream, which it is
+// told about from whichever goroutine notices first.
+type connectionTracker struct {
+	connected int32
 }
 
-func (c *Runner) extractLogs(ctx context.Context, csl *workloadsv1alpha1.Console, pod *corev1.Pod, containerName string, streams IOStreams) error {
+// Connected marks the current stream as established
+func (t *connectionTracker) Connected() {
+	atomic.StoreInt32(&t.connected, 1)
+}
+
+// Reset returns whether a stream was established since it was last called
+func (t *connectionTracker) Reset() bool {
+	return atomic.SwapInt32(&t.connected, 0) == 1
+}
+
+// containerFinished returns true if we can determine that the given container is no
+// longer running. When the pod can't be retrieved for any reason other than it having
+// been deleted, we assume the container is still running, as this is most likely
+// caused by the same connectivity problems that interrupted our stream.
+func (c *Runner) containerFinished(ctx context.Context, pod *corev1.Pod, containerName string) bool {
+	latest, err := c.clientset.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+	if err != nil {
+		return apierrors.IsNotFound(err)
+	}
+
+	if latest.Status.Phase != corev1.PodRunning && latest.Status.Phase != corev1.PodPending {
+		return true
+	}
+
+	for _, status := range latest.Status.ContainerStatuses {
+		if status.Name == containerName {
+			return status.State.Terminated != nil
+		}
+	}
+
+	return false
+}
+
+func (c *Runner) extractLogs(ctx context.Context, pod *corev1.Pod, containerName string, streams IOStreams) error {
 	pods := c.clientset.CoreV1().Pods(pod.Namespace)
 
 	logs, err := pods.GetLogs(pod.Name, &corev1.PodLogOptions{Container: containerName}).Stream(ctx)
@@ -415,17 +569,129 @@ func (c *Runner) extractLogs(ctx context.Context, csl *workloadsv1alpha1.Console
 
 	defer logs.Close()
 
+	// The caller propagates the exit status of the pod as though we had actually
+	// attached.
 	_, err = io.Copy(streams.Out, logs)
+	return err
+}
+
+// LogsOptions encapsulates the arguments to print the logs of a console
+type LogsOptions struct {
+	Namespace string
+	Name      string
+	// UID optionally identifies the console, to read the archived logs of a deleted
+	// console whose name has since been reused. Otherwise, the most recently
+	// archived logs of consoles with the name are read.
+	UID string
+	// Follow the logs of a running console until it finishes
+	Follow bool
+	// Archive to read logs from if the console, or its pod, no longer exists. If
+	// not set, only the logs of existing consoles can be read.
+	Archive archive.LogStore
+	Output  io.Writer
+}
+
+// Logs prints the logs of a console's container, reading them from its pod if
+// it's still around, and otherwise from the archive. The metadata of the
+// archived logs is returned when they're read from the archive.
+func (c *Runner) Logs(ctx context.Context, opts LogsOptions) (*archive.LogMetadata, error) {
+	csl, err := c.FindConsoleByName(ctx, opts.Namespace, opts.Name)
+	if err != nil && !errors.Is(err, ErrConsoleNotFound) {
+		return nil, err
+	}
+
+	namespace := opts.Namespace
+	if csl != nil {
+		namespace = csl.Namespace
+	}
+
+	// The console with the name may have replaced the one being asked for
+	if csl != nil && opts.UID != "" && string(csl.UID) != opts.UID {
+		csl = nil
+	}
+
+	if csl != nil && csl.Status.PodName != "" {
+		err := c.podLogs(ctx, csl, opts)
+		if err == nil || !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+
+	if opts.Archive == nil {
+		if csl == nil {
+			return nil, fmt.Errorf("%w with name: %s, and no log archive is configured", ErrConsoleNotFound, opts.Name)
+		}
+
+		return nil, fmt.Errorf("console %s has no pod, and no log archive is configured", csl.Name)
+	}
+
+	if namespace == "" {
+		return nil, fmt.Errorf("console %s no longer exists, so a namespace is required to find its archived logs", opts.Name)
+	}
+
+	object, err := c.archivedLogs(ctx, csl, namespace, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archived logs for console %s: %w", opts.Name, err)
+	}
+	defer object.Close()
+
+	meta, logs, err := archive.ReadLogs(object)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := io.Copy(opts.Output, logs); err != nil {
+		return nil, err
+	}
+
+	return meta, nil
+}
+
+// archivedLogs gets the archived logs of the console with the UID that was asked
+// for, or of the console if it still exists, and otherwise the logs most recently
+// archived for a console with the name
+func (c *Runner) archivedLogs(ctx context.Context, csl *workloadsv1alpha1.Console, namespace string, opts LogsOptions) (io.ReadCloser, error) {
+	if opts.UID != "" {
+		return opts.Archive.Get(ctx, archive.LogKey(namespace, opts.Name, opts.UID))
+	}
+
+	if csl != nil {
+		return opts.Archive.Get(ctx, archive.LogKey(namespace, opts.Name, string(csl.UID)))
+	}
+
+	key, err := archive.FindLogs(ctx, opts.Archive, namespace, opts.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return opts.Archive.Get(ctx, key)
+}
+
+func (c *Runner) podLogs(ctx context.Context, csl *workloadsv1alpha1.Console, opts LogsOptions) error {
+	pods := c.clientset.CoreV1().Pods(csl.Namespace)
+
+	pod, err := pods.Get(ctx, csl.Status.PodName, metav1.GetOptions{})
 	if err != nil {
 		return err
 	}
 
-	// Propagate the exit status of the pod as though we had actually attached.
-	return c.waitForSuccess(ctx, csl)
+	if len(pod.Spec.Containers) == 0 {
+		return fmt.Errorf("console pod %s has no containers", pod.Name)
+	}
+
+	logOpts := &corev1.PodLogOptions{Container: pod.Spec.Containers[0].Name, Follow: opts.Follow}
+	logs, err := pods.GetLogs(pod.Name, logOpts).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+
+	_, err = io.Copy(opts.Output, logs)
+	return err
 }
 
-func newInteractiveAttacher(clientset kubernetes.Interface, restconfig *rest.Config) Attacher {
-	return &interactiveAttacher{clientset, restconfig}
+func newInteractiveAttacher(clientset kubernetes.Interface, restconfig *rest.Config, escapeSequence string, connected func()) Attacher {
+	return &interactiveAttacher{clientset: clientset, restconfig: restconfig, escapeSequence: escapeSequence, connected: connected}
 }
 
 type Attacher interface {
@@ -435,12 +701,20 @@ type Attacher interface {
 // interactiveAttacher knows how to attach to stdio of an existing container, relaying io
 // to the parent process file descriptors.
 type interactiveAttacher struct {
-	clientset  kubernetes.Interface
-	restconfig *rest.Config
+	clientset      kubernetes.Interface
+	restconfig     *rest.Config
+	escapeSequence string
+	// Called once each stream is established
+	connected func()
+
+	// Stdin is shared between reconnects, so that we keep track of the escape sequence
+	// across streams.
+	stdin *escapeReader
 }
 
 // Attach will interactively attach to a container's output, creating a new TTY
-// and hooking this into the current processes file descriptors.
+// and hooking this into the current processes file descriptors. If the user types
+// the escape sequence, we return ErrDetached without waiting for the stream to end.
 func (a *interactiveAttacher) Attach(ctx context.Context, pod *corev1.Pod, containerName string, streams IOStreams) error {
 	req := a.clientset.CoreV1().RESTClient().Post().
 		Resource("pods").
@@ -464,16 +738,40 @@ func (a *interactiveAttacher) Attach(ctx context.Context, pod *corev1.Pod, conta
 		return fmt.Errorf("failed to create SPDY executor: %w", err)
 	}
 
-	streamOptions, safe := CreateInteractiveStreamOptions(streams)
+	if a.stdin == nil {
+		a.stdin = newEscapeReader(streams.In, a.escapeSequence)
+	}
 
-	return safe(func() error { return remoteExecutor.Stream(streamOptions) })
+	// Stop monitoring the terminal size when this stream ends, so a reconnected stream
+	// receives all subsequent resizes, starting with the current size.
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	streamOptions, safe := CreateInteractiveStreamOptions(streamCtx, streams)
+	streamOptions.Stdin = a.stdin
+	notifyWhenConnected(&streamOptions, a.connected)
+
+	return safe(func() error {
+		streamErr := make(chan error, 1)
+		go func() {
+			streamErr <- remoteExecutor.Stream(streamOptions)
+		}()
+
+		select {
+		case err := <-streamErr:
+			return err
+		case <-a.stdin.Detached():
+			return ErrDetached
+		}
+	})
 }
 
 // CreateInteractiveStreamOptions constructs streaming configuration that
 // attaches the default OS stdout, stderr, stdin, with a tty, and an additional
 // function which should be used to wrap any interactive process that will make
-// use of the tty.
-func CreateInteractiveStreamOptions(streams IOStreams) (remotecommand.StreamOptions, func(term.SafeFunc) error) {
+// use of the tty. Changes to the size of the terminal are propagated until the
+// context is done.
+func CreateInteractiveStreamOptions(ctx context.Context, streams IOStreams) (remotecommand.StreamOptions, func(term.SafeFunc) error) {
 	// TODO: We may want to setup a parent interrupt handler, so that if/when the
 	// pod is terminated while a user is attached, they aren't left with their
 	// terminal in a strange state, if they're running something curses-based in
@@ -486,15 +784,12 @@ func CreateInteractiveStreamOptions(streams IOStreams) (remotecommand.StreamOpti
 		TryDev: false,
 	}
 
-	// This call spawns a goroutine to monitor/update the terminal size
-	sizeQueue := tty.MonitorSize(tty.GetSize())
-
 	return remotecommand.StreamOptions{
 		Stderr:            streams.ErrOut,
 		Stdout:            streams.Out,
 		Stdin:             streams.In,
 		Tty:               true,
-		TerminalSizeQueue: sizeQueue,
+		TerminalSizeQueue: newTerminalSizeQueue(ctx, tty.GetSize),
 	}, tty.Safe
 }
 
@@ -503,10 +798,12 @@ func CreateInteractiveStreamOptions(streams IOStreams) (remotecommand.StreamOpti
 type noninteractiveAttacher struct {
 	clientset  kubernetes.Interface
 	restconfig *rest.Config
+	// Called once each stream is established
+	connected func()
 }
 
-func newNoninteractiveAttacher(clientset kubernetes.Interface, restconfig *rest.Config) Attacher {
-	return &noninteractiveAttacher{clientset, restconfig}
+func newNoninteractiveAttacher(clientset kubernetes.Interface, restconfig *rest.Config, connected func()) Attacher {
+	return &noninteractiveAttacher{clientset, restconfig, connected}
 }
 
 // Attach will attach to a container's output.
@@ -539,10 +836,53 @@ func (a *noninteractiveAttacher) Attach(ctx context.Context, pod *corev1.Pod, co
 		Stdin:  nil,
 		Tty:    false,
 	}
+	notifyWhenConnected(&streamOptions, a.connected)
 
 	return remoteExecutor.Stream(streamOptions)
 }
 
+// notifyWhenConnected arranges for connected to be called once the stream has been
+// established, which we take to be when it first relays output or asks for the size
+// of the terminal, as the executor doesn't tell us directly.
+func notifyWhenConnected(streamOptions *remotecommand.StreamOptions, connected func()) {
+	if connected == nil {
+		return
+	}
+
+	var once sync.Once
+	notify := func() { once.Do(connected) }
+
+	if streamOptions.Stdout != nil {
+		streamOptions.Stdout = notifyingWriter{streamOptions.Stdout, notify}
+	}
+	if streamOptions.Stderr != nil {
+		streamOptions.Stderr = notifyingWriter{streamOptions.Stderr, notify}
+	}
+	if streamOptions.TerminalSizeQueue != nil {
+		streamOptions.TerminalSizeQueue = notifyingSizeQueue{streamOptions.TerminalSizeQueue, notify}
+	}
+}
+
+type notifyingWriter struct {
+	io.Writer
+	notify func()
+}
+
+func (w notifyingWriter) Write(p []byte) (int, error) {
+	w.notify()
+	return w.Writer.Write(p)
+}
+
+type notifyingSizeQueue struct {
+	remotecommand.TerminalSizeQueue
+	notify func()
+}
+
+func (q notifyingSizeQueue) Next() *remotecommand.TerminalSize {
+	q.notify()
+	return q.TerminalSizeQueue.Next()
+}
+
 type AuthoriseOptions struct {
 	Namespace   string
 	ConsoleName string
@@ -567,17 +907,9 @@ func (c *Runner) Authorise(ctx context.Context, opts AuthoriseOptions) error {
 		return err
 	}
 
-	var authz workloadsv1alpha1.ConsoleAuthorisation
-	err = c.kubeClient.Get(
-		ctx,
-		client.ObjectKey{
-			Name:      opts.ConsoleName,
-			Namespace: opts.Namespace,
-		},
-		&authz,
+	_, err = c.theatreClient.WorkloadsV1alpha1().ConsoleAuthorisations(opts.Namespace).Patch(
+		ctx, opts.ConsoleName, types.JSONPatchType, patchBytes, metav1.PatchOptions{},
 	)
-
-	err = c.kubeClient.Patch(ctx, &authz, client.ConstantPatch(types.JSONPatchType, patchBytes))
 	if err != nil {
 		return err
 	}
@@ -595,7 +927,7 @@ type ListOptions struct {
 // List is a wrapper around ListConsolesByLabelsAndUser that will output to a specified output.
 // This functionality is intended to be used in a CLI setting, where you are usually outputting to os.Stdout.
 func (c *Runner) List(ctx context.Context, opts ListOptions) (ConsoleSlice, error) {
-	consoles, err := c.ListConsolesByLabelsAndUser(opts.Namespace, opts.Username, opts.Selector)
+	consoles, err := c.ListConsolesByLabelsAndUser(ctx, opts.Namespace, opts.Username, opts.Selector)
 	if err != nil {
 		return nil, err
 	}
@@ -604,7 +936,7 @@ func (c *Runner) List(ctx context.Context, opts ListOptions) (ConsoleSlice, erro
 }
 
 // CreateResource builds a console according to the supplied options and submits it to the API
-func (c *Runner) CreateResource(namespace string, template workloadsv1alpha1.ConsoleTemplate, opts Options) (*workloadsv1alpha1.Console, error) {
+func (c *Runner) CreateResource(ctx context.Context, namespace string, template workloadsv1alpha1.ConsoleTemplate, opts Options) (*workloadsv1alpha1.Console, error) {
 	csl := &workloadsv1alpha1.Console{
 		ObjectMeta: metav1.ObjectMeta{
 			// Let Kubernetes generate a unique name
@@ -620,14 +952,16 @@ func (c *Runner) CreateResource(namespace string, template workloadsv1alpha1.Con
 			Command:        opts.Cmd,
 			Reason:         opts.Reason,
 			Noninteractive: opts.Noninteractive,
+			Resources:      opts.Resources,
 		},
 	}
 
-	err := c.kubeClient.Create(
-		context.TODO(),
-		csl,
-	)
-	return csl, err
+	if opts.StartAfter != nil {
+		startAfter := metav1.NewTime(*opts.StartAfter)
+		csl.Spec.StartAfter = &startAfter
+	}
+
+	return c.theatreClient.WorkloadsV1alpha1().Consoles(namespace).Create(ctx, csl, metav1.CreateOptions{})
 }
 
 // MultipleConsoleTemplateError is returned whenever our selector was too broad, and
@@ -651,15 +985,14 @@ func (e MultipleConsoleTemplateError) Error() string {
 // FindTemplateBySelector will search for a template matching the given label
 // selector and return errors if none or multiple are found (when the selector
 // is too broad)
-func (c *Runner) FindTemplateBySelector(namespace string, labelSelector string) (*workloadsv1alpha1.ConsoleTemplate, error) {
-	var templates workloadsv1alpha1.ConsoleTemplateList
+func (c *Runner) FindTemplateBySelector(ctx context.Context, namespace string, labelSelector string) (*workloadsv1alpha1.ConsoleTemplate, error) {
 	selectorSet, err := labels.ConvertSelectorToLabelsMap(labelSelector)
 	if err != nil {
 		return nil, fmt.Errorf("invalid selector: %w", err)
 	}
 
-	opts := &client.ListOptions{Namespace: namespace, LabelSelector: labels.SelectorFromSet(selectorSet)}
-	err = c.kubeClient.List(context.TODO(), &templates, opts)
+	opts := metav1.ListOptions{LabelSelector: labels.SelectorFromSet(selectorSet).String()}
+	templates, err := c.theatreClient.WorkloadsV1alpha1().ConsoleTemplates(namespace).List(ctx, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list consoles templates: %w", err)
 	}
@@ -673,15 +1006,24 @@ func (c *Runner) FindTemplateBySelector(namespace string, labelSelector string)
 	return &template, nil
 }
 
-func (c *Runner) FindConsoleByName(namespace, name string) (*workloadsv1alpha1.Console, error) {
+// getConsoleTemplate retrieves the template that a console was created from
+func (c *Runner) getConsoleTemplate(ctx context.Context, csl *workloadsv1alpha1.Console) (*workloadsv1alpha1.ConsoleTemplate, error) {
+	tpl, err := c.theatreClient.WorkloadsV1alpha1().ConsoleTemplates(csl.Namespace).Get(ctx, csl.Spec.ConsoleTemplateRef.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get console template: %w", err)
+	}
+
+	return tpl, nil
+}
+
+func (c *Runner) FindConsoleByName(ctx context.Context, namespace, name string) (*workloadsv1alpha1.Console, error) {
 	// We must List then filter the slice instead of calling Get(name), otherwise
 	// the real Kubernetes client will return the following error when namespace
 	// is empty: "an empty namespace may not be set when a resource name is
 	// provided".
 	// The fake clientset generated by client-gen will not replicate this error in
 	// unit tests.
-	var allConsolesInNamespace workloadsv1alpha1.ConsoleList
-	err := c.kubeClient.List(context.TODO(), &allConsolesInNamespace, &client.ListOptions{Namespace: namespace})
+	allConsolesInNamespace, err := c.theatreClient.WorkloadsV1alpha1().Consoles(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -694,7 +1036,7 @@ func (c *Runner) FindConsoleByName(namespace, name string) (*workloadsv1alpha1.C
 	}
 
 	if len(matchingConsoles) == 0 {
-		return nil, fmt.Errorf("no consoles found with name: %s", name)
+		return nil, fmt.Errorf("%w with name: %s", ErrConsoleNotFound, name)
 	}
 	if len(matchingConsoles) > 1 {
 		return nil, fmt.Errorf("too many consoles found with name: %s, please specify namespace", name)
@@ -733,19 +1075,21 @@ func (cs ConsoleSlice) Print(output io.Writer) error {
 	return nil
 }
 
-func (c *Runner) ListConsolesByLabelsAndUser(namespace, username, labelSelector string) (ConsoleSlice, error) {
+func (c *Runner) ListConsolesByLabelsAndUser(ctx context.Context, namespace, username, labelSelector string) (ConsoleSlice, error) {
 	// We cannot use a FieldSelector on spec.user in conjunction with the
 	// LabelSelector for CRD types like Console. The error message "field label
 	// not supported: spec.user" is returned by the real Kubernetes client.
 	// See https://github.com/kubernetes/kubernetes/issues/53459.
-	var csls workloadsv1alpha1.ConsoleList
 	selectorSet, err := labels.ConvertSelectorToLabelsMap(labelSelector)
 	if err != nil {
 		return nil, fmt.Errorf("invalid selector: %w", err)
 	}
 
-	opts := &client.ListOptions{Namespace: namespace, LabelSelector: labels.SelectorFromSet(selectorSet)}
-	err = c.kubeClient.List(context.TODO(), &csls, opts)
+	opts := metav1.ListOptions{LabelSelector: labels.SelectorFromSet(selectorSet).String()}
+	csls, err := c.theatreClient.WorkloadsV1alpha1().Consoles(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
 
 	var filtered []workloadsv1alpha1.Console
 	for _, csl := range csls.Items {
@@ -753,7 +1097,7 @@ func (c *Runner) ListConsolesByLabelsAndUser(namespace, username, labelSelector
 			filtered = append(filtered, csl)
 		}
 	}
-	return filtered, err
+	return filtered, nil
 }
 
 // WaitUntilReady will block until the console reaches a phase that indicates
@@ -776,6 +1120,7 @@ func (c *Runner) WaitUntilReady(ctx context.Context, createdCsl workloadsv1alpha
 
 var (
 	consolePendingAuthorisationError = errors.New("console pending authorisation")
+	consoleScheduledError            = errors.New("console scheduled")
 	consoleNotFoundError             = errors.New("console not found")
 )
 
@@ -791,9 +1136,12 @@ func (c *Runner) waitForConsole(ctx context.Context, createdCsl workloadsv1alpha
 	isStopped := func(csl *workloadsv1alpha1.Console) bool {
 		return csl != nil && csl.Status.Phase == workloadsv1alpha1.ConsoleStopped
 	}
+	isScheduled := func(csl *workloadsv1alpha1.Console) bool {
+		return csl != nil && csl.Status.Phase == workloadsv1alpha1.ConsoleScheduled
+	}
 
 	listOptions := metav1.SingleObject(createdCsl.ObjectMeta)
-	w, err := c.consoleClient.Namespace(createdCsl.Namespace).Watch(ctx, listOptions)
+	w, err := c.theatreClient.WorkloadsV1alpha1().Consoles(createdCsl.Namespace).Watch(ctx, listOptions)
 	if err != nil {
 		return nil, fmt.Errorf("error watching console: %w", err)
 	}
@@ -801,9 +1149,7 @@ func (c *Runner) waitForConsole(ctx context.Context, createdCsl workloadsv1alpha
 	// Get the console, because watch will only give us an event when something
 	// is changed, and the phase could have already stabilised before the watch
 	// is set up.
-	csl := &workloadsv1alpha1.Console{}
-
-	err = c.kubeClient.Get(ctx, client.ObjectKey{Name: createdCsl.Name, Namespace: createdCsl.Namespace}, csl)
+	csl, err := c.theatreClient.WorkloadsV1alpha1().Consoles(createdCsl.Namespace).Get(ctx, createdCsl.Name, metav1.GetOptions{})
 	if err != nil && !apierrors.IsNotFound(err) {
 		return nil, fmt.Errorf("error retrieving console: %w", err)
 	}
@@ -815,6 +1161,9 @@ func (c *Runner) waitForConsole(ctx context.Context, createdCsl workloadsv1alpha
 	if isPendingAuthorisation(csl) {
 		return csl, consolePendingAuthorisationError
 	}
+	if isScheduled(csl) {
+		return csl, consoleScheduledError
+	}
 	// If the console has already stopped it may have already run to
 	// completion, so let's return it
 	if isStopped(csl) {
@@ -833,8 +1182,13 @@ func (c *Runner) waitForConsole(ctx context.Context, createdCsl workloadsv1alpha
 				return nil, errors.New("watch channel closed")
 			}
 
-			obj := event.Object.(*unstructured.Unstructured)
-			runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), csl)
+			// We can receive *metav1.Status events in the situation where there's an error, in
+			// which case we should exit early.
+			if status, ok := event.Object.(*metav1.Status); ok {
+				return nil, fmt.Errorf("received failure from Kubernetes: %s", status.Reason)
+			}
+
+			csl = event.Object.(*workloadsv1alpha1.Console)
 
 			if isRunning(csl) {
 				return csl, nil
@@ -842,6 +1196,9 @@ func (c *Runner) waitForConsole(ctx context.Context, createdCsl workloadsv1alpha
 			if isPendingAuthorisation(csl) {
 				return csl, consolePendingAuthorisationError
 			}
+			if isScheduled(csl) {
+				return csl, consoleScheduledError
+			}
 			// If the console has already stopped it may have already run to
 			// completion, so let's return it
 			if isStopped(csl) {
@@ -862,7 +1219,7 @@ func (c *Runner) waitForRoleBinding(ctx context.Context, csl *workloadsv1alpha1.
 	}
 
 	rbClient := c.clientset.RbacV1().RoleBindings(csl.Namespace)
-	watcher, err := rbClient.Watch(context.TODO(), metav1.ListOptions{FieldSelector: "metadata.name=" + csl.Name})
+	watcher, err := rbClient.Watch(ctx, metav1.ListOptions{FieldSelector: "metadata.name=" + csl.Name})
 	if err != nil {
 		return fmt.Errorf("error watching rolebindings: %w", err)
 	}
@@ -875,7 +1232,7 @@ func (c *Runner) waitForRoleBinding(ctx context.Context, csl *workloadsv1alpha1.
 	// subsequent loop would block forever.
 	// If the associated RoleBinding exists and has the console user in its
 	// subject list, return early.
-	rb, err := rbClient.Get(context.TODO(), csl.Name, metav1.GetOptions{})
+	rb, err := rbClient.Get(ctx, csl.Name, metav1.GetOptions{})
 	if err == nil && rbHasSubject(rb, csl.Spec.User) {
 		return nil
 	}
@@ -912,8 +1269,7 @@ func rbHasSubject(rb *rbacv1.RoleBinding, subjectName string) bool {
 
 // GetAttachablePod returns an attachable pod for the given console
 func (c *Runner) GetAttachablePod(ctx context.Context, csl *workloadsv1alpha1.Console) (*corev1.Pod, string, error) {
-	pod := &corev1.Pod{}
-	err := c.kubeClient.Get(ctx, client.ObjectKey{Namespace: csl.Namespace, Name: csl.Status.PodName}, pod)
+	pod, err := c.clientset.CoreV1().Pods(csl.Namespace).Get(ctx, csl.Status.PodName, metav1.GetOptions{})
 	if err != nil {
 		return nil, "", err
 	}
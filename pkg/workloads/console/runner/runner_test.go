@@ -0,0 +1,340 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/remotecommand"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	theatrefake "github.com/gocardless/theatre/v2/pkg/client/clientset/versioned/fake"
+	"github.com/gocardless/theatre/v2/pkg/workloads/console/archive"
+)
+
+var errStreamDropped = errors.New("stream dropped")
+
+// attachResult is the outcome of a single attempt by fakeAttacher
+type attachResult struct {
+	connected bool
+	err       error
+}
+
+// fakeAttacher returns each of its results in turn, repeating the last once they run
+// out, telling the tracker of each attempt that connected
+type fakeAttacher struct {
+	results  []attachResult
+	tracker  *connectionTracker
+	attempts int
+}
+
+func (a *fakeAttacher) Attach(context.Context, *corev1.Pod, string, IOStreams) error {
+	result := a.results[len(a.results)-1]
+	if a.attempts < len(a.results) {
+		result = a.results[a.attempts]
+	}
+	a.attempts++
+
+	if result.connected {
+		a.tracker.Connected()
+	}
+
+	return result.err
+}
+
+var _ = Describe("attachUntilFinished", func() {
+	var (
+		pod          *corev1.Pod
+		objects      []runtime.Object
+		attacher     *fakeAttacher
+		tracker      *connectionTracker
+		backoff      wait.Backoff
+		reconnecting []error
+		err          error
+	)
+
+	BeforeEach(func() {
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "staging", Name: "console-abc"},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "console",
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				}},
+			},
+		}
+		objects = nil
+		tracker = &connectionTracker{}
+		attacher = &fakeAttacher{tracker: tracker}
+		backoff = wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 2}
+		reconnecting = nil
+	})
+
+	JustBeforeEach(func() {
+		runner := &Runner{clientset: fake.NewSimpleClientset(append(objects, pod)...)}
+		opts := AttachOptions{
+			ReconnectBackoff: &backoff,
+			Hook: DefaultLifecycleHook{
+				ReconnectingToConsoleFunc: func(_ *workloadsv1alpha1.Console, err error) error {
+					reconnecting = append(reconnecting, err)
+					return nil
+				},
+			},
+		}.WithDefaults()
+
+		err = runner.attachUntilFinished(context.Background(), pod, "console", attacher, tracker, &workloadsv1alpha1.Console{}, opts)
+	})
+
+	Context("When the stream ends normally", func() {
+		BeforeEach(func() {
+			attacher.results = []attachResult{{connected: true}}
+		})
+
+		It("Returns without reconnecting", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(attacher.attempts).To(Equal(1))
+			Expect(reconnecting).To(BeEmpty())
+		})
+	})
+
+	Context("When the stream drops while the container is running", func() {
+		BeforeEach(func() {
+			attacher.results = []attachResult{{connected: true, err: errStreamDropped}, {connected: true}}
+		})
+
+		It("Reconnects", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(attacher.attempts).To(Equal(2))
+			Expect(reconnecting).To(Equal([]error{errStreamDropped}))
+		})
+	})
+
+	Context("When reconnecting keeps failing", func() {
+		BeforeEach(func() {
+			attacher.results = []attachResult{{connected: true, err: errStreamDropped}, {err: errStreamDropped}}
+		})
+
+		It("Gives up once the backoff is exhausted", func() {
+			Expect(err).To(MatchError("failed to attach to console: stream dropped"))
+			Expect(attacher.attempts).To(Equal(3))
+			Expect(reconnecting).To(HaveLen(2))
+		})
+	})
+
+	Context("When reconnected streams drop again", func() {
+		BeforeEach(func() {
+			backoff.Steps = 1
+			attacher.results = []attachResult{
+				{connected: true, err: errStreamDropped},
+				{connected: true, err: errStreamDropped},
+				{connected: true, err: errStreamDropped},
+				{connected: true},
+			}
+		})
+
+		It("Resets the backoff each time a stream is established", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(attacher.attempts).To(Equal(4))
+			Expect(reconnecting).To(HaveLen(3))
+		})
+	})
+
+	Context("When the stream drops because the container has finished", func() {
+		BeforeEach(func() {
+			attacher.results = []attachResult{{connected: true, err: errStreamDropped}}
+			pod.Status.ContainerStatuses[0].State = corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{ExitCode: 0},
+			}
+		})
+
+		It("Returns without reconnecting", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(attacher.attempts).To(Equal(1))
+			Expect(reconnecting).To(BeEmpty())
+		})
+	})
+
+	Context("When the stream drops because the pod has completed", func() {
+		BeforeEach(func() {
+			attacher.results = []attachResult{{connected: true, err: errStreamDropped}}
+			pod.Status.Phase = corev1.PodSucceeded
+		})
+
+		It("Returns without reconnecting", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(attacher.attempts).To(Equal(1))
+			Expect(reconnecting).To(BeEmpty())
+		})
+	})
+
+	Context("When the user detaches", func() {
+		BeforeEach(func() {
+			attacher.results = []attachResult{{connected: true, err: ErrDetached}}
+		})
+
+		It("Returns ErrDetached", func() {
+			Expect(errors.Is(err, ErrDetached)).To(BeTrue())
+			Expect(attacher.attempts).To(Equal(1))
+		})
+	})
+})
+
+var _ = Describe("notifyWhenConnected", func() {
+	var (
+		connections   int
+		streamOptions remotecommand.StreamOptions
+		stdout        bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		connections = 0
+		stdout.Reset()
+		streamOptions = remotecommand.StreamOptions{Stdout: &stdout}
+	})
+
+	JustBeforeEach(func() {
+		notifyWhenConnected(&streamOptions, func() { connections++ })
+	})
+
+	It("Notifies once, when output is first relayed", func() {
+		Expect(connections).To(Equal(0))
+
+		for _, output := range []string{"hello ", "world"} {
+			_, err := streamOptions.Stdout.Write([]byte(output))
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(stdout.String()).To(Equal("hello world"))
+		Expect(connections).To(Equal(1))
+	})
+
+	Context("With a terminal", func() {
+		var cancel func()
+
+		BeforeEach(func() {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			streamOptions.TerminalSizeQueue = newTerminalSizeQueue(ctx, func() *remotecommand.TerminalSize {
+				return &remotecommand.TerminalSize{Width: 80, Height: 24}
+			})
+		})
+
+		AfterEach(func() {
+			cancel()
+		})
+
+		It("Notifies when the terminal size is first requested", func() {
+			Expect(streamOptions.TerminalSizeQueue.Next()).To(Equal(&remotecommand.TerminalSize{Width: 80, Height: 24}))
+			Expect(connections).To(Equal(1))
+		})
+	})
+})
+
+var _ = Describe("Logs", func() {
+	var (
+		dir      string
+		store    archive.LogStore
+		consoles []workloadsv1alpha1.Console
+		opts     LogsOptions
+		output   bytes.Buffer
+		meta     *archive.LogMetadata
+		err      error
+	)
+
+	archiveLogs := func(uid, logs string, archivedAt time.Time) {
+		var buf bytes.Buffer
+		Expect(archive.WriteLogs(&buf, archive.LogMetadata{
+			Console: "console-abc", Namespace: "staging", UID: uid, ArchivedAt: archivedAt,
+		}, strings.NewReader(logs))).To(Succeed())
+
+		key := archive.LogKey("staging", "console-abc", uid)
+		Expect(store.Put(context.TODO(), key, bytes.NewReader(buf.Bytes()), int64(buf.Len()))).To(Succeed())
+		Expect(os.Chtimes(filepath.Join(dir, filepath.FromSlash(key)), archivedAt, archivedAt)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		dir, err = ioutil.TempDir("", "log-archive-")
+		Expect(err).NotTo(HaveOccurred())
+		store = &archive.DirectoryLogStore{Path: dir}
+
+		archiveLogs("first-uid", "first console\n", time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC))
+		archiveLogs("second-uid", "second console\n", time.Date(2020, 1, 2, 12, 0, 0, 0, time.UTC))
+
+		consoles = nil
+		output.Reset()
+		opts = LogsOptions{Namespace: "staging", Name: "console-abc", Archive: store, Output: &output}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	JustBeforeEach(func() {
+		// The object tracker of the generated clientset can't list consoles, as its
+		// scheme registers them under a different group to the one the client uses
+		theatreClient := theatrefake.NewSimpleClientset()
+		theatreClient.PrependReactor("list", "consoles", func(clienttesting.Action) (bool, runtime.Object, error) {
+			return true, &workloadsv1alpha1.ConsoleList{Items: consoles}, nil
+		})
+
+		runner := NewFromClients(fake.NewSimpleClientset(), theatreClient)
+		meta, err = runner.Logs(context.TODO(), opts)
+	})
+
+	Context("When the console has been deleted", func() {
+		It("Reads the most recently archived logs of consoles with the name", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(meta.UID).To(Equal("second-uid"))
+			Expect(output.String()).To(Equal("second console\n"))
+		})
+
+		Context("With a UID", func() {
+			BeforeEach(func() {
+				opts.UID = "first-uid"
+			})
+
+			It("Reads the archived logs of that console", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(meta.UID).To(Equal("first-uid"))
+				Expect(output.String()).To(Equal("first console\n"))
+			})
+		})
+	})
+
+	Context("When the console exists without a pod", func() {
+		BeforeEach(func() {
+			consoles = []workloadsv1alpha1.Console{{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "staging", Name: "console-abc", UID: "first-uid"},
+			}}
+		})
+
+		It("Reads the archived logs of that console", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output.String()).To(Equal("first console\n"))
+		})
+	})
+
+	Context("When no logs were archived for the console", func() {
+		BeforeEach(func() {
+			opts.Name = "console-def"
+		})
+
+		It("Returns ErrLogsNotFound", func() {
+			Expect(errors.Is(err, archive.ErrLogsNotFound)).To(BeTrue())
+		})
+	})
+})
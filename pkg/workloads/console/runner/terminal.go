@@ -0,0 +1,199 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// DefaultEscapeSequence is typed at the start of a line to detach from an interactive
+// console, leaving it running. This matches the convention used by ssh.
+const DefaultEscapeSequence = "~."
+
+// ErrDetached is returned by an attacher when the user has typed the escape sequence
+var ErrDetached = errors.New("detached from console")
+
+// terminalSizeQueue implements remotecommand.TerminalSizeQueue, providing the size of the
+// local terminal when first created and then again whenever the process receives
+// SIGWINCH. It stops monitoring once the context is done, at which point Next returns
+// nil and the remote end stops waiting for resize events.
+type terminalSizeQueue struct {
+	ctx     context.Context
+	size    func() *remotecommand.TerminalSize
+	resized chan remotecommand.TerminalSize
+}
+
+var _ remotecommand.TerminalSizeQueue = &terminalSizeQueue{}
+
+// newTerminalSizeQueue monitors the size of a terminal, as returned by size, which is
+// usually the GetSize method of a term.TTY.
+func newTerminalSizeQueue(ctx context.Context, size func() *remotecommand.TerminalSize) *terminalSizeQueue {
+	q := &terminalSizeQueue{
+		ctx:     ctx,
+		size:    size,
+		resized: make(chan remotecommand.TerminalSize, 1),
+	}
+
+	// Register for the signal before returning, so we can't miss a resize that happens
+	// while the monitoring goroutine is starting.
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+
+	go func() {
+		defer signal.Stop(winch)
+
+		q.push()
+		for {
+			select {
+			case <-winch:
+				q.push()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return q
+}
+
+// push queues the current terminal size, replacing any size that hasn't yet been
+// consumed: only the latest size is of any interest to the remote end.
+func (q *terminalSizeQueue) push() {
+	size := q.size()
+	if size == nil {
+		return
+	}
+
+	select {
+	case <-q.resized:
+	default:
+	}
+
+	select {
+	case q.resized <- *size:
+	default:
+	}
+}
+
+// Next blocks until the terminal has been resized, returning nil once the queue has
+// been stopped.
+func (q *terminalSizeQueue) Next() *remotecommand.TerminalSize {
+	select {
+	case size := <-q.resized:
+		return &size
+	case <-q.ctx.Done():
+		return nil
+	}
+}
+
+// escapeReader wraps the stdin of an interactive session, watching for the escape
+// sequence being typed at the start of a line. Input that partially matches the
+// sequence is held back until we know whether it completes the sequence, and typing
+// the first character of the sequence twice sends it once, allowing the sequence
+// itself to be sent to the console.
+//
+// Once the sequence has been seen, Detached is closed and all subsequent reads return
+// io.EOF, ending the copy of stdin to the attach stream. Consoles' containers don't set
+// stdinOnce, so their process keeps its stdin open, and anyone attaching later can
+// carry on using it.
+type escapeReader struct {
+	in       io.Reader
+	sequence []byte
+	detached chan struct{}
+
+	buffered  []byte
+	matched   int
+	lineStart bool
+}
+
+func newEscapeReader(in io.Reader, sequence string) *escapeReader {
+	return &escapeReader{
+		in:        in,
+		sequence:  []byte(sequence),
+		detached:  make(chan struct{}),
+		lineStart: true,
+	}
+}
+
+// Detached is closed when the escape sequence is typed
+func (r *escapeReader) Detached() <-chan struct{} {
+	return r.detached
+}
+
+func (r *escapeReader) Read(p []byte) (int, error) {
+	for len(r.buffered) == 0 {
+		if r.isDetached() {
+			return 0, io.EOF
+		}
+
+		if len(r.sequence) == 0 {
+			return r.in.Read(p)
+		}
+
+		input := make([]byte, len(p))
+		n, err := r.in.Read(input)
+		r.scan(input[:n])
+
+		if err != nil {
+			// Nothing can complete a partial match now, so pass it on
+			if !r.isDetached() {
+				r.buffered = append(r.buffered, r.sequence[:r.matched]...)
+				r.matched = 0
+			}
+
+			if len(r.buffered) == 0 {
+				return 0, err
+			}
+			break
+		}
+	}
+
+	n := copy(p, r.buffered)
+	r.buffered = r.buffered[n:]
+
+	return n, nil
+}
+
+func (r *escapeReader) isDetached() bool {
+	select {
+	case <-r.detached:
+		return true
+	default:
+		return false
+	}
+}
+
+// scan processes input from the user, appending everything that should be passed on
+// to the console to the buffer.
+func (r *escapeReader) scan(input []byte) {
+	for _, b := range input {
+		if r.lineStart || r.matched > 0 {
+			if b == r.sequence[r.matched] {
+				r.matched++
+				if r.matched == len(r.sequence) {
+					close(r.detached)
+					return
+				}
+
+				continue
+			}
+
+			if r.matched == 1 && b == r.sequence[0] {
+				r.buffered = append(r.buffered, b)
+				r.matched, r.lineStart = 0, false
+				continue
+			}
+
+			r.buffered = append(r.buffered, r.sequence[:r.matched]...)
+			r.matched = 0
+		}
+
+		r.buffered = append(r.buffered, b)
+		r.lineStart = b == '\r' || b == '\n'
+	}
+}
@@ -0,0 +1,178 @@
+package runner
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// chunkReader returns each of its chunks from a separate read, as input typed into a
+// terminal arrives, followed by io.EOF
+type chunkReader struct {
+	chunks []string
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(p, r.chunks[0])
+	if r.chunks[0] = r.chunks[0][n:]; r.chunks[0] == "" {
+		r.chunks = r.chunks[1:]
+	}
+
+	return n, nil
+}
+
+// readAll reads from the reader with a buffer of the given size until it returns an
+// error, which is returned along with everything read
+func readAll(r io.Reader, size int) (string, error) {
+	var output []byte
+	buffer := make([]byte, size)
+	for {
+		n, err := r.Read(buffer)
+		output = append(output, buffer[:n]...)
+		if err != nil {
+			return string(output), err
+		}
+	}
+}
+
+var _ = Describe("escapeReader", func() {
+	DescribeTable("Read",
+		func(sequence string, chunks []string, expected string, detached bool) {
+			for _, size := range []int{1, 64} {
+				reader := newEscapeReader(&chunkReader{chunks: append([]string{}, chunks...)}, sequence)
+				output, err := readAll(reader, size)
+				Expect(err).To(Equal(io.EOF))
+				Expect(output).To(Equal(expected), "reading %d bytes at a time", size)
+				Expect(reader.isDetached()).To(Equal(detached))
+			}
+		},
+		Entry("passes input through", "~.", []string{"ls\n", "exit\n"}, "ls\nexit\n", false),
+		Entry("detaches at the start of input", "~.", []string{"~."}, "", true),
+		Entry("detaches at the start of a line", "~.", []string{"ls\n~."}, "ls\n", true),
+		Entry("detaches after a carriage return", "~.", []string{"ls\r~."}, "ls\r", true),
+		Entry("detaches when the sequence is split across reads", "~.", []string{"ls\n~", "."}, "ls\n", true),
+		Entry("drops input following the sequence", "~.", []string{"~.", "rm -rf /\n"}, "", true),
+		Entry("ignores the sequence within a line", "~.", []string{"echo ~.\n"}, "echo ~.\n", false),
+		Entry("passes on partial prefixes", "~.", []string{"~", "/bin\n"}, "~/bin\n", false),
+		Entry("passes on partial prefixes at the end of input", "~.", []string{"ls\n~"}, "ls\n~", false),
+		Entry("passes on partial prefixes of longer sequences", "~~.", []string{"~~", "x"}, "~~x", false),
+		Entry("sends the first character once when typed twice", "~.", []string{"~", "~."}, "~.", false),
+		Entry("passes everything through without a sequence", "", []string{"~."}, "~.", false),
+	)
+
+	It("Closes Detached once the sequence is typed", func() {
+		reader := newEscapeReader(&chunkReader{chunks: []string{"~."}}, "~.")
+		Expect(reader.Detached()).NotTo(BeClosed())
+
+		_, err := readAll(reader, 64)
+		Expect(err).To(Equal(io.EOF))
+		Expect(reader.Detached()).To(BeClosed())
+	})
+
+	It("Returns EOF, rather than blocking, once detached", func() {
+		reader := newEscapeReader(&chunkReader{chunks: []string{"~.", "more\n"}}, "~.")
+		_, err := readAll(reader, 64)
+		Expect(err).To(Equal(io.EOF))
+
+		n, err := reader.Read(make([]byte, 64))
+		Expect(n).To(Equal(0))
+		Expect(err).To(Equal(io.EOF))
+	})
+})
+
+// fakeTerminal has a size that can be changed while it's being monitored
+type fakeTerminal struct {
+	sync.Mutex
+	size *remotecommand.TerminalSize
+}
+
+func (t *fakeTerminal) GetSize() *remotecommand.TerminalSize {
+	t.Lock()
+	defer t.Unlock()
+	return t.size
+}
+
+func (t *fakeTerminal) Resize(width, height uint16) {
+	t.Lock()
+	defer t.Unlock()
+	t.size = &remotecommand.TerminalSize{Width: width, Height: height}
+}
+
+var _ = Describe("terminalSizeQueue", func() {
+	var (
+		ctx      context.Context
+		cancel   func()
+		terminal *fakeTerminal
+		queue    *terminalSizeQueue
+	)
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		terminal = &fakeTerminal{}
+		terminal.Resize(80, 24)
+	})
+
+	JustBeforeEach(func() {
+		queue = newTerminalSizeQueue(ctx, terminal.GetSize)
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("Delivers the initial size", func() {
+		Expect(queue.Next()).To(Equal(&remotecommand.TerminalSize{Width: 80, Height: 24}))
+	})
+
+	It("Delivers the new size when the terminal is resized", func() {
+		Expect(queue.Next()).To(Equal(&remotecommand.TerminalSize{Width: 80, Height: 24}))
+
+		terminal.Resize(120, 40)
+		Expect(syscall.Kill(os.Getpid(), syscall.SIGWINCH)).To(Succeed())
+		Expect(queue.Next()).To(Equal(&remotecommand.TerminalSize{Width: 120, Height: 40}))
+	})
+
+	It("Only delivers the latest size", func() {
+		Expect(queue.Next()).To(Equal(&remotecommand.TerminalSize{Width: 80, Height: 24}))
+
+		terminal.Resize(100, 30)
+		queue.push()
+		terminal.Resize(120, 40)
+		queue.push()
+
+		Expect(queue.Next()).To(Equal(&remotecommand.TerminalSize{Width: 120, Height: 40}))
+	})
+
+	It("Returns nil once the context is done", func() {
+		Expect(queue.Next()).NotTo(BeNil())
+
+		cancel()
+		Expect(queue.Next()).To(BeNil())
+	})
+
+	Context("When the size is unknown", func() {
+		BeforeEach(func() {
+			terminal = &fakeTerminal{}
+		})
+
+		It("Delivers nothing until the context is done", func() {
+			next := make(chan *remotecommand.TerminalSize)
+			go func() { next <- queue.Next() }()
+
+			Consistently(next, "50ms").ShouldNot(Receive())
+			cancel()
+			Eventually(next).Should(Receive(BeNil()))
+		})
+	})
+})